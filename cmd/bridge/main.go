@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/sc-chat/test-chat/internal/sigctx"
+	"github.com/sc-chat/test-chat/pkg/bridge"
+	"github.com/sc-chat/test-chat/pkg/config"
+)
+
+var (
+	addr      string
+	name      string
+	ircAddr   string
+	channel   string
+	debug     bool
+	tlsConfig config.ClientTLS
+)
+
+// parseFlags function parses command line flags into the variables
+func parseFlags() {
+	flag.StringVar(&addr, "a", "0.0.0.0:8000", "server address")
+	flag.StringVar(&name, "n", "bridge", "bridge name")
+	flag.StringVar(&ircAddr, "i", "", "IRC server address")
+	flag.StringVar(&channel, "c", "#chat", "IRC channel")
+	flag.BoolVar(&debug, "d", false, "debug mode")
+	flag.BoolVar(&tlsConfig.Enabled, "tls", false, "connect to the chat server using TLS")
+	flag.StringVar(&tlsConfig.CA, "tls-ca", "", "CA certificate file verifying the chat server (system roots if empty)")
+	flag.StringVar(&tlsConfig.ServerName, "tls-server-name", "", "name of the chat server in its certificate (host of the address if empty)")
+
+	flag.Parse()
+}
+
+func main() {
+	parseFlags()
+
+	b, err := bridge.NewBridge(addr, name, ircAddr, channel, debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tlsConfig.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	b.TLS, err = tlsConfig.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := sigctx.NewSignalContext(context.Background())
+
+	err = b.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sc-chat/test-chat/internal/sigctx"
+	"github.com/sc-chat/test-chat/pkg/chatctl"
+	"github.com/sc-chat/test-chat/pkg/config"
+)
+
+var (
+	addr      string
+	token     string
+	format    string
+	debug     bool
+	tlsConfig config.ClientTLS
+)
+
+// parseFlags function parses command line flags into the variables
+func parseFlags() {
+	flag.StringVar(&addr, "a", "0.0.0.0:8000", "server address")
+	flag.StringVar(&token, "t", "", "admin token (env "+config.EnvAdminToken+" is preferred)")
+	flag.StringVar(&format, "o", chatctl.FormatTable, "output format (table or json)")
+	flag.BoolVar(&debug, "d", false, "debug mode")
+	flag.BoolVar(&tlsConfig.Enabled, "tls", false, "connect to the server using TLS")
+	flag.StringVar(&tlsConfig.CA, "tls-ca", "", "CA certificate file verifying the server (system roots if empty)")
+	flag.StringVar(&tlsConfig.ServerName, "tls-server-name", "", "name of the server in its certificate (host of the address if empty)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <command> [args]\n\n%s\nFlags:\n", os.Args[0], chatctl.Usage)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if v := os.Getenv(config.EnvAdminToken); v != "" {
+		token = v
+	}
+}
+
+func main() {
+	parseFlags()
+
+	c, err := chatctl.NewCtl(addr, token, format, os.Stdout, debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tlsConfig.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	c.TLS, err = tlsConfig.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := sigctx.NewSignalContext(context.Background())
+
+	err = c.Run(ctx, flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,33 +3,134 @@ package main
 import (
 	"context"
 	"flag"
+	"io/ioutil"
 	"log"
+	"os"
 
+	"github.com/sc-chat/test-chat/internal/logger"
 	"github.com/sc-chat/test-chat/internal/sigctx"
 	"github.com/sc-chat/test-chat/pkg/client"
+	"github.com/sc-chat/test-chat/pkg/config"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+	"github.com/sc-chat/test-chat/pkg/sign"
+	"github.com/sc-chat/test-chat/pkg/trace"
 )
 
-var (
-	addr  string
-	name  string
-	debug bool
-	ms    int
-)
+// bindFlags function binds command line flags to the config, set flags override config file and environment
+func bindFlags(fs *flag.FlagSet, cfg *config.Client) {
+	fs.StringVar(&cfg.Addr, "a", cfg.Addr, "server address")
+	fs.StringVar(&cfg.Name, "n", cfg.Name, "client name")
+	fs.BoolVar(&cfg.TLS.Enabled, "tls", cfg.TLS.Enabled, "connect to the server using TLS")
+	fs.StringVar(&cfg.TLS.CA, "tls-ca", cfg.TLS.CA, "CA certificate file verifying the server (system roots if empty)")
+	fs.StringVar(&cfg.TLS.Cert, "tls-cert", cfg.TLS.Cert, "client certificate file proving the name to the server")
+	fs.StringVar(&cfg.TLS.Key, "tls-key", cfg.TLS.Key, "client certificate key file")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "timeout of connecting to the server")
+	fs.BoolFunc("d", "debug mode", func(string) error {
+		cfg.Log.Level = "debug"
+		return nil
+	})
+	fs.BoolVar(&cfg.Color, "c", cfg.Color, "color client names")
+	fs.StringVar(&cfg.Trace.File, "trace", cfg.Trace.File, "file of trace spans in JSON lines (not kept if empty)")
+	fs.StringVar(&cfg.Key, "key", cfg.Key, "file of device key for direct messages, created if it does not exist (new key for each run if empty)")
+	fs.StringVar(&cfg.SigningKey, "signing-key", cfg.SigningKey, "file of key signing messages, created if it does not exist (new key for each run if empty)")
+	fs.StringVar(&cfg.Pins, "pins", cfg.Pins, "file of signing keys of authors pinned on first use (kept in memory if empty)")
+	fs.StringVar(&cfg.DevicePins, "device-pins", cfg.DevicePins, "file of device keys of direct message recipients pinned on first use (kept in memory if empty)")
+	fs.StringVar(&cfg.Downloads, "downloads", cfg.Downloads, "directory of files downloaded by /get (working directory if empty)")
+}
+
+// parseFlags function returns configuration from defaults, config file, environment and flags in increasing priority
+func parseFlags(args []string) (config.Client, error) {
+	path := os.Getenv(config.EnvFile)
+
+	// config file is located first so that flags can override its values
+	pre := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	pre.SetOutput(ioutil.Discard)
+	pre.StringVar(&path, "config", path, "")
+	bindFlags(pre, new(config.Client))
+	pre.Parse(args)
+
+	cfg := config.DefaultClient()
+	if err := config.Load(path, &cfg, nil); err != nil {
+		return cfg, err
+	}
 
-func init() {
-	flag.StringVar(&addr, "a", "0.0.0.0:8000", "server address")
-	flag.StringVar(&name, "n", "", "client name")
-	flag.BoolVar(&debug, "d", false, "debug mode")
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.String("config", path, "config file in YAML, keys are overridden by "+config.EnvPrefix+"* environment variables and flags (env "+config.EnvFile+")")
+	bindFlags(fs, &cfg)
+	fs.Parse(args)
 
-	flag.Parse()
+	return cfg, cfg.Validate()
 }
 
 func main() {
-	c, err := client.NewClient(addr, name, debug)
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	level, _ := logger.ParseLevel(cfg.Log.Level)
+
+	c, err := client.NewClient(cfg.Addr, cfg.Name, level == logger.DebugLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c.Logger, err = logger.New(os.Stderr, cfg.Log.Format, level)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c.TLS, err = cfg.LoadTLS()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	c.Timeout = cfg.Timeout
+	c.Renderer.Color = cfg.Color
+	c.Downloads = cfg.Downloads
+
+	if cfg.Key != "" {
+		c.Key, err = e2e.LoadKey(cfg.Key)
+	} else {
+		c.Key, err = e2e.GenerateKey()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.SigningKey != "" {
+		c.Signer, err = sign.LoadKey(cfg.SigningKey)
+	} else {
+		c.Signer, err = sign.GenerateKey()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.Pins != "" {
+		c.Pins, err = client.LoadPins(cfg.Pins)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.DevicePins != "" {
+		c.Devices, err = client.LoadPins(cfg.DevicePins)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.Trace.File != "" {
+		f, err := os.OpenFile(cfg.Trace.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		c.Tracer, _ = trace.NewTracer("chat-client", trace.NewFileExporter(f))
+	}
+
 	ctx := sigctx.NewSignalContext(context.Background())
 
 	err = c.Run(ctx)
@@ -3,33 +3,341 @@ package main
 import (
 	"context"
 	"flag"
+	"io/ioutil"
 	"log"
+	"os"
+	"syscall"
+	"time"
 
+	"github.com/sc-chat/test-chat/internal/logger"
 	"github.com/sc-chat/test-chat/internal/sigctx"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/cluster"
+	"github.com/sc-chat/test-chat/pkg/config"
+	"github.com/sc-chat/test-chat/pkg/federation"
+	"github.com/sc-chat/test-chat/pkg/history"
+	"github.com/sc-chat/test-chat/pkg/irc"
+	"github.com/sc-chat/test-chat/pkg/metrics"
+	"github.com/sc-chat/test-chat/pkg/moderation"
 	"github.com/sc-chat/test-chat/pkg/server"
+	"github.com/sc-chat/test-chat/pkg/trace"
 )
 
-var (
-	addr  string
-	debug bool
-)
+// bindFlags function binds command line flags to the config, set flags override config file and environment
+func bindFlags(fs *flag.FlagSet, cfg *config.Server) {
+	fs.StringVar(&cfg.Addr, "a", cfg.Addr, "server address")
+	fs.StringVar(&cfg.TLS.Cert, "tls-cert", cfg.TLS.Cert, "TLS certificate file (connections are not encrypted if empty)")
+	fs.StringVar(&cfg.TLS.Key, "tls-key", cfg.TLS.Key, "TLS private key file")
+	fs.StringVar(&cfg.IRC.Addr, "i", cfg.IRC.Addr, "IRC server address (disabled if empty)")
+	fs.StringVar(&cfg.Metrics.Addr, "m", cfg.Metrics.Addr, "metrics address serving /metrics in Prometheus format (disabled if empty)")
+	fs.StringVar(&cfg.Storage.Redis, "r", cfg.Storage.Redis, "redis address shared by server instances (single instance if empty)")
+	fs.StringVar(&cfg.Auth.AdminToken, "t", cfg.Auth.AdminToken, "admin token (only moderators and admins by role if empty)")
+	fs.StringVar(&cfg.Auth.Roles, "p", cfg.Auth.Roles, "roles file in JSON (all clients are users if empty)")
+	fs.StringVar(&cfg.Moderation.Rules, "mod", cfg.Moderation.Rules, "moderation rules file in JSON (no moderation if empty)")
+	fs.StringVar(&cfg.Moderation.Log, "mod-log", cfg.Moderation.Log, "file of moderation actions log in JSON lines (not kept if empty)")
+	fs.StringVar(&cfg.Trace.File, "trace", cfg.Trace.File, "file of trace spans in JSON lines (not kept if empty)")
+	fs.StringVar(&cfg.Trace.OTLP, "trace-otlp", cfg.Trace.OTLP, "OTLP/HTTP collector url receiving trace spans, e.g. http://localhost:4318/v1/traces")
+	fs.DurationVar(&cfg.Drain, "drain", cfg.Drain, "time given to connected clients between shutdown notice and closing of their streams")
+	fs.StringVar(&cfg.Storage.State, "state", cfg.Storage.State, "file keeping bans and mutes between restarts (not kept if empty)")
+	fs.StringVar(&cfg.Storage.History, "history", cfg.Storage.History, "file of chat history in JSON lines searched by clients (not kept if empty)")
+	fs.StringVar(&cfg.Storage.Files, "files", cfg.Storage.Files, "directory of files uploaded by clients (not accepted if empty)")
+	fs.DurationVar(&cfg.Retention.Interval, "compact", cfg.Retention.Interval, "period of purging history by retention rules (not compacted if zero)")
+	fs.StringVar(&cfg.Audit.File, "audit", cfg.Audit.File, "audit log file (not kept if empty)")
+	fs.Int64Var(&cfg.Audit.MaxBytes, "audit-max", cfg.Audit.MaxBytes, "audit log size in bytes which rotates it (never if 0)")
+	fs.IntVar(&cfg.Audit.Keep, "audit-keep", cfg.Audit.Keep, "number of rotated audit log files to keep")
+	fs.BoolFunc("d", "debug mode (same as -log-level=debug)", func(string) error {
+		cfg.Log.Level = "debug"
+		return nil
+	})
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "log level (debug, info, warn, error), SIGUSR1 toggles debug level")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "log format (text or json)")
+
+	fs.IntVar(&cfg.Limits.MaxMessageBytes, "max-message", cfg.Limits.MaxMessageBytes, "maximum message size in bytes")
+	fs.IntVar(&cfg.Limits.MaxNameLength, "max-name", cfg.Limits.MaxNameLength, "maximum name length in characters")
+
+	fs.Float64Var(&cfg.RateLimits.TokenRate, "rate", cfg.RateLimits.TokenRate, "messages per second of client session (unlimited if 0)")
+	fs.IntVar(&cfg.RateLimits.TokenBurst, "burst", cfg.RateLimits.TokenBurst, "burst of messages of client session")
+	fs.Float64Var(&cfg.RateLimits.NameRate, "name-rate", cfg.RateLimits.NameRate, "messages per second of all client sessions (unlimited if 0)")
+	fs.IntVar(&cfg.RateLimits.NameBurst, "name-burst", cfg.RateLimits.NameBurst, "burst of messages of all client sessions")
+	fs.IntVar(&cfg.RateLimits.Violations, "flood", cfg.RateLimits.Violations, "throttled messages per minute which mute the client (never if 0)")
+	fs.DurationVar(&cfg.RateLimits.MuteFor, "flood-mute", cfg.RateLimits.MuteFor, "mute duration of flooding client")
+
+	fs.StringVar(&cfg.Federation.Addr, "f", cfg.Federation.Addr, "federation address (disabled if empty)")
+	fs.StringVar(&cfg.Federation.Name, "fn", cfg.Federation.Name, "federation server name, must match the certificate")
+	fs.StringVar(&cfg.Federation.Peers, "fp", cfg.Federation.Peers, "federation peers as name=address,name=address")
+	fs.StringVar(&cfg.Federation.Cert, "fcert", cfg.Federation.Cert, "federation certificate file")
+	fs.StringVar(&cfg.Federation.Key, "fkey", cfg.Federation.Key, "federation private key file")
+	fs.StringVar(&cfg.Federation.CA, "fca", cfg.Federation.CA, "federation CA certificate file")
+}
+
+// parseFlags function returns configuration from defaults, config file, environment and flags in increasing priority
+func parseFlags(args []string) (config.Server, error) {
+	path := os.Getenv(config.EnvFile)
 
-func init() {
-	flag.StringVar(&addr, "a", "0.0.0.0:8000", "server address")
-	flag.BoolVar(&debug, "d", false, "debug mode")
+	// config file is located first so that flags can override its values
+	pre := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	pre.SetOutput(ioutil.Discard)
+	pre.StringVar(&path, "config", path, "")
+	bindFlags(pre, new(config.Server))
+	pre.Parse(args)
 
-	flag.Parse()
+	cfg := config.DefaultServer()
+	if err := config.Load(path, &cfg, nil); err != nil {
+		return cfg, err
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.String("config", path, "config file in YAML, keys are overridden by "+config.EnvPrefix+"* environment variables and flags (env "+config.EnvFile+")")
+	bindFlags(fs, &cfg)
+	fs.Parse(args)
+
+	return cfg, cfg.Validate()
 }
 
 func main() {
-	s, err := server.NewServer(addr, debug)
+	cfg, err := parseFlags(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	level, _ := logger.ParseLevel(cfg.Log.Level)
+	debug := level == logger.DebugLevel
+
+	s, err := server.NewServer(cfg.Addr, debug)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.Logger, err = logger.New(os.Stderr, cfg.Log.Format, level)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.TLS, err = cfg.LoadTLS()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	clients := server.NewClientState()
+	clients.StreamBuffer = cfg.Buffers.Stream
+	s.Clients = clients
+	s.Broadcast = make(chan chat.ResponseStream, cfg.Buffers.Broadcast)
+
+	s.AdminToken = cfg.Auth.AdminToken
+	s.RateLimits = server.NewRateLimits(cfg.RateLimits)
+	s.Limits = cfg.Limits
+	s.DrainTimeout = cfg.Drain
+
+	if cfg.Storage.State != "" {
+		s.Restrictions, err = server.LoadRestrictions(cfg.Storage.State)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.StateFile = cfg.Storage.State
+	}
+
+	if cfg.Storage.History != "" {
+		s.History, err = history.OpenStore(cfg.Storage.History)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.History.Close()
+
+		s.History.SetRetention(cfg.Retention.Rules())
+		s.CompactInterval = cfg.Retention.Interval
+	}
+
+	if cfg.Storage.Files != "" {
+		s.Files, err = blob.NewStore(cfg.Storage.Files, cfg.FileQuota)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.Auth.Roles != "" {
+		s.Permissions, err = server.LoadPermissions(cfg.Auth.Roles)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.Moderation.Rules != "" {
+		s.Moderation, err = moderation.LoadPipeline(cfg.Moderation.Rules)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.Moderation.Log != "" {
+		f, err := os.OpenFile(cfg.Moderation.Log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		s.Moderation.Recorder = moderation.NewJSONRecorder(f)
+	}
+
+	if cfg.Audit.File != "" {
+		s.Audit, err = audit.NewLog(cfg.Audit.File, cfg.Audit.MaxBytes, cfg.Audit.Keep)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Audit.Close()
+	}
+
+	if cfg.Storage.Redis != "" {
+		s.Bus = cluster.NewRedisBus(cfg.Storage.Redis)
+	}
+
 	ctx := sigctx.NewSignalContext(context.Background())
 
+	if cfg.Storage.Redis != "" {
+		p := cluster.NewRedisPresence(cfg.Storage.Redis)
+		go p.Run(ctx)
+
+		s.Presence = p
+		s.Directory = p
+	}
+
+	var exporters trace.Exporters
+
+	if cfg.Trace.File != "" {
+		f, err := os.OpenFile(cfg.Trace.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+
+		exporters = append(exporters, trace.NewFileExporter(f))
+	}
+
+	if cfg.Trace.OTLP != "" {
+		e, err := trace.NewOTLPExporter(cfg.Trace.OTLP)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer e.Flush()
+
+		s.Metrics.NewCounterFunc("chat_dropped_spans_total", "Number of spans not exported because the OTLP queue was full.", func() float64 {
+			return float64(e.Dropped())
+		})
+
+		report := func(err error) {
+			s.Logger.Error("Failed to export spans", "err", err)
+		}
+
+		go func() {
+			if err := e.Run(ctx.Done(), 5*time.Second, report); err != nil {
+				report(err)
+			}
+		}()
+
+		exporters = append(exporters, e)
+	}
+
+	if len(exporters) > 0 {
+		s.Tracer, _ = trace.NewTracer("chat-server", exporters)
+	}
+
+	go logger.ToggleOnSignal(ctx, s.Logger, syscall.SIGUSR1)
+
+	go func() {
+		for range sigctx.NewReloadChannel(ctx) {
+			s.Reload(loadSettings)
+		}
+	}()
+
+	if cfg.IRC.Addr != "" {
+		i, err := irc.NewServer(cfg.IRC.Addr, s, debug)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		i.Logger = s.Logger.With("component", "irc")
+
+		go func() {
+			if err := i.Run(ctx); err != nil {
+				i.Logger.Error("IRC server error", "err", err)
+			}
+		}()
+	}
+
+	if cfg.Metrics.Addr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.Metrics.Addr, s.Metrics); err != nil {
+				s.Logger.Error("Metrics server error", "err", err)
+			}
+		}()
+	}
+
+	if cfg.Federation.Addr != "" {
+		f, err := newFederation(cfg.Federation, s, debug)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		f.Logger = s.Logger.With("component", "federation")
+
+		go func() {
+			if err := f.Run(ctx); err != nil {
+				f.Logger.Error("Federation error", "err", err)
+			}
+		}()
+	}
+
 	err = s.Run(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// loadSettings function reads configuration again and returns its parts which can be changed without restart
+func loadSettings() (server.Settings, error) {
+	var settings server.Settings
+
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		return settings, err
+	}
+
+	settings.RateLimits = cfg.RateLimits
+	settings.Level, _ = logger.ParseLevel(cfg.Log.Level)
+
+	retention := cfg.Retention.Rules()
+	settings.Retention = &retention
+
+	if cfg.Storage.State != "" {
+		if settings.Restrictions, err = server.LoadRestrictions(cfg.Storage.State); err != nil {
+			return settings, err
+		}
+	}
+
+	if cfg.Moderation.Rules != "" {
+		rules, err := moderation.ReadConfig(cfg.Moderation.Rules)
+		if err != nil {
+			return settings, err
+		}
+		settings.Moderation = &rules
+	}
+
+	settings.Certificate, err = cfg.LoadCertificate()
+
+	return settings, err
+}
+
+func newFederation(cfg config.Federation, s *server.Server, debug bool) (*federation.Federation, error) {
+	tlsConfig, err := federation.LoadTLS(cfg.Cert, cfg.Key, cfg.CA)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := federation.ParsePeers(cfg.Peers)
+	if err != nil {
+		return nil, err
+	}
+
+	return federation.NewFederation(cfg.Name, cfg.Addr, tlsConfig, peers, s, debug)
+}
@@ -2,3 +2,6 @@ package constants
 
 // TokenHeader provides header name for token transfer
 const TokenHeader = "x-token"
+
+// AdminTokenHeader provides header name for admin token transfer
+const AdminTokenHeader = "x-admin-token"
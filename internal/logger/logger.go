@@ -0,0 +1,278 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Level of log entries
+type Level int32
+
+// log levels
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String method returns name of the level
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return "level(" + strconv.Itoa(int(l)) + ")"
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel function returns level by its name
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return Level(i), nil
+		}
+	}
+
+	return InfoLevel, errors.Errorf("Unknown log level %q", name)
+}
+
+// output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// output is shared by logger and its children
+type output struct {
+	w      io.Writer
+	format string
+	level  int32
+	mtx    sync.Mutex
+}
+
+// Logger writes leveled entries with key-value fields
+// fields are given as alternating keys and values, e.g. Info("Logged in", "user", name)
+// values of keys ending with "token" are replaced by token prefix so that tokens are never logged in full
+type Logger struct {
+	out    *output
+	fields []interface{}
+}
+
+// With method returns child logger which adds fields to each entry, level is shared with the parent
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+
+	return &Logger{out: l.out, fields: fields}
+}
+
+// SetLevel method changes minimal level of written entries, returns previous level
+func (l *Logger) SetLevel(level Level) Level {
+	return Level(atomic.SwapInt32(&l.out.level, int32(level)))
+}
+
+// Level method returns minimal level of written entries
+func (l *Logger) Level() Level {
+	return Level(atomic.LoadInt32(&l.out.level))
+}
+
+// Enabled method returns true if entries of the level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// Debug method writes debug entry
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(DebugLevel, msg, keyvals)
+}
+
+// Info method writes info entry
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(InfoLevel, msg, keyvals)
+}
+
+// Warn method writes warning entry
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(WarnLevel, msg, keyvals)
+}
+
+// Error method writes error entry
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(ErrorLevel, msg, keyvals)
+}
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := append(append([]interface{}{}, l.fields...), keyvals...)
+	if len(fields)%2 != 0 {
+		fields = append(fields, "(missing)")
+	}
+
+	now := time.Now().UTC()
+
+	var line []byte
+	if l.out.format == FormatJSON {
+		line = formatJSON(now, level, msg, fields)
+	} else {
+		line = formatText(now, level, msg, fields)
+	}
+
+	l.out.mtx.Lock()
+	defer l.out.mtx.Unlock()
+
+	l.out.w.Write(line)
+}
+
+// field function returns key and value of the field as written
+func field(key, value interface{}) (string, interface{}) {
+	k := fmt.Sprint(key)
+
+	if strings.HasSuffix(k, "token") {
+		return k + "_prefix", TokenPrefix(fmt.Sprint(value))
+	}
+
+	switch v := value.(type) {
+	case error:
+		return k, v.Error()
+	case fmt.Stringer:
+		return k, v.String()
+	}
+
+	return k, value
+}
+
+func formatText(now time.Time, level Level, msg string, fields []interface{}) []byte {
+	var b strings.Builder
+
+	b.WriteString(now.Format("2006-01-02T15:04:05.000Z07:00"))
+	b.WriteByte(' ')
+	b.WriteString(strings.ToUpper(level.String()))
+	b.WriteByte(' ')
+	b.WriteString(msg)
+
+	for i := 0; i < len(fields); i += 2 {
+		k, v := field(fields[i], fields[i+1])
+
+		s := fmt.Sprint(v)
+		if s == "" || strings.ContainsAny(s, " \"=\t\r\n") {
+			s = strconv.Quote(s)
+		}
+
+		b.WriteByte(' ')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(s)
+	}
+
+	b.WriteByte('\n')
+
+	return []byte(b.String())
+}
+
+func formatJSON(now time.Time, level Level, msg string, fields []interface{}) []byte {
+	// keys are kept in order of fields
+	var b strings.Builder
+
+	b.WriteString(`{"time":`)
+	writeJSON(&b, now.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, level.String())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, msg)
+
+	for i := 0; i < len(fields); i += 2 {
+		k, v := field(fields[i], fields[i+1])
+
+		b.WriteByte(',')
+		writeJSON(&b, k)
+		b.WriteByte(':')
+		writeJSON(&b, v)
+	}
+
+	b.WriteString("}\n")
+
+	return []byte(b.String())
+}
+
+func writeJSON(b *strings.Builder, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+
+	b.Write(data)
+}
+
+// TokenPrefix function returns beginning of the token which identifies session in logs
+// short tokens are not written at all
+func TokenPrefix(token string) string {
+	if len(token) < 32 {
+		return "***"
+	}
+
+	return token[:8]
+}
+
+// ToggleOnSignal function switches logger between debug and its level on each signal until the context is done
+func ToggleOnSignal(ctx context.Context, l *Logger, sig ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig...)
+	defer signal.Stop(ch)
+
+	level := l.Level()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if l.Level() != DebugLevel {
+				level = l.SetLevel(DebugLevel)
+			} else {
+				l.SetLevel(level)
+			}
+
+			l.Info("Log level is changed", "level", l.Level())
+		}
+	}
+}
+
+// New returns Logger pointer
+func New(w io.Writer, format string, level Level) (*Logger, error) {
+	if format != FormatText && format != FormatJSON {
+		return nil, errors.Errorf("Unknown log format %q", format)
+	}
+
+	return &Logger{
+		out: &output{w: w, format: format, level: int32(level)},
+	}, nil
+}
+
+// NewLogger returns Logger pointer writing text to stderr, debug entries are written only in debug mode
+func NewLogger(debug bool) *Logger {
+	level := InfoLevel
+	if debug {
+		level = DebugLevel
+	}
+
+	l, _ := New(os.Stderr, FormatText, level)
+
+	return l
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const token = "50d858e0985ecc7f60418aaf0cc5ab587f42c2570a884095a9e8ccacd0f6545c"
+
+func TestLevels(t *testing.T) {
+	out := new(bytes.Buffer)
+	l, _ := New(out, FormatText, WarnLevel)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	if lines := strings.Count(out.String(), "\n"); lines != 2 {
+		t.Errorf("Lines should be %d but got %d", 2, lines)
+	}
+
+	child := l.With("component", "irc")
+	if previous := child.SetLevel(DebugLevel); previous != WarnLevel {
+		t.Errorf("Previous level should be %s but got %s", WarnLevel, previous)
+	}
+
+	if !l.Enabled(DebugLevel) {
+		t.Error("Level should be shared with children")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		name  string
+		level Level
+		err   bool
+	}{
+		{name: "debug", level: DebugLevel},
+		{name: "INFO", level: InfoLevel},
+		{name: "warn", level: WarnLevel},
+		{name: "error", level: ErrorLevel},
+		{name: "verbose", level: InfoLevel, err: true},
+	}
+
+	for _, tc := range cases {
+		level, err := ParseLevel(tc.name)
+		if tc.level != level || tc.err != (err != nil) {
+			t.Errorf("Level of %q should be %s but got %s (%v)", tc.name, tc.level, level, err)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	out := new(bytes.Buffer)
+	l, _ := New(out, FormatText, DebugLevel)
+
+	l.With("component", "server").Info("Client has logged in", "user", "Bob Smith", "token", token, "err", errors.New("oops"))
+
+	line := out.String()
+	for _, part := range []string{" INFO Client has logged in", `component=server`, `user="Bob Smith"`, "token_prefix=50d858e0", "err=oops"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("Line should contain %q but got %q", part, line)
+		}
+	}
+
+	if strings.Contains(line, token) {
+		t.Errorf("Line should not contain full token but got %q", line)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	out := new(bytes.Buffer)
+	l, _ := New(out, FormatJSON, DebugLevel)
+
+	l.Warn("Peer is unavailable", "peer", "b.example.com", "admin_token", "secret", "odd")
+
+	var entry map[string]string
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"level":              "warn",
+		"msg":                "Peer is unavailable",
+		"peer":               "b.example.com",
+		"admin_token_prefix": "***",
+		"odd":                "(missing)",
+	}
+
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("Field %s should be %q but got %q", k, v, entry[k])
+		}
+	}
+
+	if _, err := New(out, "xml", InfoLevel); err == nil {
+		t.Error("Unknown format should be rejected")
+	}
+}
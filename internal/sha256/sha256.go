@@ -3,6 +3,10 @@ package sha256
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"strings"
 )
 
 // NewHash returns sha256 hash of provided string
@@ -11,3 +15,98 @@ func NewHash(s string) string {
 	h.Write([]byte(s))
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// New returns sha256 hash.Hash
+func New() hash.Hash {
+	return sha256.New()
+}
+
+// Fingerprint returns first half of sha256 hash of the data in groups of 4 hex digits to be compared by people
+func Fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	digits := hex.EncodeToString(sum[:16])
+
+	groups := make([]string, 0, len(digits)/4)
+	for i := 0; i < len(digits); i += 4 {
+		groups = append(groups, digits[i:i+4])
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// Reader hashes data read through it
+type Reader struct {
+	r io.Reader
+	h hash.Hash
+	n int64
+}
+
+// Read method reads from underlying reader and adds read data to the hash
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	r.n += int64(n)
+	return n, err
+}
+
+// Hash method returns sha256 hash of data read so far
+func (r *Reader) Hash() string {
+	return hex.EncodeToString(r.h.Sum(nil))
+}
+
+// Size method returns number of bytes read so far
+func (r *Reader) Size() int64 {
+	return r.n
+}
+
+// NewReader returns Reader pointer
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r, h: sha256.New()}
+}
+
+// Writer hashes data written through it
+type Writer struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+// Write method writes to underlying writer and adds written data to the hash
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.h.Write(p[:n])
+	w.n += int64(n)
+	return n, err
+}
+
+// Hash method returns sha256 hash of data written so far
+func (w *Writer) Hash() string {
+	return hex.EncodeToString(w.h.Sum(nil))
+}
+
+// Size method returns number of bytes written so far
+func (w *Writer) Size() int64 {
+	return w.n
+}
+
+// NewWriter returns Writer pointer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, h: sha256.New()}
+}
+
+// HashReader returns sha256 hash of all data of the reader
+func HashReader(r io.Reader) (string, error) {
+	h := NewReader(r)
+	_, err := io.Copy(ioutil.Discard, h)
+	return h.Hash(), err
+}
+
+// Valid returns true if provided string is hex encoded sha256 hash
+func Valid(s string) bool {
+	if len(s) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
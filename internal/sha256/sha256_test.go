@@ -1,6 +1,9 @@
 package sha256
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestNewHash(t *testing.T) {
 	str := "example"
@@ -11,3 +14,49 @@ func TestNewHash(t *testing.T) {
 		t.Errorf("Hash should be %s but got %s\n", expectedHash, hash)
 	}
 }
+
+func TestReader(t *testing.T) {
+	expectedHash := "50d858e0985ecc7f60418aaf0cc5ab587f42c2570a884095a9e8ccacd0f6545c"
+
+	hash, err := HashReader(strings.NewReader("example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expectedHash != hash {
+		t.Errorf("Hash should be %s but got %s\n", expectedHash, hash)
+	}
+
+	var buf strings.Builder
+	w := NewWriter(&buf)
+	w.Write([]byte("exam"))
+	w.Write([]byte("ple"))
+
+	if hash := w.Hash(); expectedHash != hash || w.Size() != 7 || buf.String() != "example" {
+		t.Errorf("Hash should be %s but got %s\n", expectedHash, hash)
+	}
+
+	cases := []struct {
+		hash  string
+		valid bool
+	}{
+		{hash: expectedHash, valid: true},
+		{hash: strings.ToUpper(expectedHash), valid: true},
+		{hash: expectedHash[:10], valid: false},
+		{hash: strings.Replace(expectedHash, "5", "x", 1), valid: false},
+	}
+
+	for _, tc := range cases {
+		if valid := Valid(tc.hash); tc.valid != valid {
+			t.Errorf("Hash %s should be valid %t but got %t\n", tc.hash, tc.valid, valid)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	expected := "50d8 58e0 985e cc7f 6041 8aaf 0cc5 ab58"
+
+	if fingerprint := Fingerprint([]byte("example")); expected != fingerprint {
+		t.Errorf("Fingerprint should be %s but got %s", expected, fingerprint)
+	}
+}
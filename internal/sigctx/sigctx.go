@@ -23,3 +23,30 @@ func NewSignalContext(ctx context.Context) context.Context {
 
 	return ctx
 }
+
+// NewReloadChannel returns channel which receives value on each SIGHUP signal until the context is done
+// signals received during reload are coalesced
+func NewReloadChannel(ctx context.Context) <-chan struct{} {
+	reload := make(chan struct{}, 1)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigs)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigs:
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return reload
+}
@@ -0,0 +1,233 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+)
+
+// event types
+const (
+	Login      = "login"
+	Logout     = "logout"
+	AuthFailed = "auth_failed"
+	Kick       = "kick"
+	Ban        = "ban"
+	Unban      = "unban"
+	Mute       = "mute"
+	Unmute     = "unmute"
+	Announce   = "announce"
+	Reload     = "reload"
+	LogLevel   = "log_level"
+	Export     = "export"
+	Import     = "import"
+	Upload     = "upload"
+)
+
+// Event is single record of the audit log
+// each event contains hash of the previous one so that changed, removed or reordered events are detected
+type Event struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Actor  string    `json:"actor,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+	Prev   string    `json:"prev"`
+	Hash   string    `json:"hash"`
+}
+
+// hash method returns hash of the event without its own hash
+func (e Event) hash() (string, error) {
+	e.Hash = ""
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	return sha256.NewHash(string(data)), nil
+}
+
+// Log writes events as JSON lines with hash chain
+// the file is rotated to path.1, path.2 and so on when it exceeds max size
+type Log struct {
+	path     string
+	maxBytes int64
+	keep     int
+
+	f    *os.File
+	size int64
+	seq  uint64
+	prev string
+	mtx  sync.Mutex
+}
+
+// Record method appends event to the log
+func (l *Log) Record(typ, actor, target, detail string) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.maxBytes > 0 && l.size >= l.maxBytes {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	e := Event{
+		Seq:    l.seq + 1,
+		Time:   time.Now().UTC(),
+		Type:   typ,
+		Actor:  actor,
+		Target: target,
+		Detail: detail,
+		Prev:   l.prev,
+	}
+
+	var err error
+	if e.Hash, err = e.hash(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	n, err := l.f.Write(append(data, '\n'))
+	l.size += int64(n)
+	if err != nil {
+		return errors.WithMessage(err, "failed to write audit event")
+	}
+
+	l.seq = e.Seq
+	l.prev = e.Hash
+
+	return nil
+}
+
+// Close method closes the log file
+func (l *Log) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.f.Close()
+}
+
+// rotate method shifts rotated files, removes the oldest one and starts new file
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+
+	if l.keep > 0 {
+		os.Remove(rotated(l.path, l.keep))
+
+		for i := l.keep - 1; i > 0; i-- {
+			os.Rename(rotated(l.path, i), rotated(l.path, i+1))
+		}
+
+		if err := os.Rename(l.path, rotated(l.path, 1)); err != nil {
+			return errors.WithMessage(err, "failed to rotate audit log")
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return errors.WithMessage(err, "failed to rotate audit log")
+	}
+
+	return l.open()
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.WithMessage(err, "failed to open audit log")
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.f = f
+	l.size = st.Size()
+
+	return nil
+}
+
+// rotated function returns path of rotated file with index i
+func rotated(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// lastEvent function returns the last event of the file, false if the file has no events
+func lastEvent(path string) (Event, bool, error) {
+	var e Event
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return e, false, nil
+	} else if err != nil {
+		return e, false, err
+	}
+	defer f.Close()
+
+	var last []byte
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, maxLine)
+	for sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			last = append(last[:0], sc.Bytes()...)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return e, false, err
+	}
+
+	if last == nil {
+		return e, false, nil
+	}
+
+	if err := json.Unmarshal(last, &e); err != nil {
+		return e, false, errors.WithMessage(err, "invalid last event of "+path)
+	}
+
+	return e, true, nil
+}
+
+// NewLog returns Log pointer, the hash chain of existing log is continued
+// maxBytes of zero disables rotation, keep is number of rotated files to keep
+func NewLog(path string, maxBytes int64, keep int) (*Log, error) {
+	l := &Log{
+		path:     path,
+		maxBytes: maxBytes,
+		keep:     keep,
+	}
+
+	// current file can be empty right after rotation
+	for _, p := range []string{path, rotated(path, 1)} {
+		e, ok, err := lastEvent(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			l.seq, l.prev = e.Seq, e.Hash
+			break
+		}
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
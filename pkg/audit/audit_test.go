@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLog(t *testing.T, maxBytes int64, keep int) (*Log, string, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "audit.log")
+	l, err := NewLog(path, maxBytes, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l, path, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLogVerify(t *testing.T) {
+	l, path, cleanup := newTestLog(t, 0, 0)
+	defer cleanup()
+
+	l.Record(Login, "Bob", "", "session 01234567")
+	l.Record(Kick, "admin", "Bob", "")
+	l.Record(Ban, "admin", "Eve", "for 1h0m0s")
+
+	res, err := VerifyFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Events != 3 || !res.Anchored {
+		t.Errorf("Result should be 3 anchored events but got %+v", res)
+	}
+
+	// chain is continued after reopening
+	l.Close()
+	l, err = NewLog(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(Logout, "Bob", "", "")
+
+	if res, err := VerifyFiles(path); err != nil || res.Events != 4 {
+		t.Errorf("Result should be 4 events but got %+v (%v)", res, err)
+	}
+}
+
+func TestVerifyTampering(t *testing.T) {
+	l, path, cleanup := newTestLog(t, 0, 0)
+	defer cleanup()
+
+	for _, name := range []string{"Alice", "Bob", "Carol", "Dave"} {
+		l.Record(Login, name, "", "")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+
+	cases := []struct {
+		name string
+		log  string
+		err  string
+	}{
+		{name: "valid", log: string(data)},
+		{name: "changed", log: strings.Replace(string(data), `"actor":"Bob"`, `"actor":"Eve"`, 1), err: "hash mismatch"},
+		{name: "removed", log: lines[0] + lines[2] + lines[3], err: "does not follow"},
+		{name: "reordered", log: lines[0] + lines[2] + lines[1] + lines[3], err: "does not follow"},
+		{name: "garbage", log: lines[0] + "garbage\n", err: "invalid event"},
+	}
+
+	for _, tc := range cases {
+		_, err := Verify(bytes.NewBufferString(tc.log))
+
+		if tc.err == "" && err != nil {
+			t.Errorf("Log %s should be valid but got %v", tc.name, err)
+		} else if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("Error of %s log should contain %q but got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	l, path, cleanup := newTestLog(t, 300, 2)
+	defer cleanup()
+
+	for i := 0; i < 20; i++ {
+		if err := l.Record(Login, "Bob", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := logFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 || files[0] != path+".2" || files[2] != path {
+		t.Fatalf("Files should be [%s.2 %s.1 %s] but got %v", path, path, path, files)
+	}
+
+	res, err := VerifyFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// oldest events are removed with rotated files
+	if res.Anchored || res.Events >= 20 {
+		t.Errorf("Result should be not anchored and have less than 20 events but got %+v", res)
+	}
+}
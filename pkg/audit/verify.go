@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxLine is maximum size of single event
+const maxLine = 1024 * 1024
+
+// Result of the log verification
+type Result struct {
+	Files  []string `json:"files"`
+	Events int      `json:"events"`
+	// Anchored is false if the first verified event follows an event which is not available anymore
+	// e.g. because the oldest rotated file has been removed
+	Anchored bool   `json:"anchored"`
+	Last     string `json:"last"`
+}
+
+// chain verifies events one by one
+type chain struct {
+	seq      uint64
+	prev     string
+	started  bool
+	anchored bool
+	events   int
+}
+
+// next method checks event against the previous one
+func (c *chain) next(e Event) error {
+	hash, err := e.hash()
+	if err != nil {
+		return err
+	}
+
+	if hash != e.Hash {
+		return errors.Errorf("hash mismatch of event %d, the event is changed", e.Seq)
+	}
+
+	if !c.started {
+		c.anchored = e.Seq == 1 && e.Prev == ""
+	} else {
+		if e.Prev != c.prev {
+			return errors.Errorf("event %d does not follow event %d, events are removed or reordered", e.Seq, c.seq)
+		}
+
+		if e.Seq != c.seq+1 {
+			return errors.Errorf("event %d follows event %d, events are removed", e.Seq, c.seq)
+		}
+	}
+
+	c.started = true
+	c.seq = e.Seq
+	c.prev = e.Hash
+	c.events++
+
+	return nil
+}
+
+// verify method checks events of the reader, name is used in errors
+func (c *chain) verify(r io.Reader, name string) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxLine)
+
+	var line int
+	for sc.Scan() {
+		line++
+
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return errors.Errorf("%s:%d: invalid event: %v", name, line, err)
+		}
+
+		if err := c.next(e); err != nil {
+			return errors.Errorf("%s:%d: %v", name, line, err)
+		}
+	}
+
+	return sc.Err()
+}
+
+// Verify function checks hash chain of events of the reader
+func Verify(r io.Reader) (Result, error) {
+	var c chain
+
+	err := c.verify(r, "input")
+
+	return c.result(nil), err
+}
+
+// VerifyFiles function checks hash chain of the log and its rotated files from the oldest one
+func VerifyFiles(path string) (Result, error) {
+	files, err := logFiles(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var c chain
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return c.result(files), err
+		}
+
+		err = c.verify(f, file)
+		f.Close()
+
+		if err != nil {
+			return c.result(files), err
+		}
+	}
+
+	return c.result(files), nil
+}
+
+func (c *chain) result(files []string) Result {
+	return Result{
+		Files:    files,
+		Events:   c.events,
+		Anchored: c.events == 0 || c.anchored,
+		Last:     c.prev,
+	}
+}
+
+// logFiles function returns rotated files of the log from the oldest one and the log itself
+func logFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []int
+	for _, m := range matches {
+		if i, err := strconv.Atoi(strings.TrimPrefix(m, path+".")); err == nil && i > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	var files []string
+	for _, i := range indexes {
+		files = append(files, rotated(path, i))
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	} else if len(files) == 0 {
+		return nil, err
+	}
+
+	return files, nil
+}
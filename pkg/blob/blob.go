@@ -0,0 +1,301 @@
+package blob
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+)
+
+// ChunkSize is size of file chunks sent over the chat protocol
+const ChunkSize = 64 << 10
+
+// maxNameLength limits length of file names
+const maxNameLength = 255
+
+// metaExt is extension of files with metadata of blobs
+const metaExt = ".json"
+
+var (
+	// ErrNotFound is returned when blob with the id is not kept
+	ErrNotFound = errors.New("File is not found")
+
+	// ErrQuota is returned when file does not fit quota
+	ErrQuota = errors.New("File exceeds quota")
+
+	// ErrSizeMismatch is returned when received data differs from declared size
+	ErrSizeMismatch = errors.New("File size does not match")
+
+	// ErrHashMismatch is returned when received data differs from declared hash
+	ErrHashMismatch = errors.New("File hash does not match")
+
+	// ErrInvalidFile is returned when name, size or hash of the file are invalid
+	ErrInvalidFile = errors.New("Invalid file")
+)
+
+// Blob is file kept by the store
+type Blob struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Owner  string    `json:"owner"`
+	Size   int64     `json:"size"`
+	SHA256 string    `json:"sha256"`
+	Time   time.Time `json:"time"`
+}
+
+// Quota limits kept files, zero values are not limited
+type Quota struct {
+	// MaxFileBytes limits size of single file
+	MaxFileBytes int64 `yaml:"max_file_bytes"`
+	// MaxUserBytes limits size of all files of single owner
+	MaxUserBytes int64 `yaml:"max_user_bytes"`
+	// MaxTotalBytes limits size of all files
+	MaxTotalBytes int64 `yaml:"max_total_bytes"`
+}
+
+// Store keeps files on local disk, each file is kept with metadata file next to it
+type Store struct {
+	dir   string
+	quota Quota
+	blobs map[string]Blob
+	// used is size of kept and currently received files by owner
+	used  map[string]int64
+	total int64
+	mtx   sync.RWMutex
+}
+
+// Put method keeps file read from the reader
+// declared size and hash are checked against received data, quota is checked before the file is received
+func (s *Store) Put(owner, name string, size int64, hash string, r io.Reader) (Blob, error) {
+	name, err := ValidateName(name)
+	if err != nil {
+		return Blob{}, err
+	}
+
+	if size < 0 || !sha256.Valid(hash) {
+		return Blob{}, ErrInvalidFile
+	}
+
+	if err := s.reserve(owner, size); err != nil {
+		return Blob{}, err
+	}
+
+	b := Blob{
+		ID:     newID(),
+		Name:   name,
+		Owner:  owner,
+		Size:   size,
+		SHA256: strings.ToLower(hash),
+		Time:   time.Now().UTC(),
+	}
+
+	if err := s.write(b, r); err != nil {
+		s.release(owner, size)
+		return Blob{}, err
+	}
+
+	s.mtx.Lock()
+	s.blobs[b.ID] = b
+	s.mtx.Unlock()
+
+	return b, nil
+}
+
+// write method receives data of the blob and writes the blob with its metadata
+func (s *Store) write(b Blob, r io.Reader) error {
+	tmp, err := ioutil.TempFile(s.dir, b.ID+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.NewReader(io.LimitReader(r, b.Size+1))
+	if _, err := io.Copy(tmp, h); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if h.Size() != b.Size {
+		return ErrSizeMismatch
+	}
+
+	if h.Hash() != b.SHA256 {
+		return ErrHashMismatch
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(b.ID)); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	// blob without metadata is not loaded, so metadata is written last
+	return ioutil.WriteFile(s.path(b.ID)+metaExt, data, 0600)
+}
+
+// reserve method adds size of the file to usage if it fits quota
+func (s *Store) reserve(owner string, size int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch {
+	case s.quota.MaxFileBytes > 0 && size > s.quota.MaxFileBytes,
+		s.quota.MaxUserBytes > 0 && s.used[owner]+size > s.quota.MaxUserBytes,
+		s.quota.MaxTotalBytes > 0 && s.total+size > s.quota.MaxTotalBytes:
+		return ErrQuota
+	}
+
+	s.used[owner] += size
+	s.total += size
+
+	return nil
+}
+
+// release method removes size of the file from usage
+func (s *Store) release(owner string, size int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.used[owner] -= size
+	s.total -= size
+}
+
+// Get method returns metadata of the blob, false if the blob is not kept
+func (s *Store) Get(id string) (Blob, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	b, ok := s.blobs[id]
+	return b, ok
+}
+
+// Open method returns metadata and content of the blob, content must be closed by the caller
+func (s *Store) Open(id string) (Blob, io.ReadCloser, error) {
+	b, ok := s.Get(id)
+	if !ok {
+		return Blob{}, nil, ErrNotFound
+	}
+
+	f, err := os.Open(s.path(id))
+	if err != nil {
+		return Blob{}, nil, err
+	}
+
+	return b, f, nil
+}
+
+// Usage method returns number and size of kept files
+func (s *Store) Usage() (int, int64) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return len(s.blobs), s.total
+}
+
+// Used method returns size of files of the owner
+func (s *Store) Used(owner string) int64 {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.used[owner]
+}
+
+// Check method returns error if files can not be written to the directory
+func (s *Store) Check() error {
+	tmp, err := ioutil.TempFile(s.dir, ".check")
+	if err != nil {
+		return errors.WithMessage(err, "files directory is not writable")
+	}
+	tmp.Close()
+
+	return os.Remove(tmp.Name())
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// load method reads metadata of kept blobs
+func (s *Store) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+metaExt))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var b Blob
+		if err := json.Unmarshal(data, &b); err != nil {
+			return errors.WithMessage(err, "invalid metadata "+path)
+		}
+
+		if _, err := os.Stat(s.path(b.ID)); err != nil {
+			return errors.WithMessage(err, "missing file of "+path)
+		}
+
+		s.blobs[b.ID] = b
+		s.used[b.Owner] += b.Size
+		s.total += b.Size
+	}
+
+	return nil
+}
+
+// ValidateName function returns base name of the file, error if the name is empty or too long
+func ValidateName(name string) (string, error) {
+	name = filepath.Base(filepath.Clean("/" + strings.Replace(name, "\\", "/", -1)))
+	if name == "/" || name == "." || len(name) > maxNameLength || strings.ContainsAny(name, "\x00\n\r") {
+		return "", ErrInvalidFile
+	}
+
+	return name, nil
+}
+
+// newID function returns random hex id of a blob
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewStore returns Store pointer
+// directory is created if it does not exist and blobs kept in it are loaded
+func NewStore(dir string, quota Quota) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithMessage(err, "failed to create files directory")
+	}
+
+	s := &Store{
+		dir:   dir,
+		quota: quota,
+		blobs: make(map[string]Blob),
+		used:  make(map[string]int64),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, errors.WithMessage(err, "failed to load files")
+	}
+
+	return s, nil
+}
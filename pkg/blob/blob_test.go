@@ -0,0 +1,86 @@
+package blob
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+)
+
+func TestPut(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewStore(dir, Quota{MaxFileBytes: 10, MaxUserBytes: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "0123456789"
+	hash := sha256.NewHash(content)
+
+	cases := []struct {
+		name     string
+		file     string
+		size     int64
+		hash     string
+		data     string
+		expected error
+	}{
+		{name: "valid", file: "log.txt", size: 10, hash: hash, data: content},
+		{name: "too big", file: "big.txt", size: 11, hash: hash, data: content + "0", expected: ErrQuota},
+		{name: "user quota", file: "more.txt", size: 10, hash: hash, data: content, expected: ErrQuota},
+		{name: "short", file: "short.txt", size: 4, hash: hash, data: content[:3], expected: ErrSizeMismatch},
+		{name: "long", file: "long.txt", size: 4, hash: hash, data: content[:5], expected: ErrSizeMismatch},
+		{name: "hash", file: "hash.txt", size: 4, hash: hash, data: content[:4], expected: ErrHashMismatch},
+		{name: "invalid hash", file: "hash.txt", size: 4, hash: "abc", data: content[:4], expected: ErrInvalidFile},
+		{name: "invalid name", file: "/", size: 4, hash: hash, data: content[:4], expected: ErrInvalidFile},
+	}
+
+	for _, tc := range cases {
+		_, err := s.Put("Alice", tc.file, tc.size, tc.hash, strings.NewReader(tc.data))
+		if err != tc.expected {
+			t.Errorf("Error should be %v but got %v (%s)", tc.expected, err, tc.name)
+		}
+	}
+
+	if used := s.Used("Alice"); used != 10 {
+		t.Errorf("Used bytes should be %d but got %d", 10, used)
+	}
+
+	if _, err := s.Put("Bob", "../../etc/passwd", 10, hash, strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	// kept files are loaded by new store
+	s, err = NewStore(dir, Quota{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, size := s.Usage(); n != 2 || size != 20 {
+		t.Errorf("Usage should be %d files of %d bytes but got %d of %d", 2, 20, n, size)
+	}
+
+	for id, b := range s.blobs {
+		if b.Owner == "Bob" && b.Name != "passwd" {
+			t.Errorf("Name should be %s but got %s", "passwd", b.Name)
+		}
+
+		_, r, err := s.Open(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := ioutil.ReadAll(r)
+		r.Close()
+
+		if string(data) != content {
+			t.Errorf("Content should be %q but got %q", content, data)
+		}
+	}
+
+	if _, _, err := s.Open("unknown"); err != ErrNotFound {
+		t.Errorf("Error should be %v but got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,212 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/client"
+	"github.com/sc-chat/test-chat/pkg/irc"
+)
+
+const (
+	// DefaultIRCPrefix is prepended to IRC nicknames relayed into the chat
+	DefaultIRCPrefix = "irc/"
+
+	// DefaultChatPrefix is prepended to chat names relayed into IRC
+	DefaultChatPrefix = "chat/"
+)
+
+// NewBridge returns Bridge pointer
+func NewBridge(chatAddr, name, ircAddr, channel string, allowDebug bool) (*Bridge, error) {
+	// basic server address validation
+	if ircAddr == "" {
+		return nil, errors.New("Invalid IRC address")
+	}
+
+	if !strings.HasPrefix(channel, "#") {
+		return nil, errors.New("Invalid IRC channel")
+	}
+
+	c, err := client.NewClient(chatAddr, name, allowDebug)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+
+	b := &Bridge{
+		IRCAddr:    ircAddr,
+		Channel:    channel,
+		Nick:       strings.Replace(name, " ", "_", -1),
+		IRCPrefix:  DefaultIRCPrefix,
+		ChatPrefix: DefaultChatPrefix,
+		Logger:     logger.NewLogger(allowDebug),
+
+		chat:  c,
+		input: w,
+	}
+
+	c.Input = r
+	c.OnEvent = b.fromChat
+
+	return b, nil
+}
+
+// Bridge struct relays messages between the chat and IRC channel
+type Bridge struct {
+	IRCAddr    string
+	Channel    string
+	Nick       string
+	IRCPrefix  string
+	ChatPrefix string
+	Logger     *logger.Logger
+	// TLS encrypts connection to the chat server, connection is not encrypted if nil
+	TLS *tls.Config
+
+	chat  *client.Client
+	input *io.PipeWriter
+
+	conn     net.Conn
+	writeMtx sync.Mutex
+}
+
+// Run method
+func (b *Bridge) Run(ctx context.Context) error {
+	b.chat.TLS = b.TLS
+
+	conn, err := net.DialTimeout("tcp", b.IRCAddr, b.chat.Timeout)
+	if err != nil {
+		return errors.WithMessage(err, "failed to connect to IRC server")
+	}
+
+	b.conn = conn
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+		b.input.Close()
+	}()
+
+	b.Logger.Info("Bridge is connected", "peer", b.IRCAddr)
+
+	b.send(irc.NewMessage("", "NICK", b.Nick))
+	b.send(irc.NewMessage("", "USER", b.Nick, "0", "*", b.chat.Name))
+
+	chatErr := make(chan error, 1)
+	go func() {
+		chatErr <- b.chat.Run(ctx)
+		cancel()
+	}()
+
+	ircErr := b.receive()
+	cancel()
+
+	if err := <-chatErr; err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		// connection closed by the bridge itself
+		return nil
+	}
+
+	return errors.WithMessage(ircErr, "IRC error")
+}
+
+// receive method reads IRC lines until the connection is closed
+func (b *Bridge) receive() error {
+	sc := bufio.NewScanner(b.conn)
+
+	for sc.Scan() {
+		m, err := irc.ParseMessage(sc.Text())
+		if err != nil {
+			continue
+		}
+
+		switch m.Command {
+		case "PING":
+			b.send(irc.NewMessage("", "PONG", m.Params...))
+		case "001":
+			b.send(irc.NewMessage("", "JOIN", b.Channel))
+		case "433":
+			// nickname is already in use
+			b.Nick += "_"
+			b.send(irc.NewMessage("", "NICK", b.Nick))
+		case "PRIVMSG":
+			b.fromIRC(m)
+		case "ERROR":
+			return errors.New(m.Param(0))
+		}
+	}
+
+	return sc.Err()
+}
+
+// fromIRC method relays IRC channel message into the chat
+func (b *Bridge) fromIRC(m *irc.Message) {
+	nick := m.Prefix
+	if i := strings.IndexByte(nick, '!'); i >= 0 {
+		nick = nick[:i]
+	}
+
+	text := m.Param(1)
+
+	switch {
+	case m.Param(0) != b.Channel:
+		// private messages are not relayed
+	case nick == b.Nick:
+		// own message echoed by the server
+	case strings.HasPrefix(nick, b.ChatPrefix):
+		// author relayed from the chat by another bridge
+	default:
+		b.Logger.Debug("Relaying message from IRC", "user", nick, "message", text)
+
+		if _, err := fmt.Fprintln(b.input, b.IRCPrefix+nick+": "+text); err != nil {
+			b.Logger.Warn("Failed to relay message to the chat", "err", err)
+		}
+	}
+}
+
+// fromChat method relays chat message into IRC channel
+func (b *Bridge) fromChat(res *chat.ResponseStream) {
+	evt, ok := res.Event.(*chat.ResponseStream_ClientMessage)
+	if !ok {
+		return
+	}
+
+	name, text := evt.ClientMessage.Name, evt.ClientMessage.Message
+
+	switch {
+	case name == b.chat.Name:
+		// own message echoed by the chat
+	case strings.HasPrefix(name, b.IRCPrefix):
+		// author relayed from IRC by another bridge
+	default:
+		b.Logger.Debug("Relaying message from chat", "user", name, "message", text)
+
+		text = strings.NewReplacer("\r", " ", "\n", " ").Replace(text)
+		b.send(irc.NewMessage("", "PRIVMSG", b.Channel, b.ChatPrefix+name+": "+text))
+	}
+}
+
+func (b *Bridge) send(m *irc.Message) {
+	b.writeMtx.Lock()
+	defer b.writeMtx.Unlock()
+
+	if _, err := b.conn.Write([]byte(m.String() + "\r\n")); err != nil {
+		b.Logger.Warn("Failed to write to IRC server", "err", err)
+	}
+}
@@ -0,0 +1,240 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/client"
+	"github.com/sc-chat/test-chat/pkg/irc"
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+// fakeIRC is in-process IRC server accepting single connection
+type fakeIRC struct {
+	l     net.Listener
+	conn  net.Conn
+	lines chan *irc.Message
+}
+
+func newFakeIRC(t *testing.T) *fakeIRC {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fakeIRC{l: l, lines: make(chan *irc.Message, 100)}
+}
+
+func (f *fakeIRC) accept(t *testing.T) {
+	conn, err := f.l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.conn = conn
+
+	go func() {
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			if m, err := irc.ParseMessage(sc.Text()); err == nil {
+				f.lines <- m
+			}
+		}
+		close(f.lines)
+	}()
+}
+
+func (f *fakeIRC) send(line string) {
+	f.conn.Write([]byte(line + "\r\n"))
+}
+
+// expect method waits for the next command of the provided type
+func (f *fakeIRC) expect(t *testing.T, command string) *irc.Message {
+	timeout := time.After(2 * time.Second)
+
+	for {
+		select {
+		case m, ok := <-f.lines:
+			if !ok {
+				t.Fatalf("Connection closed while waiting for %s", command)
+			}
+			if m.Command == command {
+				return m
+			}
+		case <-timeout:
+			t.Fatalf("Timeout while waiting for %s", command)
+		}
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func waitMessage(t *testing.T, events chan *chat.ResponseStream) *chat.ResponseStream_Message {
+	timeout := time.After(2 * time.Second)
+
+	for {
+		select {
+		case res := <-events:
+			if evt, ok := res.Event.(*chat.ResponseStream_ClientMessage); ok {
+				return evt.ClientMessage
+			}
+		case <-timeout:
+			t.Fatal("Timeout while waiting for chat message")
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// chat server
+	addr := freeAddr(t)
+	s, err := server.NewServer(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.DrainTimeout = 0
+
+	srvCtx, srvCancel := context.WithCancel(context.Background())
+	srvDone := make(chan bool)
+	go func() {
+		s.Run(srvCtx)
+		close(srvDone)
+	}()
+	defer func() {
+		srvCancel()
+		<-srvDone
+	}()
+
+	// IRC server
+	f := newFakeIRC(t)
+	defer f.l.Close()
+
+	b, err := NewBridge(addr, "bridge", f.l.Addr().String(), "#chat", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go b.Run(ctx)
+
+	f.accept(t)
+	f.expect(t, "NICK")
+	f.expect(t, "USER")
+	f.send(":irc 001 bridge :Welcome")
+	f.expect(t, "JOIN")
+
+	// chat member
+	alice, err := client.NewClient(addr, "Alice", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in, out := io.Pipe()
+	events := make(chan *chat.ResponseStream, 100)
+	alice.Input = in
+	alice.OnEvent = func(res *chat.ResponseStream) {
+		events <- res
+	}
+	go alice.Run(ctx)
+
+	// wait until the bridge joins the chat
+	for len(s.Clients.Online()) < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// chat to IRC
+	out.Write([]byte("hello\n"))
+
+	m := f.expect(t, "PRIVMSG")
+	if m.Param(0) != "#chat" || m.Param(1) != "chat/Alice: hello" {
+		t.Errorf("Message should be %q but got %q", "chat/Alice: hello", m.Param(1))
+	}
+
+	// IRC to chat, including messages which should not be relayed
+	f.send(":bridge!b@irc PRIVMSG #chat :own echo")
+	f.send(":chat/Bob!o@irc PRIVMSG #chat :hello")
+	f.send(":carol!c@irc PRIVMSG bridge :private")
+	f.send(":carol!c@irc PRIVMSG #chat :chat/Alice: quoted")
+	f.send(":carol!c@irc PRIVMSG #chat :hi there")
+
+	for _, expected := range []string{"irc/carol: chat/Alice: quoted", "irc/carol: hi there"} {
+		msg := waitMessage(t, events)
+		for msg.Name == "Alice" {
+			msg = waitMessage(t, events)
+		}
+
+		if msg.Name != "bridge" || msg.Message != expected {
+			t.Errorf("Message should be %q but got %s: %q", expected, msg.Name, msg.Message)
+		}
+	}
+
+	// bridged message echoed by the chat must not return to IRC
+	out.Write([]byte("bye\n"))
+
+	m = f.expect(t, "PRIVMSG")
+	if !strings.HasSuffix(m.Param(1), "bye") {
+		t.Errorf("Message should be %q but got %q", "chat/Alice: bye", m.Param(1))
+	}
+}
+
+func TestFromChat(t *testing.T) {
+	b, err := NewBridge("127.0.0.1:8000", "bridge", "127.0.0.1:6667", "#chat", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.IRCPrefix = "irc-"
+
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	b.conn = conn
+
+	lines := make(chan string, 10)
+	go func() {
+		sc := bufio.NewScanner(peer)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+
+	cases := []struct {
+		name    string
+		message string
+		relayed bool
+	}{
+		{name: "bridge", message: "own echo"},
+		{name: "irc-bob", message: "relayed by another bridge"},
+		{name: "Alice", message: "irc-bob: quoted", relayed: true},
+	}
+
+	for _, c := range cases {
+		b.fromChat(&chat.ResponseStream{
+			Event: &chat.ResponseStream_ClientMessage{
+				ClientMessage: &chat.ResponseStream_Message{Name: c.name, Message: c.message},
+			},
+		})
+
+		select {
+		case line := <-lines:
+			if !c.relayed {
+				t.Errorf("Message of %s should not be relayed but got %q", c.name, line)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if c.relayed {
+				t.Errorf("Message of %s should be relayed", c.name)
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ package chat
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import duration "github.com/golang/protobuf/ptypes/duration"
 import timestamp "github.com/golang/protobuf/ptypes/timestamp"
 
 import (
@@ -25,7 +26,11 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type LoginRequest struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// X25519 public key of the device, direct messages are not received by the session if empty
+	PublicKey []byte `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// Ed25519 public key verifying signatures of messages, messages of the session are not signed if empty
+	SigningKey           []byte   `protobuf:"bytes,3,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -35,7 +40,7 @@ func (m *LoginRequest) Reset()         { *m = LoginRequest{} }
 func (m *LoginRequest) String() string { return proto.CompactTextString(m) }
 func (*LoginRequest) ProtoMessage()    {}
 func (*LoginRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{0}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{0}
 }
 func (m *LoginRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LoginRequest.Unmarshal(m, b)
@@ -62,8 +67,24 @@ func (m *LoginRequest) GetName() string {
 	return ""
 }
 
+func (m *LoginRequest) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *LoginRequest) GetSigningKey() []byte {
+	if m != nil {
+		return m.SigningKey
+	}
+	return nil
+}
+
 type LoginResponse struct {
-	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// name of the session after normalization
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -73,7 +94,7 @@ func (m *LoginResponse) Reset()         { *m = LoginResponse{} }
 func (m *LoginResponse) String() string { return proto.CompactTextString(m) }
 func (*LoginResponse) ProtoMessage()    {}
 func (*LoginResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{1}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{1}
 }
 func (m *LoginResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LoginResponse.Unmarshal(m, b)
@@ -100,6 +121,13 @@ func (m *LoginResponse) GetToken() string {
 	return ""
 }
 
+func (m *LoginResponse) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
 type LogoutRequest struct {
 	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -111,7 +139,7 @@ func (m *LogoutRequest) Reset()         { *m = LogoutRequest{} }
 func (m *LogoutRequest) String() string { return proto.CompactTextString(m) }
 func (*LogoutRequest) ProtoMessage()    {}
 func (*LogoutRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{2}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{2}
 }
 func (m *LogoutRequest) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LogoutRequest.Unmarshal(m, b)
@@ -148,7 +176,7 @@ func (m *LogoutResponse) Reset()         { *m = LogoutResponse{} }
 func (m *LogoutResponse) String() string { return proto.CompactTextString(m) }
 func (*LogoutResponse) ProtoMessage()    {}
 func (*LogoutResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{3}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{3}
 }
 func (m *LogoutResponse) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_LogoutResponse.Unmarshal(m, b)
@@ -169,7 +197,13 @@ func (m *LogoutResponse) XXX_DiscardUnknown() {
 var xxx_messageInfo_LogoutResponse proto.InternalMessageInfo
 
 type RequestStream struct {
-	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// ids of files uploaded by the client which are attached to the message
+	Attachments []string `protobuf:"bytes,2,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// direct message is sent instead of the message if set
+	Direct *DirectMessage `protobuf:"bytes,3,opt,name=direct,proto3" json:"direct,omitempty"`
+	// Ed25519 signature of the session name, message and attachments
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -179,7 +213,7 @@ func (m *RequestStream) Reset()         { *m = RequestStream{} }
 func (m *RequestStream) String() string { return proto.CompactTextString(m) }
 func (*RequestStream) ProtoMessage()    {}
 func (*RequestStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{4}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{4}
 }
 func (m *RequestStream) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RequestStream.Unmarshal(m, b)
@@ -206,6 +240,130 @@ func (m *RequestStream) GetMessage() string {
 	return ""
 }
 
+func (m *RequestStream) GetAttachments() []string {
+	if m != nil {
+		return m.Attachments
+	}
+	return nil
+}
+
+func (m *RequestStream) GetDirect() *DirectMessage {
+	if m != nil {
+		return m.Direct
+	}
+	return nil
+}
+
+func (m *RequestStream) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// DirectMessage is encrypted by the client for each device of the recipient and other devices of the sender
+type DirectMessage struct {
+	To                   string      `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	Envelopes            []*Envelope `protobuf:"bytes,2,rep,name=envelopes,proto3" json:"envelopes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *DirectMessage) Reset()         { *m = DirectMessage{} }
+func (m *DirectMessage) String() string { return proto.CompactTextString(m) }
+func (*DirectMessage) ProtoMessage()    {}
+func (*DirectMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{5}
+}
+func (m *DirectMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DirectMessage.Unmarshal(m, b)
+}
+func (m *DirectMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DirectMessage.Marshal(b, m, deterministic)
+}
+func (dst *DirectMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DirectMessage.Merge(dst, src)
+}
+func (m *DirectMessage) XXX_Size() int {
+	return xxx_messageInfo_DirectMessage.Size(m)
+}
+func (m *DirectMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_DirectMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DirectMessage proto.InternalMessageInfo
+
+func (m *DirectMessage) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *DirectMessage) GetEnvelopes() []*Envelope {
+	if m != nil {
+		return m.Envelopes
+	}
+	return nil
+}
+
+// Envelope is ciphertext of direct message for single device
+type Envelope struct {
+	// id of the device public key
+	Device               string   `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Nonce                []byte   `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Ciphertext           []byte   `protobuf:"bytes,3,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Envelope) Reset()         { *m = Envelope{} }
+func (m *Envelope) String() string { return proto.CompactTextString(m) }
+func (*Envelope) ProtoMessage()    {}
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{6}
+}
+func (m *Envelope) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Envelope.Unmarshal(m, b)
+}
+func (m *Envelope) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Envelope.Marshal(b, m, deterministic)
+}
+func (dst *Envelope) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Envelope.Merge(dst, src)
+}
+func (m *Envelope) XXX_Size() int {
+	return xxx_messageInfo_Envelope.Size(m)
+}
+func (m *Envelope) XXX_DiscardUnknown() {
+	xxx_messageInfo_Envelope.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Envelope proto.InternalMessageInfo
+
+func (m *Envelope) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *Envelope) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *Envelope) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
 type ResponseStream struct {
 	Timestamp *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	// Types that are valid to be assigned to Event:
@@ -213,17 +371,23 @@ type ResponseStream struct {
 	//	*ResponseStream_ClientLogout
 	//	*ResponseStream_ClientMessage
 	//	*ResponseStream_ServerShutdown
-	Event                isResponseStream_Event `protobuf_oneof:"event"`
-	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
-	XXX_unrecognized     []byte                 `json:"-"`
-	XXX_sizecache        int32                  `json:"-"`
+	//	*ResponseStream_ServerAnnouncement
+	//	*ResponseStream_DirectMessage
+	Event isResponseStream_Event `protobuf_oneof:"event"`
+	// W3C traceparent of the span which produced the event, empty if the event is not traced
+	Traceparent string `protobuf:"bytes,7,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	// unique id of the event, kept in message history
+	Id                   string   `protobuf:"bytes,8,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ResponseStream) Reset()         { *m = ResponseStream{} }
 func (m *ResponseStream) String() string { return proto.CompactTextString(m) }
 func (*ResponseStream) ProtoMessage()    {}
 func (*ResponseStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{5}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7}
 }
 func (m *ResponseStream) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ResponseStream.Unmarshal(m, b)
@@ -243,6 +407,13 @@ func (m *ResponseStream) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ResponseStream proto.InternalMessageInfo
 
+func (m *ResponseStream) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
 type isResponseStream_Event interface {
 	isResponseStream_Event()
 }
@@ -250,21 +421,39 @@ type isResponseStream_Event interface {
 type ResponseStream_ClientLogin struct {
 	ClientLogin *ResponseStream_Login `protobuf:"bytes,2,opt,name=client_login,json=clientLogin,proto3,oneof"`
 }
+
 type ResponseStream_ClientLogout struct {
 	ClientLogout *ResponseStream_Logout `protobuf:"bytes,3,opt,name=client_logout,json=clientLogout,proto3,oneof"`
 }
+
 type ResponseStream_ClientMessage struct {
 	ClientMessage *ResponseStream_Message `protobuf:"bytes,4,opt,name=client_message,json=clientMessage,proto3,oneof"`
 }
+
 type ResponseStream_ServerShutdown struct {
 	ServerShutdown *ResponseStream_Shutdown `protobuf:"bytes,5,opt,name=server_shutdown,json=serverShutdown,proto3,oneof"`
 }
 
-func (*ResponseStream_ClientLogin) isResponseStream_Event()    {}
-func (*ResponseStream_ClientLogout) isResponseStream_Event()   {}
-func (*ResponseStream_ClientMessage) isResponseStream_Event()  {}
+type ResponseStream_ServerAnnouncement struct {
+	ServerAnnouncement *ResponseStream_Announcement `protobuf:"bytes,6,opt,name=server_announcement,json=serverAnnouncement,proto3,oneof"`
+}
+
+type ResponseStream_DirectMessage struct {
+	DirectMessage *ResponseStream_Direct `protobuf:"bytes,9,opt,name=direct_message,json=directMessage,proto3,oneof"`
+}
+
+func (*ResponseStream_ClientLogin) isResponseStream_Event() {}
+
+func (*ResponseStream_ClientLogout) isResponseStream_Event() {}
+
+func (*ResponseStream_ClientMessage) isResponseStream_Event() {}
+
 func (*ResponseStream_ServerShutdown) isResponseStream_Event() {}
 
+func (*ResponseStream_ServerAnnouncement) isResponseStream_Event() {}
+
+func (*ResponseStream_DirectMessage) isResponseStream_Event() {}
+
 func (m *ResponseStream) GetEvent() isResponseStream_Event {
 	if m != nil {
 		return m.Event
@@ -272,13 +461,6 @@ func (m *ResponseStream) GetEvent() isResponseStream_Event {
 	return nil
 }
 
-func (m *ResponseStream) GetTimestamp() *timestamp.Timestamp {
-	if m != nil {
-		return m.Timestamp
-	}
-	return nil
-}
-
 func (m *ResponseStream) GetClientLogin() *ResponseStream_Login {
 	if x, ok := m.GetEvent().(*ResponseStream_ClientLogin); ok {
 		return x.ClientLogin
@@ -307,6 +489,34 @@ func (m *ResponseStream) GetServerShutdown() *ResponseStream_Shutdown {
 	return nil
 }
 
+func (m *ResponseStream) GetServerAnnouncement() *ResponseStream_Announcement {
+	if x, ok := m.GetEvent().(*ResponseStream_ServerAnnouncement); ok {
+		return x.ServerAnnouncement
+	}
+	return nil
+}
+
+func (m *ResponseStream) GetDirectMessage() *ResponseStream_Direct {
+	if x, ok := m.GetEvent().(*ResponseStream_DirectMessage); ok {
+		return x.DirectMessage
+	}
+	return nil
+}
+
+func (m *ResponseStream) GetTraceparent() string {
+	if m != nil {
+		return m.Traceparent
+	}
+	return ""
+}
+
+func (m *ResponseStream) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*ResponseStream) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _ResponseStream_OneofMarshaler, _ResponseStream_OneofUnmarshaler, _ResponseStream_OneofSizer, []interface{}{
@@ -314,6 +524,8 @@ func (*ResponseStream) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer
 		(*ResponseStream_ClientLogout)(nil),
 		(*ResponseStream_ClientMessage)(nil),
 		(*ResponseStream_ServerShutdown)(nil),
+		(*ResponseStream_ServerAnnouncement)(nil),
+		(*ResponseStream_DirectMessage)(nil),
 	}
 }
 
@@ -341,6 +553,16 @@ func _ResponseStream_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.ServerShutdown); err != nil {
 			return err
 		}
+	case *ResponseStream_ServerAnnouncement:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.ServerAnnouncement); err != nil {
+			return err
+		}
+	case *ResponseStream_DirectMessage:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.DirectMessage); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("ResponseStream.Event has unexpected type %T", x)
@@ -383,6 +605,22 @@ func _ResponseStream_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto
 		err := b.DecodeMessage(msg)
 		m.Event = &ResponseStream_ServerShutdown{msg}
 		return true, err
+	case 6: // event.server_announcement
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ResponseStream_Announcement)
+		err := b.DecodeMessage(msg)
+		m.Event = &ResponseStream_ServerAnnouncement{msg}
+		return true, err
+	case 9: // event.direct_message
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ResponseStream_Direct)
+		err := b.DecodeMessage(msg)
+		m.Event = &ResponseStream_DirectMessage{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -412,6 +650,16 @@ func _ResponseStream_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *ResponseStream_ServerAnnouncement:
+		s := proto.Size(x.ServerAnnouncement)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *ResponseStream_DirectMessage:
+		s := proto.Size(x.DirectMessage)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -430,7 +678,7 @@ func (m *ResponseStream_Login) Reset()         { *m = ResponseStream_Login{} }
 func (m *ResponseStream_Login) String() string { return proto.CompactTextString(m) }
 func (*ResponseStream_Login) ProtoMessage()    {}
 func (*ResponseStream_Login) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{5, 0}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 0}
 }
 func (m *ResponseStream_Login) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ResponseStream_Login.Unmarshal(m, b)
@@ -468,7 +716,7 @@ func (m *ResponseStream_Logout) Reset()         { *m = ResponseStream_Logout{} }
 func (m *ResponseStream_Logout) String() string { return proto.CompactTextString(m) }
 func (*ResponseStream_Logout) ProtoMessage()    {}
 func (*ResponseStream_Logout) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{5, 1}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 1}
 }
 func (m *ResponseStream_Logout) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ResponseStream_Logout.Unmarshal(m, b)
@@ -496,8 +744,12 @@ func (m *ResponseStream_Logout) GetName() string {
 }
 
 type ResponseStream_Message struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Name        string        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Message     string        `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Attachments []*Attachment `protobuf:"bytes,3,rep,name=attachments,proto3" json:"attachments,omitempty"`
+	// signature of the author and its signing key registered at login, empty if the message is not signed
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	SigningKey           []byte   `protobuf:"bytes,5,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -507,7 +759,7 @@ func (m *ResponseStream_Message) Reset()         { *m = ResponseStream_Message{}
 func (m *ResponseStream_Message) String() string { return proto.CompactTextString(m) }
 func (*ResponseStream_Message) ProtoMessage()    {}
 func (*ResponseStream_Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{5, 2}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 2}
 }
 func (m *ResponseStream_Message) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ResponseStream_Message.Unmarshal(m, b)
@@ -541,17 +793,40 @@ func (m *ResponseStream_Message) GetMessage() string {
 	return ""
 }
 
+func (m *ResponseStream_Message) GetAttachments() []*Attachment {
+	if m != nil {
+		return m.Attachments
+	}
+	return nil
+}
+
+func (m *ResponseStream_Message) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *ResponseStream_Message) GetSigningKey() []byte {
+	if m != nil {
+		return m.SigningKey
+	}
+	return nil
+}
+
 type ResponseStream_Shutdown struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	// open streams are closed by the server at deadline
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,1,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *ResponseStream_Shutdown) Reset()         { *m = ResponseStream_Shutdown{} }
 func (m *ResponseStream_Shutdown) String() string { return proto.CompactTextString(m) }
 func (*ResponseStream_Shutdown) ProtoMessage()    {}
 func (*ResponseStream_Shutdown) Descriptor() ([]byte, []int) {
-	return fileDescriptor_chat_1b2230346337dc13, []int{5, 3}
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 3}
 }
 func (m *ResponseStream_Shutdown) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ResponseStream_Shutdown.Unmarshal(m, b)
@@ -571,218 +846,2615 @@ func (m *ResponseStream_Shutdown) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ResponseStream_Shutdown proto.InternalMessageInfo
 
-func init() {
-	proto.RegisterType((*LoginRequest)(nil), "chat.LoginRequest")
-	proto.RegisterType((*LoginResponse)(nil), "chat.LoginResponse")
-	proto.RegisterType((*LogoutRequest)(nil), "chat.LogoutRequest")
-	proto.RegisterType((*LogoutResponse)(nil), "chat.LogoutResponse")
-	proto.RegisterType((*RequestStream)(nil), "chat.RequestStream")
-	proto.RegisterType((*ResponseStream)(nil), "chat.ResponseStream")
-	proto.RegisterType((*ResponseStream_Login)(nil), "chat.ResponseStream.Login")
-	proto.RegisterType((*ResponseStream_Logout)(nil), "chat.ResponseStream.Logout")
-	proto.RegisterType((*ResponseStream_Message)(nil), "chat.ResponseStream.Message")
-	proto.RegisterType((*ResponseStream_Shutdown)(nil), "chat.ResponseStream.Shutdown")
+func (m *ResponseStream_Shutdown) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+type ResponseStream_Announcement struct {
+	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *ResponseStream_Announcement) Reset()         { *m = ResponseStream_Announcement{} }
+func (m *ResponseStream_Announcement) String() string { return proto.CompactTextString(m) }
+func (*ResponseStream_Announcement) ProtoMessage()    {}
+func (*ResponseStream_Announcement) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 4}
+}
+func (m *ResponseStream_Announcement) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseStream_Announcement.Unmarshal(m, b)
+}
+func (m *ResponseStream_Announcement) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseStream_Announcement.Marshal(b, m, deterministic)
+}
+func (dst *ResponseStream_Announcement) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseStream_Announcement.Merge(dst, src)
+}
+func (m *ResponseStream_Announcement) XXX_Size() int {
+	return xxx_messageInfo_ResponseStream_Announcement.Size(m)
+}
+func (m *ResponseStream_Announcement) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseStream_Announcement.DiscardUnknown(m)
+}
 
-// ChatClient is the client API for Chat service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ChatClient interface {
-	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
-	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
-	Stream(ctx context.Context, opts ...grpc.CallOption) (Chat_StreamClient, error)
+var xxx_messageInfo_ResponseStream_Announcement proto.InternalMessageInfo
+
+func (m *ResponseStream_Announcement) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
 }
 
-type chatClient struct {
-	cc *grpc.ClientConn
+// Direct is envelope of direct message delivered to the device it is encrypted for
+type ResponseStream_Direct struct {
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// public key of the sender device
+	SenderKey  []byte `protobuf:"bytes,3,opt,name=sender_key,json=senderKey,proto3" json:"sender_key,omitempty"`
+	Nonce      []byte `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Ciphertext []byte `protobuf:"bytes,5,opt,name=ciphertext,proto3" json:"ciphertext,omitempty"`
+	// id of the device the envelope is encrypted for, used to route the envelope between server instances
+	Device               string   `protobuf:"bytes,6,opt,name=device,proto3" json:"device,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func NewChatClient(cc *grpc.ClientConn) ChatClient {
-	return &chatClient{cc}
+func (m *ResponseStream_Direct) Reset()         { *m = ResponseStream_Direct{} }
+func (m *ResponseStream_Direct) String() string { return proto.CompactTextString(m) }
+func (*ResponseStream_Direct) ProtoMessage()    {}
+func (*ResponseStream_Direct) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{7, 5}
+}
+func (m *ResponseStream_Direct) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseStream_Direct.Unmarshal(m, b)
+}
+func (m *ResponseStream_Direct) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseStream_Direct.Marshal(b, m, deterministic)
+}
+func (dst *ResponseStream_Direct) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseStream_Direct.Merge(dst, src)
+}
+func (m *ResponseStream_Direct) XXX_Size() int {
+	return xxx_messageInfo_ResponseStream_Direct.Size(m)
+}
+func (m *ResponseStream_Direct) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseStream_Direct.DiscardUnknown(m)
 }
 
-func (c *chatClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
-	out := new(LoginResponse)
-	err := c.cc.Invoke(ctx, "/chat.Chat/Login", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ResponseStream_Direct proto.InternalMessageInfo
+
+func (m *ResponseStream_Direct) GetFrom() string {
+	if m != nil {
+		return m.From
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error) {
-	out := new(LogoutResponse)
-	err := c.cc.Invoke(ctx, "/chat.Chat/Logout", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *ResponseStream_Direct) GetTo() string {
+	if m != nil {
+		return m.To
 	}
-	return out, nil
+	return ""
 }
 
-func (c *chatClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Chat_StreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Chat_serviceDesc.Streams[0], "/chat.Chat/Stream", opts...)
-	if err != nil {
-		return nil, err
+func (m *ResponseStream_Direct) GetSenderKey() []byte {
+	if m != nil {
+		return m.SenderKey
 	}
-	x := &chatStreamClient{stream}
-	return x, nil
+	return nil
 }
 
-type Chat_StreamClient interface {
-	Send(*RequestStream) error
-	Recv() (*ResponseStream, error)
-	grpc.ClientStream
+func (m *ResponseStream_Direct) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
 }
 
-type chatStreamClient struct {
+func (m *ResponseStream_Direct) GetCiphertext() []byte {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+func (m *ResponseStream_Direct) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+type SearchRequest struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// all terms must be found in the message, messages are matched by filters only if empty
+	Query  string               `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	Author string               `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Room   string               `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	Since  *timestamp.Timestamp `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`
+	Until  *timestamp.Timestamp `protobuf:"bytes,6,opt,name=until,proto3" json:"until,omitempty"`
+	// default limit of the server is used if zero
+	Limit                int32    `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchRequest) Reset()         { *m = SearchRequest{} }
+func (m *SearchRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()    {}
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{8}
+}
+func (m *SearchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchRequest.Unmarshal(m, b)
+}
+func (m *SearchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchRequest.Marshal(b, m, deterministic)
+}
+func (dst *SearchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchRequest.Merge(dst, src)
+}
+func (m *SearchRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchRequest.Size(m)
+}
+func (m *SearchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchRequest proto.InternalMessageInfo
+
+func (m *SearchRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetSince() *timestamp.Timestamp {
+	if m != nil {
+		return m.Since
+	}
+	return nil
+}
+
+func (m *SearchRequest) GetUntil() *timestamp.Timestamp {
+	if m != nil {
+		return m.Until
+	}
+	return nil
+}
+
+func (m *SearchRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	// client messages, newest first
+	Messages             []*ResponseStream `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SearchResponse) Reset()         { *m = SearchResponse{} }
+func (m *SearchResponse) String() string { return proto.CompactTextString(m) }
+func (*SearchResponse) ProtoMessage()    {}
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{9}
+}
+func (m *SearchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchResponse.Unmarshal(m, b)
+}
+func (m *SearchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchResponse.Marshal(b, m, deterministic)
+}
+func (dst *SearchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchResponse.Merge(dst, src)
+}
+func (m *SearchResponse) XXX_Size() int {
+	return xxx_messageInfo_SearchResponse.Size(m)
+}
+func (m *SearchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchResponse proto.InternalMessageInfo
+
+func (m *SearchResponse) GetMessages() []*ResponseStream {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+// Attachment refers to file kept by the server
+type Attachment struct {
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Size int64  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// hex encoded sha256 hash of the content
+	Sha256               string   `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Attachment) Reset()         { *m = Attachment{} }
+func (m *Attachment) String() string { return proto.CompactTextString(m) }
+func (*Attachment) ProtoMessage()    {}
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{10}
+}
+func (m *Attachment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Attachment.Unmarshal(m, b)
+}
+func (m *Attachment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Attachment.Marshal(b, m, deterministic)
+}
+func (dst *Attachment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Attachment.Merge(dst, src)
+}
+func (m *Attachment) XXX_Size() int {
+	return xxx_messageInfo_Attachment.Size(m)
+}
+func (m *Attachment) XXX_DiscardUnknown() {
+	xxx_messageInfo_Attachment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Attachment proto.InternalMessageInfo
+
+func (m *Attachment) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Attachment) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Attachment) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *Attachment) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+// UploadRequest sends file in chunks, token, name, size and sha256 are read from the first request
+type UploadRequest struct {
+	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Size                 int64    `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256               string   `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Chunk                []byte   `protobuf:"bytes,5,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UploadRequest) Reset()         { *m = UploadRequest{} }
+func (m *UploadRequest) String() string { return proto.CompactTextString(m) }
+func (*UploadRequest) ProtoMessage()    {}
+func (*UploadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{11}
+}
+func (m *UploadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UploadRequest.Unmarshal(m, b)
+}
+func (m *UploadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UploadRequest.Marshal(b, m, deterministic)
+}
+func (dst *UploadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UploadRequest.Merge(dst, src)
+}
+func (m *UploadRequest) XXX_Size() int {
+	return xxx_messageInfo_UploadRequest.Size(m)
+}
+func (m *UploadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UploadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UploadRequest proto.InternalMessageInfo
+
+func (m *UploadRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *UploadRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *UploadRequest) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *UploadRequest) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+func (m *UploadRequest) GetChunk() []byte {
+	if m != nil {
+		return m.Chunk
+	}
+	return nil
+}
+
+type UploadResponse struct {
+	Attachment           *Attachment `protobuf:"bytes,1,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *UploadResponse) Reset()         { *m = UploadResponse{} }
+func (m *UploadResponse) String() string { return proto.CompactTextString(m) }
+func (*UploadResponse) ProtoMessage()    {}
+func (*UploadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{12}
+}
+func (m *UploadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UploadResponse.Unmarshal(m, b)
+}
+func (m *UploadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UploadResponse.Marshal(b, m, deterministic)
+}
+func (dst *UploadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UploadResponse.Merge(dst, src)
+}
+func (m *UploadResponse) XXX_Size() int {
+	return xxx_messageInfo_UploadResponse.Size(m)
+}
+func (m *UploadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UploadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UploadResponse proto.InternalMessageInfo
+
+func (m *UploadResponse) GetAttachment() *Attachment {
+	if m != nil {
+		return m.Attachment
+	}
+	return nil
+}
+
+type DownloadRequest struct {
+	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Id                   string   `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DownloadRequest) Reset()         { *m = DownloadRequest{} }
+func (m *DownloadRequest) String() string { return proto.CompactTextString(m) }
+func (*DownloadRequest) ProtoMessage()    {}
+func (*DownloadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{13}
+}
+func (m *DownloadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DownloadRequest.Unmarshal(m, b)
+}
+func (m *DownloadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DownloadRequest.Marshal(b, m, deterministic)
+}
+func (dst *DownloadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DownloadRequest.Merge(dst, src)
+}
+func (m *DownloadRequest) XXX_Size() int {
+	return xxx_messageInfo_DownloadRequest.Size(m)
+}
+func (m *DownloadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DownloadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DownloadRequest proto.InternalMessageInfo
+
+func (m *DownloadRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *DownloadRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+// DownloadResponse sends file in chunks, attachment is set in the first response only
+type DownloadResponse struct {
+	Attachment           *Attachment `protobuf:"bytes,1,opt,name=attachment,proto3" json:"attachment,omitempty"`
+	Chunk                []byte      `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *DownloadResponse) Reset()         { *m = DownloadResponse{} }
+func (m *DownloadResponse) String() string { return proto.CompactTextString(m) }
+func (*DownloadResponse) ProtoMessage()    {}
+func (*DownloadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{14}
+}
+func (m *DownloadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DownloadResponse.Unmarshal(m, b)
+}
+func (m *DownloadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DownloadResponse.Marshal(b, m, deterministic)
+}
+func (dst *DownloadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DownloadResponse.Merge(dst, src)
+}
+func (m *DownloadResponse) XXX_Size() int {
+	return xxx_messageInfo_DownloadResponse.Size(m)
+}
+func (m *DownloadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DownloadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DownloadResponse proto.InternalMessageInfo
+
+func (m *DownloadResponse) GetAttachment() *Attachment {
+	if m != nil {
+		return m.Attachment
+	}
+	return nil
+}
+
+func (m *DownloadResponse) GetChunk() []byte {
+	if m != nil {
+		return m.Chunk
+	}
+	return nil
+}
+
+type KeysRequest struct {
+	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeysRequest) Reset()         { *m = KeysRequest{} }
+func (m *KeysRequest) String() string { return proto.CompactTextString(m) }
+func (*KeysRequest) ProtoMessage()    {}
+func (*KeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{15}
+}
+func (m *KeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeysRequest.Unmarshal(m, b)
+}
+func (m *KeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeysRequest.Marshal(b, m, deterministic)
+}
+func (dst *KeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeysRequest.Merge(dst, src)
+}
+func (m *KeysRequest) XXX_Size() int {
+	return xxx_messageInfo_KeysRequest.Size(m)
+}
+func (m *KeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeysRequest proto.InternalMessageInfo
+
+func (m *KeysRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *KeysRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// KeysResponse contains public keys of devices of the client which are online
+type KeysResponse struct {
+	Keys                 [][]byte `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeysResponse) Reset()         { *m = KeysResponse{} }
+func (m *KeysResponse) String() string { return proto.CompactTextString(m) }
+func (*KeysResponse) ProtoMessage()    {}
+func (*KeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{16}
+}
+func (m *KeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeysResponse.Unmarshal(m, b)
+}
+func (m *KeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeysResponse.Marshal(b, m, deterministic)
+}
+func (dst *KeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeysResponse.Merge(dst, src)
+}
+func (m *KeysResponse) XXX_Size() int {
+	return xxx_messageInfo_KeysResponse.Size(m)
+}
+func (m *KeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeysResponse proto.InternalMessageInfo
+
+func (m *KeysResponse) GetKeys() [][]byte {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type PushRequest struct {
+	Events               []*ResponseStream `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	Online               []string          `protobuf:"bytes,2,rep,name=online,proto3" json:"online,omitempty"`
+	Snapshot             bool              `protobuf:"varint,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *PushRequest) Reset()         { *m = PushRequest{} }
+func (m *PushRequest) String() string { return proto.CompactTextString(m) }
+func (*PushRequest) ProtoMessage()    {}
+func (*PushRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{17}
+}
+func (m *PushRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushRequest.Unmarshal(m, b)
+}
+func (m *PushRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushRequest.Marshal(b, m, deterministic)
+}
+func (dst *PushRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRequest.Merge(dst, src)
+}
+func (m *PushRequest) XXX_Size() int {
+	return xxx_messageInfo_PushRequest.Size(m)
+}
+func (m *PushRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushRequest proto.InternalMessageInfo
+
+func (m *PushRequest) GetEvents() []*ResponseStream {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *PushRequest) GetOnline() []string {
+	if m != nil {
+		return m.Online
+	}
+	return nil
+}
+
+func (m *PushRequest) GetSnapshot() bool {
+	if m != nil {
+		return m.Snapshot
+	}
+	return false
+}
+
+type PushResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushResponse) Reset()         { *m = PushResponse{} }
+func (m *PushResponse) String() string { return proto.CompactTextString(m) }
+func (*PushResponse) ProtoMessage()    {}
+func (*PushResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{18}
+}
+func (m *PushResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushResponse.Unmarshal(m, b)
+}
+func (m *PushResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushResponse.Marshal(b, m, deterministic)
+}
+func (dst *PushResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushResponse.Merge(dst, src)
+}
+func (m *PushResponse) XXX_Size() int {
+	return xxx_messageInfo_PushResponse.Size(m)
+}
+func (m *PushResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushResponse proto.InternalMessageInfo
+
+type Session struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// id is prefix of the session token, tokens are never sent to admins
+	Id                   string   `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Session) Reset()         { *m = Session{} }
+func (m *Session) String() string { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()    {}
+func (*Session) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{19}
+}
+func (m *Session) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Session.Unmarshal(m, b)
+}
+func (m *Session) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Session.Marshal(b, m, deterministic)
+}
+func (dst *Session) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Session.Merge(dst, src)
+}
+func (m *Session) XXX_Size() int {
+	return xxx_messageInfo_Session.Size(m)
+}
+func (m *Session) XXX_DiscardUnknown() {
+	xxx_messageInfo_Session.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Session proto.InternalMessageInfo
+
+func (m *Session) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Session) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type ListSessionsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListSessionsRequest) Reset()         { *m = ListSessionsRequest{} }
+func (m *ListSessionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsRequest) ProtoMessage()    {}
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{20}
+}
+func (m *ListSessionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSessionsRequest.Unmarshal(m, b)
+}
+func (m *ListSessionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSessionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListSessionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsRequest.Merge(dst, src)
+}
+func (m *ListSessionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListSessionsRequest.Size(m)
+}
+func (m *ListSessionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsRequest proto.InternalMessageInfo
+
+type ListSessionsResponse struct {
+	Sessions             []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListSessionsResponse) Reset()         { *m = ListSessionsResponse{} }
+func (m *ListSessionsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListSessionsResponse) ProtoMessage()    {}
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{21}
+}
+func (m *ListSessionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSessionsResponse.Unmarshal(m, b)
+}
+func (m *ListSessionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSessionsResponse.Marshal(b, m, deterministic)
+}
+func (dst *ListSessionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSessionsResponse.Merge(dst, src)
+}
+func (m *ListSessionsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListSessionsResponse.Size(m)
+}
+func (m *ListSessionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSessionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSessionsResponse proto.InternalMessageInfo
+
+func (m *ListSessionsResponse) GetSessions() []*Session {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+type KickRequest struct {
+	// Types that are valid to be assigned to Target:
+	//	*KickRequest_Name
+	//	*KickRequest_Id
+	Target               isKickRequest_Target `protobuf_oneof:"target"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *KickRequest) Reset()         { *m = KickRequest{} }
+func (m *KickRequest) String() string { return proto.CompactTextString(m) }
+func (*KickRequest) ProtoMessage()    {}
+func (*KickRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{22}
+}
+func (m *KickRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickRequest.Unmarshal(m, b)
+}
+func (m *KickRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickRequest.Marshal(b, m, deterministic)
+}
+func (dst *KickRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickRequest.Merge(dst, src)
+}
+func (m *KickRequest) XXX_Size() int {
+	return xxx_messageInfo_KickRequest.Size(m)
+}
+func (m *KickRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickRequest proto.InternalMessageInfo
+
+type isKickRequest_Target interface {
+	isKickRequest_Target()
+}
+
+type KickRequest_Name struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3,oneof"`
+}
+
+type KickRequest_Id struct {
+	Id string `protobuf:"bytes,3,opt,name=id,proto3,oneof"`
+}
+
+func (*KickRequest_Name) isKickRequest_Target() {}
+
+func (*KickRequest_Id) isKickRequest_Target() {}
+
+func (m *KickRequest) GetTarget() isKickRequest_Target {
+	if m != nil {
+		return m.Target
+	}
+	return nil
+}
+
+func (m *KickRequest) GetName() string {
+	if x, ok := m.GetTarget().(*KickRequest_Name); ok {
+		return x.Name
+	}
+	return ""
+}
+
+func (m *KickRequest) GetId() string {
+	if x, ok := m.GetTarget().(*KickRequest_Id); ok {
+		return x.Id
+	}
+	return ""
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*KickRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _KickRequest_OneofMarshaler, _KickRequest_OneofUnmarshaler, _KickRequest_OneofSizer, []interface{}{
+		(*KickRequest_Name)(nil),
+		(*KickRequest_Id)(nil),
+	}
+}
+
+func _KickRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*KickRequest)
+	// target
+	switch x := m.Target.(type) {
+	case *KickRequest_Name:
+		b.EncodeVarint(1<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.Name)
+	case *KickRequest_Id:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.Id)
+	case nil:
+	default:
+		return fmt.Errorf("KickRequest.Target has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _KickRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*KickRequest)
+	switch tag {
+	case 1: // target.name
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Target = &KickRequest_Name{x}
+		return true, err
+	case 3: // target.id
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Target = &KickRequest_Id{x}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _KickRequest_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*KickRequest)
+	// target
+	switch x := m.Target.(type) {
+	case *KickRequest_Name:
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(len(x.Name)))
+		n += len(x.Name)
+	case *KickRequest_Id:
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(len(x.Id)))
+		n += len(x.Id)
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type KickResponse struct {
+	Kicked               int32    `protobuf:"varint,1,opt,name=kicked,proto3" json:"kicked,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KickResponse) Reset()         { *m = KickResponse{} }
+func (m *KickResponse) String() string { return proto.CompactTextString(m) }
+func (*KickResponse) ProtoMessage()    {}
+func (*KickResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{23}
+}
+func (m *KickResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickResponse.Unmarshal(m, b)
+}
+func (m *KickResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickResponse.Marshal(b, m, deterministic)
+}
+func (dst *KickResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickResponse.Merge(dst, src)
+}
+func (m *KickResponse) XXX_Size() int {
+	return xxx_messageInfo_KickResponse.Size(m)
+}
+func (m *KickResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickResponse proto.InternalMessageInfo
+
+func (m *KickResponse) GetKicked() int32 {
+	if m != nil {
+		return m.Kicked
+	}
+	return 0
+}
+
+// zero duration lifts the ban
+type BanRequest struct {
+	Name                 string             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Duration             *duration.Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *BanRequest) Reset()         { *m = BanRequest{} }
+func (m *BanRequest) String() string { return proto.CompactTextString(m) }
+func (*BanRequest) ProtoMessage()    {}
+func (*BanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{24}
+}
+func (m *BanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BanRequest.Unmarshal(m, b)
+}
+func (m *BanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BanRequest.Marshal(b, m, deterministic)
+}
+func (dst *BanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BanRequest.Merge(dst, src)
+}
+func (m *BanRequest) XXX_Size() int {
+	return xxx_messageInfo_BanRequest.Size(m)
+}
+func (m *BanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BanRequest proto.InternalMessageInfo
+
+func (m *BanRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *BanRequest) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+type BanResponse struct {
+	Kicked               int32    `protobuf:"varint,1,opt,name=kicked,proto3" json:"kicked,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BanResponse) Reset()         { *m = BanResponse{} }
+func (m *BanResponse) String() string { return proto.CompactTextString(m) }
+func (*BanResponse) ProtoMessage()    {}
+func (*BanResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{25}
+}
+func (m *BanResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BanResponse.Unmarshal(m, b)
+}
+func (m *BanResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BanResponse.Marshal(b, m, deterministic)
+}
+func (dst *BanResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BanResponse.Merge(dst, src)
+}
+func (m *BanResponse) XXX_Size() int {
+	return xxx_messageInfo_BanResponse.Size(m)
+}
+func (m *BanResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BanResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BanResponse proto.InternalMessageInfo
+
+func (m *BanResponse) GetKicked() int32 {
+	if m != nil {
+		return m.Kicked
+	}
+	return 0
+}
+
+// zero duration lifts the mute
+type MuteRequest struct {
+	Name                 string             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Duration             *duration.Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *MuteRequest) Reset()         { *m = MuteRequest{} }
+func (m *MuteRequest) String() string { return proto.CompactTextString(m) }
+func (*MuteRequest) ProtoMessage()    {}
+func (*MuteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{26}
+}
+func (m *MuteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MuteRequest.Unmarshal(m, b)
+}
+func (m *MuteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MuteRequest.Marshal(b, m, deterministic)
+}
+func (dst *MuteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MuteRequest.Merge(dst, src)
+}
+func (m *MuteRequest) XXX_Size() int {
+	return xxx_messageInfo_MuteRequest.Size(m)
+}
+func (m *MuteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MuteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MuteRequest proto.InternalMessageInfo
+
+func (m *MuteRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MuteRequest) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+type MuteResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MuteResponse) Reset()         { *m = MuteResponse{} }
+func (m *MuteResponse) String() string { return proto.CompactTextString(m) }
+func (*MuteResponse) ProtoMessage()    {}
+func (*MuteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{27}
+}
+func (m *MuteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MuteResponse.Unmarshal(m, b)
+}
+func (m *MuteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MuteResponse.Marshal(b, m, deterministic)
+}
+func (dst *MuteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MuteResponse.Merge(dst, src)
+}
+func (m *MuteResponse) XXX_Size() int {
+	return xxx_messageInfo_MuteResponse.Size(m)
+}
+func (m *MuteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MuteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MuteResponse proto.InternalMessageInfo
+
+type AnnounceRequest struct {
+	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AnnounceRequest) Reset()         { *m = AnnounceRequest{} }
+func (m *AnnounceRequest) String() string { return proto.CompactTextString(m) }
+func (*AnnounceRequest) ProtoMessage()    {}
+func (*AnnounceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{28}
+}
+func (m *AnnounceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AnnounceRequest.Unmarshal(m, b)
+}
+func (m *AnnounceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AnnounceRequest.Marshal(b, m, deterministic)
+}
+func (dst *AnnounceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnnounceRequest.Merge(dst, src)
+}
+func (m *AnnounceRequest) XXX_Size() int {
+	return xxx_messageInfo_AnnounceRequest.Size(m)
+}
+func (m *AnnounceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnnounceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnnounceRequest proto.InternalMessageInfo
+
+func (m *AnnounceRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type AnnounceResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AnnounceResponse) Reset()         { *m = AnnounceResponse{} }
+func (m *AnnounceResponse) String() string { return proto.CompactTextString(m) }
+func (*AnnounceResponse) ProtoMessage()    {}
+func (*AnnounceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{29}
+}
+func (m *AnnounceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AnnounceResponse.Unmarshal(m, b)
+}
+func (m *AnnounceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AnnounceResponse.Marshal(b, m, deterministic)
+}
+func (dst *AnnounceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnnounceResponse.Merge(dst, src)
+}
+func (m *AnnounceResponse) XXX_Size() int {
+	return xxx_messageInfo_AnnounceResponse.Size(m)
+}
+func (m *AnnounceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnnounceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnnounceResponse proto.InternalMessageInfo
+
+type StatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{30}
+}
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (dst *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(dst, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+type StatsResponse struct {
+	Online               int32              `protobuf:"varint,1,opt,name=online,proto3" json:"online,omitempty"`
+	Sessions             int32              `protobuf:"varint,2,opt,name=sessions,proto3" json:"sessions,omitempty"`
+	Logins               int64              `protobuf:"varint,3,opt,name=logins,proto3" json:"logins,omitempty"`
+	Messages             int64              `protobuf:"varint,4,opt,name=messages,proto3" json:"messages,omitempty"`
+	BroadcastQueue       int32              `protobuf:"varint,5,opt,name=broadcast_queue,json=broadcastQueue,proto3" json:"broadcast_queue,omitempty"`
+	Uptime               *duration.Duration `protobuf:"bytes,6,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *StatsResponse) Reset()         { *m = StatsResponse{} }
+func (m *StatsResponse) String() string { return proto.CompactTextString(m) }
+func (*StatsResponse) ProtoMessage()    {}
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{31}
+}
+func (m *StatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsResponse.Unmarshal(m, b)
+}
+func (m *StatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsResponse.Marshal(b, m, deterministic)
+}
+func (dst *StatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsResponse.Merge(dst, src)
+}
+func (m *StatsResponse) XXX_Size() int {
+	return xxx_messageInfo_StatsResponse.Size(m)
+}
+func (m *StatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsResponse proto.InternalMessageInfo
+
+func (m *StatsResponse) GetOnline() int32 {
+	if m != nil {
+		return m.Online
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetSessions() int32 {
+	if m != nil {
+		return m.Sessions
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetLogins() int64 {
+	if m != nil {
+		return m.Logins
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetMessages() int64 {
+	if m != nil {
+		return m.Messages
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetBroadcastQueue() int32 {
+	if m != nil {
+		return m.BroadcastQueue
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetUptime() *duration.Duration {
+	if m != nil {
+		return m.Uptime
+	}
+	return nil
+}
+
+type ReloadRolesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadRolesRequest) Reset()         { *m = ReloadRolesRequest{} }
+func (m *ReloadRolesRequest) String() string { return proto.CompactTextString(m) }
+func (*ReloadRolesRequest) ProtoMessage()    {}
+func (*ReloadRolesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{32}
+}
+func (m *ReloadRolesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadRolesRequest.Unmarshal(m, b)
+}
+func (m *ReloadRolesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadRolesRequest.Marshal(b, m, deterministic)
+}
+func (dst *ReloadRolesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadRolesRequest.Merge(dst, src)
+}
+func (m *ReloadRolesRequest) XXX_Size() int {
+	return xxx_messageInfo_ReloadRolesRequest.Size(m)
+}
+func (m *ReloadRolesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadRolesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadRolesRequest proto.InternalMessageInfo
+
+type ReloadRolesResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadRolesResponse) Reset()         { *m = ReloadRolesResponse{} }
+func (m *ReloadRolesResponse) String() string { return proto.CompactTextString(m) }
+func (*ReloadRolesResponse) ProtoMessage()    {}
+func (*ReloadRolesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{33}
+}
+func (m *ReloadRolesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadRolesResponse.Unmarshal(m, b)
+}
+func (m *ReloadRolesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadRolesResponse.Marshal(b, m, deterministic)
+}
+func (dst *ReloadRolesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadRolesResponse.Merge(dst, src)
+}
+func (m *ReloadRolesResponse) XXX_Size() int {
+	return xxx_messageInfo_ReloadRolesResponse.Size(m)
+}
+func (m *ReloadRolesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadRolesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadRolesResponse proto.InternalMessageInfo
+
+type SetLogLevelRequest struct {
+	// current level is kept if empty
+	Level                string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelRequest) Reset()         { *m = SetLogLevelRequest{} }
+func (m *SetLogLevelRequest) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelRequest) ProtoMessage()    {}
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{34}
+}
+func (m *SetLogLevelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelRequest.Unmarshal(m, b)
+}
+func (m *SetLogLevelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelRequest.Marshal(b, m, deterministic)
+}
+func (dst *SetLogLevelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelRequest.Merge(dst, src)
+}
+func (m *SetLogLevelRequest) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelRequest.Size(m)
+}
+func (m *SetLogLevelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelRequest proto.InternalMessageInfo
+
+func (m *SetLogLevelRequest) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+type SetLogLevelResponse struct {
+	Level                string   `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Previous             string   `protobuf:"bytes,2,opt,name=previous,proto3" json:"previous,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelResponse) Reset()         { *m = SetLogLevelResponse{} }
+func (m *SetLogLevelResponse) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelResponse) ProtoMessage()    {}
+func (*SetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{35}
+}
+func (m *SetLogLevelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelResponse.Unmarshal(m, b)
+}
+func (m *SetLogLevelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelResponse.Marshal(b, m, deterministic)
+}
+func (dst *SetLogLevelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelResponse.Merge(dst, src)
+}
+func (m *SetLogLevelResponse) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelResponse.Size(m)
+}
+func (m *SetLogLevelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelResponse proto.InternalMessageInfo
+
+func (m *SetLogLevelResponse) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *SetLogLevelResponse) GetPrevious() string {
+	if m != nil {
+		return m.Previous
+	}
+	return ""
+}
+
+type HistoryRecord struct {
+	Room string `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	// login, logout or client message with its id and original timestamp
+	Event                *ResponseStream `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *HistoryRecord) Reset()         { *m = HistoryRecord{} }
+func (m *HistoryRecord) String() string { return proto.CompactTextString(m) }
+func (*HistoryRecord) ProtoMessage()    {}
+func (*HistoryRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{36}
+}
+func (m *HistoryRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistoryRecord.Unmarshal(m, b)
+}
+func (m *HistoryRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistoryRecord.Marshal(b, m, deterministic)
+}
+func (dst *HistoryRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistoryRecord.Merge(dst, src)
+}
+func (m *HistoryRecord) XXX_Size() int {
+	return xxx_messageInfo_HistoryRecord.Size(m)
+}
+func (m *HistoryRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistoryRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistoryRecord proto.InternalMessageInfo
+
+func (m *HistoryRecord) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *HistoryRecord) GetEvent() *ResponseStream {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	// all history is exported if since is not set and room is empty
+	Since                *timestamp.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	Room                 string               `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ExportRequest) Reset()         { *m = ExportRequest{} }
+func (m *ExportRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()    {}
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{37}
+}
+func (m *ExportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportRequest.Unmarshal(m, b)
+}
+func (m *ExportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportRequest.Marshal(b, m, deterministic)
+}
+func (dst *ExportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportRequest.Merge(dst, src)
+}
+func (m *ExportRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportRequest.Size(m)
+}
+func (m *ExportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportRequest proto.InternalMessageInfo
+
+func (m *ExportRequest) GetSince() *timestamp.Timestamp {
+	if m != nil {
+		return m.Since
+	}
+	return nil
+}
+
+func (m *ExportRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+type ImportResponse struct {
+	Imported int32 `protobuf:"varint,1,opt,name=imported,proto3" json:"imported,omitempty"`
+	// records with ids which are already kept
+	Skipped              int32    `protobuf:"varint,2,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImportResponse) Reset()         { *m = ImportResponse{} }
+func (m *ImportResponse) String() string { return proto.CompactTextString(m) }
+func (*ImportResponse) ProtoMessage()    {}
+func (*ImportResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chat_2c71123fdf69f8e5, []int{38}
+}
+func (m *ImportResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportResponse.Unmarshal(m, b)
+}
+func (m *ImportResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportResponse.Marshal(b, m, deterministic)
+}
+func (dst *ImportResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportResponse.Merge(dst, src)
+}
+func (m *ImportResponse) XXX_Size() int {
+	return xxx_messageInfo_ImportResponse.Size(m)
+}
+func (m *ImportResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportResponse proto.InternalMessageInfo
+
+func (m *ImportResponse) GetImported() int32 {
+	if m != nil {
+		return m.Imported
+	}
+	return 0
+}
+
+func (m *ImportResponse) GetSkipped() int32 {
+	if m != nil {
+		return m.Skipped
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*LoginRequest)(nil), "chat.LoginRequest")
+	proto.RegisterType((*LoginResponse)(nil), "chat.LoginResponse")
+	proto.RegisterType((*LogoutRequest)(nil), "chat.LogoutRequest")
+	proto.RegisterType((*LogoutResponse)(nil), "chat.LogoutResponse")
+	proto.RegisterType((*RequestStream)(nil), "chat.RequestStream")
+	proto.RegisterType((*DirectMessage)(nil), "chat.DirectMessage")
+	proto.RegisterType((*Envelope)(nil), "chat.Envelope")
+	proto.RegisterType((*ResponseStream)(nil), "chat.ResponseStream")
+	proto.RegisterType((*ResponseStream_Login)(nil), "chat.ResponseStream.Login")
+	proto.RegisterType((*ResponseStream_Logout)(nil), "chat.ResponseStream.Logout")
+	proto.RegisterType((*ResponseStream_Message)(nil), "chat.ResponseStream.Message")
+	proto.RegisterType((*ResponseStream_Shutdown)(nil), "chat.ResponseStream.Shutdown")
+	proto.RegisterType((*ResponseStream_Announcement)(nil), "chat.ResponseStream.Announcement")
+	proto.RegisterType((*ResponseStream_Direct)(nil), "chat.ResponseStream.Direct")
+	proto.RegisterType((*SearchRequest)(nil), "chat.SearchRequest")
+	proto.RegisterType((*SearchResponse)(nil), "chat.SearchResponse")
+	proto.RegisterType((*Attachment)(nil), "chat.Attachment")
+	proto.RegisterType((*UploadRequest)(nil), "chat.UploadRequest")
+	proto.RegisterType((*UploadResponse)(nil), "chat.UploadResponse")
+	proto.RegisterType((*DownloadRequest)(nil), "chat.DownloadRequest")
+	proto.RegisterType((*DownloadResponse)(nil), "chat.DownloadResponse")
+	proto.RegisterType((*KeysRequest)(nil), "chat.KeysRequest")
+	proto.RegisterType((*KeysResponse)(nil), "chat.KeysResponse")
+	proto.RegisterType((*PushRequest)(nil), "chat.PushRequest")
+	proto.RegisterType((*PushResponse)(nil), "chat.PushResponse")
+	proto.RegisterType((*Session)(nil), "chat.Session")
+	proto.RegisterType((*ListSessionsRequest)(nil), "chat.ListSessionsRequest")
+	proto.RegisterType((*ListSessionsResponse)(nil), "chat.ListSessionsResponse")
+	proto.RegisterType((*KickRequest)(nil), "chat.KickRequest")
+	proto.RegisterType((*KickResponse)(nil), "chat.KickResponse")
+	proto.RegisterType((*BanRequest)(nil), "chat.BanRequest")
+	proto.RegisterType((*BanResponse)(nil), "chat.BanResponse")
+	proto.RegisterType((*MuteRequest)(nil), "chat.MuteRequest")
+	proto.RegisterType((*MuteResponse)(nil), "chat.MuteResponse")
+	proto.RegisterType((*AnnounceRequest)(nil), "chat.AnnounceRequest")
+	proto.RegisterType((*AnnounceResponse)(nil), "chat.AnnounceResponse")
+	proto.RegisterType((*StatsRequest)(nil), "chat.StatsRequest")
+	proto.RegisterType((*StatsResponse)(nil), "chat.StatsResponse")
+	proto.RegisterType((*ReloadRolesRequest)(nil), "chat.ReloadRolesRequest")
+	proto.RegisterType((*ReloadRolesResponse)(nil), "chat.ReloadRolesResponse")
+	proto.RegisterType((*SetLogLevelRequest)(nil), "chat.SetLogLevelRequest")
+	proto.RegisterType((*SetLogLevelResponse)(nil), "chat.SetLogLevelResponse")
+	proto.RegisterType((*HistoryRecord)(nil), "chat.HistoryRecord")
+	proto.RegisterType((*ExportRequest)(nil), "chat.ExportRequest")
+	proto.RegisterType((*ImportResponse)(nil), "chat.ImportResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ChatClient is the client API for Chat service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ChatClient interface {
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
+	Stream(ctx context.Context, opts ...grpc.CallOption) (Chat_StreamClient, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	Upload(ctx context.Context, opts ...grpc.CallOption) (Chat_UploadClient, error)
+	Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (Chat_DownloadClient, error)
+	Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error)
+}
+
+type chatClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewChatClient(cc *grpc.ClientConn) ChatClient {
+	return &chatClient{cc}
+}
+
+func (c *chatClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Login", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error) {
+	out := new(LogoutResponse)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Logout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Stream(ctx context.Context, opts ...grpc.CallOption) (Chat_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chat_serviceDesc.Streams[0], "/chat.Chat/Stream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatStreamClient{stream}
+	return x, nil
+}
+
+type Chat_StreamClient interface {
+	Send(*RequestStream) error
+	Recv() (*ResponseStream, error)
+	grpc.ClientStream
+}
+
+type chatStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatStreamClient) Send(m *RequestStream) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatStreamClient) Recv() (*ResponseStream, error) {
+	m := new(ResponseStream)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatClient) Upload(ctx context.Context, opts ...grpc.CallOption) (Chat_UploadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chat_serviceDesc.Streams[1], "/chat.Chat/Upload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatUploadClient{stream}
+	return x, nil
+}
+
+type Chat_UploadClient interface {
+	Send(*UploadRequest) error
+	CloseAndRecv() (*UploadResponse, error)
+	grpc.ClientStream
+}
+
+type chatUploadClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatUploadClient) Send(m *UploadRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chatUploadClient) CloseAndRecv() (*UploadResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (Chat_DownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chat_serviceDesc.Streams[2], "/chat.Chat/Download", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chatDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chat_DownloadClient interface {
+	Recv() (*DownloadResponse, error)
+	grpc.ClientStream
+}
+
+type chatDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *chatDownloadClient) Recv() (*DownloadResponse, error) {
+	m := new(DownloadResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chatClient) Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error) {
+	out := new(KeysResponse)
+	err := c.cc.Invoke(ctx, "/chat.Chat/Keys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChatServer is the server API for Chat service.
+type ChatServer interface {
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
+	Stream(Chat_StreamServer) error
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	Upload(Chat_UploadServer) error
+	Download(*DownloadRequest, Chat_DownloadServer) error
+	Keys(context.Context, *KeysRequest) (*KeysResponse, error)
+}
+
+func RegisterChatServer(s *grpc.Server, srv ChatServer) {
+	s.RegisterService(&_Chat_serviceDesc, srv)
+}
+
+func _Chat_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Login",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Logout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServer).Stream(&chatStreamServer{stream})
+}
+
+type Chat_StreamServer interface {
+	Send(*ResponseStream) error
+	Recv() (*RequestStream, error)
+	grpc.ServerStream
+}
+
+type chatStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatStreamServer) Send(m *ResponseStream) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatStreamServer) Recv() (*RequestStream, error) {
+	m := new(RequestStream)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chat_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chat_Upload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChatServer).Upload(&chatUploadServer{stream})
+}
+
+type Chat_UploadServer interface {
+	SendAndClose(*UploadResponse) error
+	Recv() (*UploadRequest, error)
+	grpc.ServerStream
+}
+
+type chatUploadServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatUploadServer) SendAndClose(m *UploadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chatUploadServer) Recv() (*UploadRequest, error) {
+	m := new(UploadRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chat_Download_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChatServer).Download(m, &chatDownloadServer{stream})
+}
+
+type Chat_DownloadServer interface {
+	Send(*DownloadResponse) error
+	grpc.ServerStream
+}
+
+type chatDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *chatDownloadServer) Send(m *DownloadResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chat_Keys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatServer).Keys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Chat/Keys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatServer).Keys(ctx, req.(*KeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Chat_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.Chat",
+	HandlerType: (*ChatServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _Chat_Login_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Chat_Logout_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _Chat_Search_Handler,
+		},
+		{
+			MethodName: "Keys",
+			Handler:    _Chat_Keys_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Chat_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Upload",
+			Handler:       _Chat_Upload_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       _Chat_Download_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/chat/chat.proto",
+}
+
+// FederationClient is the client API for Federation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type FederationClient interface {
+	Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*PushResponse, error)
+}
+
+type federationClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewFederationClient(cc *grpc.ClientConn) FederationClient {
+	return &federationClient{cc}
+}
+
+func (c *federationClient) Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*PushResponse, error) {
+	out := new(PushResponse)
+	err := c.cc.Invoke(ctx, "/chat.Federation/Push", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FederationServer is the server API for Federation service.
+type FederationServer interface {
+	Push(context.Context, *PushRequest) (*PushResponse, error)
+}
+
+func RegisterFederationServer(s *grpc.Server, srv FederationServer) {
+	s.RegisterService(&_Federation_serviceDesc, srv)
+}
+
+func _Federation_Push_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FederationServer).Push(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Federation/Push",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FederationServer).Push(ctx, req.(*PushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Federation_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.Federation",
+	HandlerType: (*FederationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Push",
+			Handler:    _Federation_Push_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/chat/chat.proto",
+}
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AdminClient interface {
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	Kick(ctx context.Context, in *KickRequest, opts ...grpc.CallOption) (*KickResponse, error)
+	Ban(ctx context.Context, in *BanRequest, opts ...grpc.CallOption) (*BanResponse, error)
+	Mute(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*MuteResponse, error)
+	Announce(ctx context.Context, in *AnnounceRequest, opts ...grpc.CallOption) (*AnnounceResponse, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	ReloadRoles(ctx context.Context, in *ReloadRolesRequest, opts ...grpc.CallOption) (*ReloadRolesResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Admin_ExportClient, error)
+	Import(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportClient, error)
+}
+
+type adminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Kick(ctx context.Context, in *KickRequest, opts ...grpc.CallOption) (*KickResponse, error) {
+	out := new(KickResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/Kick", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Ban(ctx context.Context, in *BanRequest, opts ...grpc.CallOption) (*BanResponse, error) {
+	out := new(BanResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/Ban", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Mute(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*MuteResponse, error) {
+	out := new(MuteResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/Mute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Announce(ctx context.Context, in *AnnounceRequest, opts ...grpc.CallOption) (*AnnounceResponse, error) {
+	out := new(AnnounceResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/Announce", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ReloadRoles(ctx context.Context, in *ReloadRolesRequest, opts ...grpc.CallOption) (*ReloadRolesResponse, error) {
+	out := new(ReloadRolesResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/ReloadRoles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/chat.Admin/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Admin_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Admin_serviceDesc.Streams[0], "/chat.Admin/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_ExportClient interface {
+	Recv() (*HistoryRecord, error)
 	grpc.ClientStream
 }
 
-func (x *chatStreamClient) Send(m *RequestStream) error {
+type adminExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminExportClient) Recv() (*HistoryRecord, error) {
+	m := new(HistoryRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) Import(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Admin_serviceDesc.Streams[1], "/chat.Admin/Import", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminImportClient{stream}
+	return x, nil
+}
+
+type Admin_ImportClient interface {
+	Send(*HistoryRecord) error
+	CloseAndRecv() (*ImportResponse, error)
+	grpc.ClientStream
+}
+
+type adminImportClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminImportClient) Send(m *HistoryRecord) error {
 	return x.ClientStream.SendMsg(m)
 }
 
-func (x *chatStreamClient) Recv() (*ResponseStream, error) {
-	m := new(ResponseStream)
+func (x *adminImportClient) CloseAndRecv() (*ImportResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportResponse)
 	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-// ChatServer is the server API for Chat service.
-type ChatServer interface {
-	Login(context.Context, *LoginRequest) (*LoginResponse, error)
-	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
-	Stream(Chat_StreamServer) error
+// AdminServer is the server API for Admin service.
+type AdminServer interface {
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	Kick(context.Context, *KickRequest) (*KickResponse, error)
+	Ban(context.Context, *BanRequest) (*BanResponse, error)
+	Mute(context.Context, *MuteRequest) (*MuteResponse, error)
+	Announce(context.Context, *AnnounceRequest) (*AnnounceResponse, error)
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	ReloadRoles(context.Context, *ReloadRolesRequest) (*ReloadRolesResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+	Export(*ExportRequest, Admin_ExportServer) error
+	Import(Admin_ImportServer) error
 }
 
-func RegisterChatServer(s *grpc.Server, srv ChatServer) {
-	s.RegisterService(&_Chat_serviceDesc, srv)
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
 }
 
-func _Chat_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LoginRequest)
+func _Admin_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).Login(ctx, in)
+		return srv.(AdminServer).ListSessions(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/chat.Chat/Login",
+		FullMethod: "/chat.Admin/ListSessions",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).Login(ctx, req.(*LoginRequest))
+		return srv.(AdminServer).ListSessions(ctx, req.(*ListSessionsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LogoutRequest)
+func _Admin_Kick_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatServer).Logout(ctx, in)
+		return srv.(AdminServer).Kick(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/chat.Chat/Logout",
+		FullMethod: "/chat.Admin/Kick",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatServer).Logout(ctx, req.(*LogoutRequest))
+		return srv.(AdminServer).Kick(ctx, req.(*KickRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chat_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(ChatServer).Stream(&chatStreamServer{stream})
+func _Admin_Ban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Ban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/Ban",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Ban(ctx, req.(*BanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type Chat_StreamServer interface {
-	Send(*ResponseStream) error
-	Recv() (*RequestStream, error)
+func _Admin_Mute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Mute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/Mute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Mute(ctx, req.(*MuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Announce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnnounceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Announce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/Announce",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Announce(ctx, req.(*AnnounceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ReloadRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRolesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReloadRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/ReloadRoles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReloadRoles(ctx, req.(*ReloadRolesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chat.Admin/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Export(m, &adminExportServer{stream})
+}
+
+type Admin_ExportServer interface {
+	Send(*HistoryRecord) error
 	grpc.ServerStream
 }
 
-type chatStreamServer struct {
+type adminExportServer struct {
 	grpc.ServerStream
 }
 
-func (x *chatStreamServer) Send(m *ResponseStream) error {
+func (x *adminExportServer) Send(m *HistoryRecord) error {
 	return x.ServerStream.SendMsg(m)
 }
 
-func (x *chatStreamServer) Recv() (*RequestStream, error) {
-	m := new(RequestStream)
+func _Admin_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).Import(&adminImportServer{stream})
+}
+
+type Admin_ImportServer interface {
+	SendAndClose(*ImportResponse) error
+	Recv() (*HistoryRecord, error)
+	grpc.ServerStream
+}
+
+type adminImportServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminImportServer) SendAndClose(m *ImportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminImportServer) Recv() (*HistoryRecord, error) {
+	m := new(HistoryRecord)
 	if err := x.ServerStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-var _Chat_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "chat.Chat",
-	HandlerType: (*ChatServer)(nil),
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "chat.Admin",
+	HandlerType: (*AdminServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "Login",
-			Handler:    _Chat_Login_Handler,
+			MethodName: "ListSessions",
+			Handler:    _Admin_ListSessions_Handler,
 		},
 		{
-			MethodName: "Logout",
-			Handler:    _Chat_Logout_Handler,
+			MethodName: "Kick",
+			Handler:    _Admin_Kick_Handler,
+		},
+		{
+			MethodName: "Ban",
+			Handler:    _Admin_Ban_Handler,
+		},
+		{
+			MethodName: "Mute",
+			Handler:    _Admin_Mute_Handler,
+		},
+		{
+			MethodName: "Announce",
+			Handler:    _Admin_Announce_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Admin_Stats_Handler,
+		},
+		{
+			MethodName: "ReloadRoles",
+			Handler:    _Admin_ReloadRoles_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _Admin_SetLogLevel_Handler,
 		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
-			StreamName:    "Stream",
-			Handler:       _Chat_Stream_Handler,
+			StreamName:    "Export",
+			Handler:       _Admin_Export_Handler,
 			ServerStreams: true,
+		},
+		{
+			StreamName:    "Import",
+			Handler:       _Admin_Import_Handler,
 			ClientStreams: true,
 		},
 	},
 	Metadata: "pkg/chat/chat.proto",
 }
 
-func init() { proto.RegisterFile("pkg/chat/chat.proto", fileDescriptor_chat_1b2230346337dc13) }
-
-var fileDescriptor_chat_1b2230346337dc13 = []byte{
-	// 414 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0x4d, 0x4f, 0xea, 0x40,
-	0x14, 0x6d, 0xa1, 0x85, 0xc7, 0x05, 0xfa, 0x5e, 0x06, 0x16, 0x4d, 0xe1, 0x45, 0xd3, 0xc4, 0x04,
-	0x37, 0xc5, 0x60, 0x8c, 0xba, 0x32, 0xc1, 0x98, 0x74, 0xa1, 0x9b, 0xe2, 0x9e, 0x14, 0x1c, 0x0b,
-	0x81, 0x76, 0x6a, 0x67, 0x8a, 0x7f, 0xca, 0x3f, 0xe6, 0xbf, 0x30, 0xcc, 0x47, 0x4b, 0x93, 0xba,
-	0x69, 0x3a, 0x77, 0xce, 0x39, 0xf7, 0x9e, 0x3b, 0x07, 0x06, 0xe9, 0x2e, 0x9a, 0xae, 0x37, 0x21,
-	0xe3, 0x1f, 0x2f, 0xcd, 0x08, 0x23, 0xc8, 0x38, 0xfe, 0x3b, 0x67, 0x11, 0x21, 0xd1, 0x1e, 0x4f,
-	0x79, 0x6d, 0x95, 0xbf, 0x4f, 0xd9, 0x36, 0xc6, 0x94, 0x85, 0x71, 0x2a, 0x60, 0xae, 0x0b, 0xbd,
-	0x67, 0x12, 0x6d, 0x93, 0x00, 0x7f, 0xe4, 0x98, 0x32, 0x84, 0xc0, 0x48, 0xc2, 0x18, 0xdb, 0xfa,
-	0xb9, 0x3e, 0xe9, 0x04, 0xfc, 0xdf, 0xbd, 0x80, 0xbe, 0xc4, 0xd0, 0x94, 0x24, 0x14, 0xa3, 0x21,
-	0x98, 0x8c, 0xec, 0x70, 0x22, 0x51, 0xe2, 0x20, 0x61, 0x24, 0x67, 0x4a, 0xab, 0x1e, 0xf6, 0x0f,
-	0x2c, 0x05, 0x13, 0x72, 0xee, 0x25, 0xf4, 0x25, 0x65, 0xc1, 0x32, 0x1c, 0xc6, 0xc8, 0x86, 0x76,
-	0x8c, 0x29, 0x0d, 0x23, 0x35, 0x87, 0x3a, 0xba, 0xdf, 0x4d, 0xb0, 0x14, 0x4f, 0x82, 0xef, 0xa0,
-	0x53, 0x98, 0xe2, 0xf0, 0xee, 0xcc, 0xf1, 0x84, 0x6d, 0x4f, 0xd9, 0xf6, 0x5e, 0x15, 0x22, 0x28,
-	0xc1, 0xe8, 0x01, 0x7a, 0xeb, 0xfd, 0x16, 0x27, 0x6c, 0xb9, 0x3f, 0xda, 0xb3, 0x1b, 0x92, 0xcc,
-	0xb7, 0x58, 0xed, 0xe2, 0xf1, 0x05, 0xf8, 0x5a, 0xd0, 0x15, 0x0c, 0x7e, 0x44, 0x73, 0xe8, 0x97,
-	0x02, 0x24, 0x67, 0x76, 0x93, 0x2b, 0x8c, 0x7e, 0x53, 0x20, 0x39, 0xf3, 0xb5, 0xa0, 0x57, 0x48,
-	0x90, 0x9c, 0xa1, 0x27, 0xb0, 0xa4, 0x86, 0xb2, 0x6c, 0x70, 0x91, 0x71, 0xad, 0xc8, 0x8b, 0xc0,
-	0xf8, 0x5a, 0x20, 0x3b, 0xcb, 0x02, 0xf2, 0xe1, 0x2f, 0xc5, 0xd9, 0x01, 0x67, 0x4b, 0xba, 0xc9,
-	0xd9, 0x1b, 0xf9, 0x4c, 0x6c, 0x93, 0xeb, 0xfc, 0xaf, 0xd5, 0x59, 0x48, 0x90, 0xaf, 0x05, 0x96,
-	0xe0, 0xa9, 0x8a, 0x33, 0x02, 0x53, 0xb8, 0xab, 0x89, 0x82, 0x33, 0x86, 0x96, 0x9c, 0xbb, 0xee,
-	0xf6, 0x16, 0xda, 0x6a, 0x9e, 0x9a, 0xeb, 0xd3, 0x67, 0x6d, 0x54, 0x9e, 0xd5, 0x01, 0xf8, 0xa3,
-	0xfa, 0xcf, 0xdb, 0x60, 0xe2, 0x03, 0x4e, 0xd8, 0xec, 0x4b, 0x07, 0xe3, 0x71, 0x13, 0x32, 0x34,
-	0x2b, 0x26, 0x12, 0x5e, 0x4e, 0x03, 0xeb, 0x0c, 0x2a, 0x35, 0x99, 0x28, 0x0d, 0xdd, 0x14, 0x83,
-	0x96, 0x80, 0x32, 0x9a, 0xce, 0xb0, 0x5a, 0x2c, 0x68, 0xf7, 0xd0, 0x92, 0xb1, 0x1a, 0xa8, 0xbd,
-	0x9d, 0x04, 0x53, 0xd1, 0xaa, 0xcb, 0x74, 0xb5, 0x89, 0x7e, 0xa5, 0xaf, 0x5a, 0x3c, 0x6c, 0xd7,
-	0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb3, 0x82, 0x21, 0x46, 0x8e, 0x03, 0x00, 0x00,
+func init() { proto.RegisterFile("pkg/chat/chat.proto", fileDescriptor_chat_2c71123fdf69f8e5) }
+
+var fileDescriptor_chat_2c71123fdf69f8e5 = []byte{
+	// 1683 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0xeb, 0x6e, 0x1b, 0xc5,
+	0x17, 0xf7, 0x75, 0x63, 0x1f, 0x5f, 0x92, 0x8e, 0xd3, 0xc8, 0xd9, 0xde, 0xf2, 0x5f, 0xa9, 0x7f,
+	0x42, 0x5b, 0x25, 0xc1, 0xa8, 0x0d, 0x05, 0x55, 0x28, 0x21, 0x6d, 0x5d, 0x92, 0x0a, 0xd8, 0xb4,
+	0xa2, 0x42, 0x48, 0xd1, 0x66, 0x77, 0x6a, 0xaf, 0x6c, 0xef, 0xb8, 0xbb, 0xb3, 0x69, 0x03, 0xaf,
+	0xc0, 0x67, 0x24, 0x1e, 0x01, 0x1e, 0x86, 0x2f, 0x3c, 0x01, 0x6f, 0x82, 0xe6, 0xba, 0xb3, 0x8e,
+	0x93, 0x14, 0x10, 0x5f, 0x2c, 0x9f, 0x33, 0xe7, 0x9c, 0x39, 0xd7, 0xdf, 0x99, 0x85, 0xce, 0x74,
+	0x34, 0xd8, 0xf4, 0x87, 0x1e, 0xe5, 0x3f, 0x1b, 0xd3, 0x98, 0x50, 0x82, 0x2a, 0xec, 0xbf, 0x7d,
+	0x73, 0x40, 0xc8, 0x60, 0x8c, 0x37, 0x39, 0xef, 0x38, 0x7d, 0xbd, 0x19, 0xa4, 0xb1, 0x47, 0x43,
+	0x12, 0x09, 0x29, 0xfb, 0xd6, 0xec, 0x39, 0x0d, 0x27, 0x38, 0xa1, 0xde, 0x64, 0x2a, 0x04, 0x9c,
+	0x63, 0x68, 0x1e, 0x90, 0x41, 0x18, 0xb9, 0xf8, 0x4d, 0x8a, 0x13, 0x8a, 0x10, 0x54, 0x22, 0x6f,
+	0x82, 0xbb, 0xc5, 0xb5, 0xe2, 0x7a, 0xdd, 0xe5, 0xff, 0xd1, 0x0d, 0x80, 0x69, 0x7a, 0x3c, 0x0e,
+	0xfd, 0xa3, 0x11, 0x3e, 0xed, 0x96, 0xd6, 0x8a, 0xeb, 0x4d, 0xb7, 0x2e, 0x38, 0xfb, 0xf8, 0x14,
+	0xdd, 0x82, 0x46, 0x12, 0x0e, 0xa2, 0x30, 0x1a, 0xf0, 0xf3, 0x32, 0x3f, 0x07, 0xc9, 0xda, 0xc7,
+	0xa7, 0xce, 0x43, 0x68, 0xc9, 0x3b, 0x92, 0x29, 0x89, 0x12, 0x8c, 0x96, 0xa1, 0x4a, 0xc9, 0x08,
+	0x47, 0xf2, 0x16, 0x41, 0xe8, 0xab, 0x4b, 0xd9, 0xd5, 0xce, 0x6d, 0xae, 0x4a, 0x52, 0xaa, 0xfc,
+	0x9b, 0xab, 0xea, 0x2c, 0x41, 0x5b, 0x89, 0x89, 0x2b, 0x9c, 0x9f, 0x8b, 0xd0, 0x92, 0x3a, 0x87,
+	0x34, 0xc6, 0xde, 0x04, 0x75, 0x61, 0x61, 0x82, 0x93, 0xc4, 0x1b, 0xa8, 0xe0, 0x14, 0x89, 0xd6,
+	0xa0, 0xe1, 0x51, 0xea, 0xf9, 0xc3, 0x09, 0x8e, 0x68, 0xd2, 0x2d, 0xad, 0x95, 0xd7, 0xeb, 0xae,
+	0xc9, 0x42, 0x77, 0xc1, 0x0a, 0xc2, 0x18, 0xfb, 0x94, 0x47, 0xd7, 0xe8, 0x75, 0x36, 0x78, 0x25,
+	0xf6, 0x38, 0xef, 0xb9, 0x30, 0xe3, 0x4a, 0x11, 0x74, 0x1d, 0xea, 0x2c, 0x78, 0x8f, 0xa6, 0x31,
+	0xee, 0x56, 0x44, 0xb6, 0x34, 0xc3, 0x79, 0x0e, 0xad, 0x9c, 0x1a, 0x6a, 0x43, 0x89, 0x12, 0xe9,
+	0x52, 0x89, 0x12, 0x74, 0x0f, 0xea, 0x38, 0x3a, 0xc1, 0x63, 0x32, 0xc5, 0xc2, 0x97, 0x46, 0xaf,
+	0x2d, 0xae, 0x7b, 0x2c, 0xd9, 0x6e, 0x26, 0xe0, 0xbc, 0x82, 0x9a, 0x62, 0xa3, 0x15, 0xb0, 0x02,
+	0x7c, 0x12, 0xfa, 0x2a, 0x40, 0x49, 0xb1, 0x9c, 0x45, 0x24, 0xf2, 0xb1, 0x2c, 0x9d, 0x20, 0xd0,
+	0x4d, 0x00, 0x3f, 0x9c, 0x0e, 0x71, 0x4c, 0xf1, 0x3b, 0xaa, 0xaa, 0x96, 0x71, 0x9c, 0x5f, 0x6b,
+	0xd0, 0x56, 0xe9, 0x94, 0x29, 0xfc, 0x04, 0xea, 0xba, 0x7f, 0xf8, 0x1d, 0x8d, 0x9e, 0xbd, 0x21,
+	0x3a, 0x6c, 0x43, 0x75, 0xd8, 0xc6, 0x0b, 0x25, 0xe1, 0x66, 0xc2, 0xe8, 0x73, 0x68, 0xfa, 0xe3,
+	0x10, 0x47, 0xf4, 0x68, 0xcc, 0x3a, 0xa1, 0x5b, 0x92, 0xca, 0x3c, 0xae, 0xfc, 0x2d, 0x1b, 0xbc,
+	0x57, 0xfa, 0x05, 0xb7, 0x21, 0x34, 0x38, 0x89, 0x76, 0xa1, 0x95, 0x19, 0x20, 0xa9, 0x2a, 0xc4,
+	0xb5, 0xf3, 0x2c, 0x90, 0x94, 0xf6, 0x0b, 0x6e, 0x53, 0x9b, 0x20, 0x29, 0x45, 0x8f, 0xa1, 0x2d,
+	0x6d, 0xa8, 0x46, 0xa8, 0x70, 0x23, 0xd7, 0xe7, 0x1a, 0x91, 0xf5, 0xe9, 0x17, 0x5c, 0x79, 0xb3,
+	0x2a, 0x58, 0x1f, 0x16, 0x13, 0x1c, 0x9f, 0xe0, 0xf8, 0x28, 0x19, 0xa6, 0x34, 0x20, 0x6f, 0xa3,
+	0x6e, 0x95, 0xdb, 0xb9, 0x31, 0xd7, 0xce, 0xa1, 0x14, 0xea, 0x17, 0xdc, 0xb6, 0xd0, 0x53, 0x1c,
+	0xf4, 0x02, 0x3a, 0xd2, 0x92, 0x17, 0x45, 0x24, 0x8d, 0x7c, 0xcc, 0xda, 0xad, 0x6b, 0x71, 0x6b,
+	0xff, 0x9b, 0x6b, 0x6d, 0xc7, 0x10, 0xec, 0x17, 0x5c, 0x24, 0xf4, 0x4d, 0x2e, 0xda, 0x83, 0xb6,
+	0xe8, 0x44, 0x1d, 0x66, 0xfd, 0x82, 0x5c, 0x89, 0x66, 0x64, 0x51, 0x06, 0xb9, 0xb6, 0x5c, 0x83,
+	0x06, 0x8d, 0x3d, 0x1f, 0x4f, 0xbd, 0x98, 0xf9, 0xb4, 0xc0, 0x3b, 0xca, 0x64, 0xb1, 0xc6, 0x0d,
+	0x83, 0x6e, 0x4d, 0x34, 0x6e, 0x18, 0xd8, 0xd7, 0xa0, 0x2a, 0x6a, 0x35, 0x07, 0x43, 0xec, 0xeb,
+	0x60, 0xc9, 0x2a, 0xcc, 0x3b, 0xfd, 0xad, 0x08, 0x0b, 0xea, 0xe2, 0x39, 0xe7, 0xe6, 0xec, 0x96,
+	0xf2, 0xb3, 0xdb, 0xcb, 0xcf, 0x6e, 0x99, 0xcf, 0xcb, 0x92, 0x88, 0x74, 0x47, 0x1f, 0xe4, 0xa7,
+	0xf9, 0xc2, 0x01, 0x9d, 0x85, 0xb3, 0xea, 0x2c, 0x9c, 0xd9, 0xbb, 0x50, 0xd3, 0x15, 0x7c, 0x00,
+	0xb5, 0x00, 0x7b, 0xc1, 0x38, 0x8c, 0xf0, 0x7b, 0x0c, 0x84, 0x96, 0xb5, 0xd7, 0xa1, 0x99, 0xab,
+	0xd9, 0xb9, 0xe0, 0x64, 0xff, 0x52, 0x04, 0x4b, 0xd4, 0x88, 0x65, 0xe6, 0x75, 0x4c, 0x26, 0x2a,
+	0x33, 0xec, 0xbf, 0x44, 0x8f, 0x92, 0x46, 0x8f, 0x1b, 0x00, 0x09, 0x8e, 0x02, 0x1c, 0x1b, 0x58,
+	0x5c, 0x17, 0x1c, 0x86, 0xd5, 0x1a, 0x0a, 0x2a, 0xe7, 0x43, 0x41, 0x75, 0x16, 0x0a, 0x0c, 0x60,
+	0xb1, 0x4c, 0x60, 0xd9, 0x5d, 0x80, 0x2a, 0x3e, 0xc1, 0x11, 0x75, 0xfe, 0x2c, 0x42, 0xeb, 0x10,
+	0x7b, 0xb1, 0x3f, 0xbc, 0x10, 0xa7, 0x19, 0xf7, 0x4d, 0x8a, 0xe3, 0x53, 0xe9, 0xb0, 0x20, 0x98,
+	0x79, 0x2f, 0xa5, 0x43, 0x12, 0x73, 0x7f, 0xeb, 0xae, 0xa4, 0x58, 0xbc, 0x31, 0x21, 0x13, 0xee,
+	0x6b, 0xdd, 0xe5, 0xff, 0xd1, 0x16, 0x54, 0x93, 0x90, 0x05, 0x50, 0xbd, 0x34, 0xdb, 0x42, 0x90,
+	0x69, 0xa4, 0x11, 0x0d, 0xc7, 0x5d, 0xeb, 0x72, 0x0d, 0x2e, 0xc8, 0xbc, 0x1c, 0x87, 0x93, 0x50,
+	0x34, 0x7d, 0xd5, 0x15, 0x84, 0xb3, 0x0b, 0x6d, 0x15, 0xa2, 0x5c, 0x63, 0x5b, 0x50, 0x93, 0x55,
+	0x4a, 0xba, 0x45, 0xde, 0x78, 0xcb, 0xf3, 0x46, 0xcc, 0xd5, 0x52, 0xce, 0xf7, 0x00, 0x59, 0x53,
+	0xca, 0x01, 0x2a, 0xaa, 0x01, 0x9a, 0xb7, 0x00, 0x19, 0x2f, 0x09, 0x7f, 0xc0, 0x3c, 0x33, 0x65,
+	0x97, 0xff, 0x67, 0xf9, 0x4a, 0x86, 0x5e, 0xef, 0xfe, 0x03, 0x99, 0x19, 0x49, 0x39, 0x3f, 0x42,
+	0xeb, 0xe5, 0x74, 0x4c, 0xbc, 0xe0, 0xe2, 0x22, 0xfc, 0xcb, 0x6b, 0x98, 0x55, 0x7f, 0x98, 0x46,
+	0x23, 0xd9, 0x28, 0x82, 0x60, 0xe9, 0x51, 0x97, 0xeb, 0xf4, 0x40, 0x36, 0x75, 0x72, 0x3a, 0xce,
+	0x4e, 0xa6, 0x21, 0xe3, 0x6c, 0xc3, 0xe2, 0x1e, 0x79, 0x1b, 0x5d, 0x1e, 0x82, 0xc8, 0x5c, 0x49,
+	0x65, 0xce, 0xf9, 0x0e, 0x96, 0x32, 0xc5, 0x7f, 0x7a, 0x7d, 0x16, 0x58, 0xc9, 0x0c, 0x6c, 0x1b,
+	0x1a, 0xfb, 0xf8, 0x34, 0xf9, 0xdb, 0x39, 0x75, 0x1c, 0x68, 0x0a, 0x45, 0xe9, 0x10, 0x82, 0xca,
+	0x08, 0x9f, 0x8a, 0x56, 0x69, 0xba, 0xfc, 0xbf, 0x43, 0xa0, 0xf1, 0x75, 0x9a, 0xe8, 0xa9, 0xb9,
+	0x07, 0x16, 0x1f, 0xa8, 0x8b, 0xfb, 0x49, 0xca, 0xb0, 0x02, 0x91, 0x88, 0x43, 0x8f, 0x78, 0xb2,
+	0x48, 0x0a, 0xd9, 0x50, 0x4b, 0x22, 0x6f, 0x9a, 0x0c, 0x89, 0x58, 0x93, 0x35, 0x57, 0xd3, 0x4e,
+	0x1b, 0x9a, 0xe2, 0x42, 0xf9, 0x4e, 0xfa, 0x14, 0x16, 0x0e, 0x71, 0x92, 0x84, 0x64, 0x2e, 0x6c,
+	0xcb, 0x44, 0x97, 0x55, 0xa2, 0xbf, 0xac, 0xd4, 0x4a, 0x4b, 0x65, 0xf5, 0xea, 0xba, 0x0a, 0x9d,
+	0x83, 0x30, 0xa1, 0x52, 0x5f, 0x65, 0xc8, 0xd9, 0x81, 0xe5, 0x3c, 0x5b, 0xc6, 0xff, 0x21, 0xd4,
+	0x12, 0xc9, 0x93, 0xe1, 0xb5, 0x44, 0x78, 0x52, 0xd2, 0xd5, 0xc7, 0xce, 0x01, 0x34, 0xf6, 0x43,
+	0x7f, 0x94, 0xe5, 0xdc, 0xf0, 0xac, 0x5f, 0x90, 0xbe, 0x2d, 0x65, 0xbe, 0xf5, 0x0b, 0xcc, 0xbb,
+	0xdd, 0x1a, 0x58, 0xd4, 0x8b, 0x07, 0x98, 0xe6, 0xfd, 0xfc, 0x3f, 0x34, 0x85, 0x35, 0xe9, 0xc8,
+	0x0a, 0x58, 0xa3, 0xd0, 0x1f, 0x61, 0x31, 0x7b, 0x55, 0x57, 0x52, 0xce, 0xb7, 0x00, 0xbb, 0xde,
+	0x85, 0x2f, 0xe1, 0xfb, 0x50, 0x53, 0x0f, 0x6c, 0xf9, 0x84, 0x59, 0x3d, 0x03, 0x27, 0x7b, 0x52,
+	0xc0, 0xd5, 0xa2, 0xce, 0x6d, 0x68, 0x70, 0xc3, 0x97, 0xdc, 0xff, 0x0a, 0x1a, 0xcf, 0x53, 0x8a,
+	0xff, 0x03, 0x07, 0xda, 0xd0, 0x14, 0x96, 0x65, 0xd5, 0xef, 0xc2, 0xa2, 0x5a, 0x3f, 0xea, 0xb6,
+	0x73, 0x37, 0x90, 0x83, 0x60, 0x29, 0x13, 0x96, 0x06, 0xda, 0xd0, 0x3c, 0xa4, 0x1e, 0xd5, 0x35,
+	0xff, 0x83, 0x2d, 0x00, 0xc1, 0xc8, 0x82, 0x94, 0xcd, 0x29, 0x83, 0x34, 0x9a, 0x53, 0x75, 0x41,
+	0x89, 0x9f, 0x68, 0x9a, 0xe9, 0xf0, 0xe7, 0x61, 0x22, 0x71, 0x48, 0x52, 0x4c, 0x47, 0x03, 0x6d,
+	0x85, 0x9f, 0x68, 0x1a, 0x7d, 0x00, 0x8b, 0xc7, 0x31, 0xf1, 0x02, 0xdf, 0x4b, 0xe8, 0xd1, 0x9b,
+	0x14, 0xa7, 0x62, 0x35, 0x54, 0xdd, 0xb6, 0x66, 0x7f, 0xc3, 0xb8, 0xe8, 0x23, 0xb0, 0xd2, 0x29,
+	0x7b, 0x91, 0x76, 0xad, 0xcb, 0x12, 0x27, 0x05, 0x9d, 0x65, 0x40, 0x2e, 0xe6, 0xa0, 0x42, 0xc6,
+	0x58, 0xc7, 0x7a, 0x15, 0x3a, 0x39, 0xae, 0x4c, 0xc9, 0x1d, 0x40, 0x87, 0x98, 0x3d, 0x35, 0x0f,
+	0xf0, 0x09, 0x1e, 0x1b, 0x70, 0x31, 0x66, 0xb4, 0x82, 0x0b, 0x4e, 0x38, 0x4f, 0xa1, 0x93, 0x93,
+	0xcd, 0xbe, 0x8b, 0xce, 0x0a, 0xb3, 0xe8, 0xa7, 0x31, 0x3e, 0x09, 0x49, 0x9a, 0x48, 0x7c, 0xd1,
+	0xb4, 0xf3, 0x15, 0xb4, 0xfa, 0x61, 0x42, 0x49, 0x7c, 0xea, 0x62, 0x9f, 0xc4, 0x81, 0xde, 0x99,
+	0x45, 0x63, 0x67, 0xde, 0x91, 0x6b, 0x5a, 0x76, 0xcc, 0x7c, 0x50, 0x91, 0x9b, 0xfc, 0x25, 0xb4,
+	0x1e, 0xbf, 0x9b, 0x92, 0x58, 0x7f, 0x70, 0xe9, 0x85, 0x5b, 0x7c, 0xdf, 0x85, 0xab, 0x5c, 0x28,
+	0x65, 0x2e, 0x38, 0x4f, 0xa0, 0xfd, 0x6c, 0x22, 0xcc, 0xca, 0x58, 0x6d, 0xa8, 0x85, 0x9c, 0xa3,
+	0xc7, 0x40, 0xd3, 0xac, 0x17, 0x93, 0x51, 0x38, 0x9d, 0xe2, 0x40, 0xb6, 0x88, 0x22, 0x7b, 0x3f,
+	0x95, 0xa1, 0xf2, 0xc5, 0xd0, 0xa3, 0xa8, 0xa7, 0x1f, 0x9b, 0x22, 0x1a, 0xf3, 0x23, 0xd6, 0xee,
+	0xe4, 0x78, 0xb2, 0x3e, 0x05, 0x74, 0x5f, 0xbf, 0x41, 0x33, 0x81, 0xec, 0xd3, 0xd2, 0x5e, 0xce,
+	0x33, 0xb5, 0xda, 0x43, 0xb0, 0xe4, 0xf7, 0x4f, 0x47, 0x65, 0xce, 0xf8, 0xae, 0xb4, 0xe7, 0xa6,
+	0xd3, 0x29, 0xac, 0x17, 0xb7, 0x8a, 0xec, 0x46, 0xf1, 0x66, 0x50, 0xaa, 0xb9, 0x47, 0x92, 0xbd,
+	0x9c, 0x67, 0xea, 0x1b, 0xb7, 0xc1, 0x12, 0xbb, 0x54, 0xa9, 0xe5, 0xd6, 0xba, 0xbd, 0x9c, 0x67,
+	0x2a, 0xb5, 0xf5, 0x22, 0x7a, 0x04, 0x35, 0xb5, 0x07, 0xd1, 0x55, 0xf9, 0x8d, 0x9a, 0x5f, 0xa8,
+	0xf6, 0xca, 0x2c, 0x5b, 0xa9, 0x6f, 0x15, 0xd1, 0x26, 0x54, 0xd8, 0xc6, 0x42, 0x57, 0x84, 0x8c,
+	0xb1, 0xf6, 0x6c, 0x64, 0xb2, 0x94, 0x4a, 0xef, 0x11, 0xc0, 0x13, 0x1c, 0x60, 0x31, 0x36, 0x4c,
+	0x9d, 0xed, 0x16, 0xa5, 0x6e, 0x2c, 0x36, 0x1b, 0x99, 0x2c, 0xad, 0xfe, 0x7b, 0x05, 0xaa, 0x3b,
+	0xc1, 0x24, 0x8c, 0xd0, 0x53, 0x68, 0x9a, 0x3b, 0x03, 0xad, 0xca, 0x5a, 0x9c, 0x5d, 0x2f, 0xb6,
+	0x3d, 0xef, 0x48, 0xa7, 0x8e, 0x85, 0x10, 0xfa, 0x23, 0x1d, 0x42, 0xb6, 0x45, 0x6c, 0x64, 0xb2,
+	0xb4, 0xc2, 0x3d, 0x28, 0xef, 0x7a, 0x11, 0x92, 0x2f, 0x83, 0x0c, 0xff, 0xed, 0x2b, 0x06, 0xc7,
+	0x34, 0xcf, 0x80, 0x54, 0x99, 0x37, 0xe0, 0xda, 0x46, 0x26, 0x4b, 0x2b, 0x7c, 0x06, 0x35, 0x05,
+	0x9e, 0xaa, 0x22, 0x33, 0xc8, 0x6b, 0xaf, 0xcc, 0xb2, 0xb5, 0x72, 0x0f, 0xaa, 0x1c, 0x54, 0x55,
+	0x93, 0x9b, 0x90, 0x6b, 0x77, 0x72, 0x3c, 0xad, 0xb3, 0x07, 0x0d, 0x03, 0x9d, 0x50, 0x57, 0x75,
+	0xe7, 0x2c, 0x8c, 0xd9, 0xab, 0x73, 0x4e, 0x4c, 0x2b, 0x06, 0x40, 0x29, 0x2b, 0x67, 0xf1, 0xcd,
+	0x5e, 0x9d, 0x73, 0xa2, 0xad, 0x3c, 0x00, 0x4b, 0x80, 0x89, 0xea, 0xe3, 0x1c, 0xb4, 0xa8, 0x08,
+	0x72, 0x00, 0xc6, 0xfb, 0x70, 0x1b, 0xac, 0x67, 0x13, 0x53, 0x2f, 0x27, 0xa2, 0xfa, 0x3f, 0x0f,
+	0x28, 0xac, 0xff, 0x8f, 0x2d, 0x8e, 0x4a, 0x1f, 0xff, 0x35, 0x00, 0x67, 0x3e, 0x11, 0x81, 0x32,
+	0x13, 0x00, 0x00,
 }
@@ -0,0 +1,527 @@
+package chatctl
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/constants"
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+const ms = 500
+
+// output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Usage describes available commands
+const Usage = `Commands:
+  sessions                      list client sessions
+  kick <name>                   close all sessions of the client
+  kick -session <id>            close single session
+  ban [-for 24h] <name>         forbid client to log in and close its sessions
+  unban <name>                  lift the ban
+  mute [-for 1h] <name>         forbid client to send messages
+  unmute <name>                 lift the mute
+  announce <message>            send server announcement to all clients
+  stats                         show server statistics
+  reload-roles                  read roles file of the server again
+  log-level [level]             show or change log level of the server (debug, info, warn, error)
+  audit verify <file>           check hash chain of audit log and its rotated files
+  export [-since 24h] [-room chat] [-format jsonl|txt|html]
+                                write chat history to stdout
+  import <file>                 add chat history exported in jsonl format, kept records are skipped
+`
+
+// command runs single chatctl command
+type command func(ctx context.Context, c *Ctl, args []string) error
+
+var commands = map[string]command{
+	"sessions":     sessions,
+	"kick":         kick,
+	"ban":          ban,
+	"unban":        unban,
+	"mute":         mute,
+	"unmute":       unmute,
+	"announce":     announce,
+	"stats":        stats,
+	"reload-roles": reloadRoles,
+	"log-level":    logLevel,
+	"audit":        auditLog,
+	"export":       export,
+	"import":       importHistory,
+}
+
+// NewCtl returns Ctl pointer
+func NewCtl(addr, token, format string, out io.Writer, allowDebug bool) (*Ctl, error) {
+	// basic server address validation
+	if addr == "" {
+		return nil, errors.New("Invalid address")
+	}
+
+	if format != FormatTable && format != FormatJSON {
+		return nil, errors.Errorf("Unknown output format %q", format)
+	}
+
+	return &Ctl{
+		Addr:    addr,
+		Token:   token,
+		Format:  format,
+		Out:     out,
+		Timeout: time.Duration(ms) * time.Millisecond,
+		Logger:  logger.NewLogger(allowDebug),
+	}, nil
+}
+
+// Ctl struct operates running server through Admin service
+type Ctl struct {
+	Addr    string
+	Token   string
+	Format  string
+	Out     io.Writer
+	Timeout time.Duration
+	Logger  *logger.Logger
+	// TLS encrypts connection to the server, connection is not encrypted if nil
+	TLS *tls.Config
+}
+
+// Run method runs command with arguments
+func (c *Ctl) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("Command is required")
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errors.Errorf("Unknown command %q", args[0])
+	}
+
+	return cmd(ctx, c, args[1:])
+}
+
+// admin method connects to the server and returns Admin client with authorized context
+func (c *Ctl) admin(ctx context.Context) (chat.AdminClient, context.Context, func(), error) {
+	connCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	creds := grpc.WithInsecure()
+	if c.TLS != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(c.TLS))
+	}
+
+	conn, err := grpc.DialContext(connCtx, c.Addr, creds, grpc.WithBlock())
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "failed to connect to provided address")
+	}
+
+	c.Logger.Debug("Connected", "addr", c.Addr)
+
+	md := metadata.New(map[string]string{constants.AdminTokenHeader: c.Token})
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	return chat.NewAdminClient(conn), ctx, func() { conn.Close() }, nil
+}
+
+// print method writes value as JSON or rows as table
+func (c *Ctl) print(v interface{}, header []string, rows [][]string) error {
+	if c.Format == FormatJSON {
+		enc := json.NewEncoder(c.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(c.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// newFlagSet returns flag set of the command which does not print to stderr
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	return fs
+}
+
+func sessions(ctx context.Context, c *Ctl, args []string) error {
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	res, err := admin.ListSessions(ctx, new(chat.ListSessionsRequest))
+	if err != nil {
+		return err
+	}
+
+	type session struct {
+		Name string `json:"name"`
+		ID   string `json:"id"`
+	}
+
+	list := make([]session, 0, len(res.Sessions))
+	rows := make([][]string, 0, len(res.Sessions))
+	for _, s := range res.Sessions {
+		list = append(list, session{Name: s.Name, ID: s.Id})
+		rows = append(rows, []string{s.Name, s.Id})
+	}
+
+	return c.print(list, []string{"NAME", "SESSION"}, rows)
+}
+
+func kick(ctx context.Context, c *Ctl, args []string) error {
+	fs := newFlagSet("kick")
+	id := fs.String("session", "", "session ID listed by sessions command")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	req := new(chat.KickRequest)
+	switch {
+	case *id != "":
+		req.Target = &chat.KickRequest_Id{Id: *id}
+	case fs.NArg() == 1:
+		req.Target = &chat.KickRequest_Name{Name: fs.Arg(0)}
+	default:
+		return errors.New("Usage: kick <name> | kick -session <id>")
+	}
+
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	res, err := admin.Kick(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.print(map[string]int32{"kicked": res.Kicked}, []string{"KICKED"}, [][]string{{fmt.Sprint(res.Kicked)}})
+}
+
+func ban(ctx context.Context, c *Ctl, args []string) error {
+	fs := newFlagSet("ban")
+	d := fs.Duration("for", 24*time.Hour, "ban duration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 || *d <= 0 {
+		return errors.New("Usage: ban [-for 24h] <name>")
+	}
+
+	return banFor(ctx, c, fs.Arg(0), *d)
+}
+
+func unban(ctx context.Context, c *Ctl, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: unban <name>")
+	}
+
+	return banFor(ctx, c, args[0], 0)
+}
+
+func banFor(ctx context.Context, c *Ctl, name string, d time.Duration) error {
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	res, err := admin.Ban(ctx, &chat.BanRequest{Name: name, Duration: ptypes.DurationProto(d)})
+	if err != nil {
+		return err
+	}
+
+	return c.print(map[string]interface{}{"name": name, "duration": d.String(), "kicked": res.Kicked},
+		[]string{"NAME", "DURATION", "KICKED"}, [][]string{{name, d.String(), fmt.Sprint(res.Kicked)}})
+}
+
+func mute(ctx context.Context, c *Ctl, args []string) error {
+	fs := newFlagSet("mute")
+	d := fs.Duration("for", time.Hour, "mute duration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 || *d <= 0 {
+		return errors.New("Usage: mute [-for 1h] <name>")
+	}
+
+	return muteFor(ctx, c, fs.Arg(0), *d)
+}
+
+func unmute(ctx context.Context, c *Ctl, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: unmute <name>")
+	}
+
+	return muteFor(ctx, c, args[0], 0)
+}
+
+func muteFor(ctx context.Context, c *Ctl, name string, d time.Duration) error {
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	if _, err := admin.Mute(ctx, &chat.MuteRequest{Name: name, Duration: ptypes.DurationProto(d)}); err != nil {
+		return err
+	}
+
+	return c.print(map[string]interface{}{"name": name, "duration": d.String()},
+		[]string{"NAME", "DURATION"}, [][]string{{name, d.String()}})
+}
+
+func announce(ctx context.Context, c *Ctl, args []string) error {
+	message := strings.Join(args, " ")
+	if message == "" {
+		return errors.New("Usage: announce <message>")
+	}
+
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	if _, err := admin.Announce(ctx, &chat.AnnounceRequest{Message: message}); err != nil {
+		return err
+	}
+
+	return c.print(map[string]string{"announced": message}, []string{"ANNOUNCED"}, [][]string{{message}})
+}
+
+func stats(ctx context.Context, c *Ctl, args []string) error {
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	res, err := admin.Stats(ctx, new(chat.StatsRequest))
+	if err != nil {
+		return err
+	}
+
+	uptime, _ := ptypes.Duration(res.Uptime)
+	uptime = uptime.Truncate(time.Second)
+
+	v := map[string]interface{}{
+		"online":          res.Online,
+		"sessions":        res.Sessions,
+		"logins":          res.Logins,
+		"messages":        res.Messages,
+		"broadcast_queue": res.BroadcastQueue,
+		"uptime":          uptime.String(),
+	}
+
+	rows := [][]string{
+		{"online", fmt.Sprint(res.Online)},
+		{"sessions", fmt.Sprint(res.Sessions)},
+		{"logins", fmt.Sprint(res.Logins)},
+		{"messages", fmt.Sprint(res.Messages)},
+		{"broadcast_queue", fmt.Sprint(res.BroadcastQueue)},
+		{"uptime", uptime.String()},
+	}
+
+	return c.print(v, []string{"METRIC", "VALUE"}, rows)
+}
+
+func reloadRoles(ctx context.Context, c *Ctl, args []string) error {
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	if _, err := admin.ReloadRoles(ctx, new(chat.ReloadRolesRequest)); err != nil {
+		return err
+	}
+
+	return c.print(map[string]bool{"reloaded": true}, []string{"RELOADED"}, [][]string{{"true"}})
+}
+
+func auditLog(ctx context.Context, c *Ctl, args []string) error {
+	if len(args) != 2 || args[0] != "verify" {
+		return errors.New("Usage: audit verify <file>")
+	}
+
+	res, err := audit.VerifyFiles(args[1])
+	if err != nil {
+		return errors.WithMessage(err, "audit log is tampered")
+	}
+
+	return c.print(res, []string{"FILES", "EVENTS", "ANCHORED", "LAST"},
+		[][]string{{strings.Join(res.Files, ","), fmt.Sprint(res.Events), fmt.Sprint(res.Anchored), res.Last}})
+}
+
+func logLevel(ctx context.Context, c *Ctl, args []string) error {
+	if len(args) > 1 {
+		return errors.New("Usage: log-level [level]")
+	}
+
+	req := new(chat.SetLogLevelRequest)
+	if len(args) == 1 {
+		req.Level = args[0]
+	}
+
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	res, err := admin.SetLogLevel(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.print(map[string]string{"level": res.Level, "previous": res.Previous},
+		[]string{"LEVEL", "PREVIOUS"}, [][]string{{res.Level, res.Previous}})
+}
+
+func export(ctx context.Context, c *Ctl, args []string) error {
+	fs := newFlagSet("export")
+	since := fs.String("since", "", "export records since time in RFC 3339 or duration before now, e.g. 24h")
+	room := fs.String("room", "", "export records of the room only")
+	format := fs.String("format", history.FormatJSONL, "transcript format (jsonl, txt or html)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 0 || (*format != history.FormatJSONL && *format != history.FormatText && *format != history.FormatHTML) {
+		return errors.New("Usage: export [-since 24h] [-room chat] [-format jsonl|txt|html]")
+	}
+
+	req := &chat.ExportRequest{Room: *room}
+	if *since != "" {
+		t, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if req.Since, err = ptypes.TimestampProto(t); err != nil {
+			return err
+		}
+	}
+
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	stream, err := admin.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var records []history.Record
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		r, ok, err := history.NewRecord(*res.Event, res.Room)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("invalid record %q", res.Event.Id))
+		} else if !ok {
+			c.Logger.Debug("Unexpected history record", "id", res.Event.Id)
+			continue
+		}
+
+		records = append(records, r)
+	}
+
+	return history.WriteTranscript(c.Out, *format, records)
+}
+
+// parseSince function returns time given in RFC 3339 or as duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("Invalid since %q", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+func importHistory(ctx context.Context, c *Ctl, args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: import <file>")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := history.ReadTranscript(f)
+	if err != nil {
+		return err
+	}
+
+	admin, ctx, closeConn, err := c.admin(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	stream, err := admin.Import(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		event := r.Event()
+		if err := stream.Send(&chat.HistoryRecord{Room: r.Room, Event: &event}); err != nil {
+			// the server closes the stream on invalid record, its error is returned by CloseAndRecv
+			break
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+
+	return c.print(map[string]int32{"imported": res.Imported, "skipped": res.Skipped},
+		[]string{"IMPORTED", "SKIPPED"}, [][]string{{fmt.Sprint(res.Imported), fmt.Sprint(res.Skipped)}})
+}
@@ -0,0 +1,340 @@
+package chatctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/history"
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+// selfSigned function returns server certificate for 127.0.0.1 and pool trusting it
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "chat"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestNewCtl(t *testing.T) {
+	cases := []struct {
+		addr   string
+		format string
+		err    bool
+	}{
+		{addr: "example:8000", format: FormatTable},
+		{addr: "example:8000", format: FormatJSON},
+		{addr: "", format: FormatTable, err: true},
+		{addr: "example:8000", format: "xml", err: true},
+	}
+
+	for _, tc := range cases {
+		_, err := NewCtl(tc.addr, "", tc.format, new(bytes.Buffer), false)
+		if (err != nil) != tc.err {
+			t.Errorf("Error for %q/%q should be %t but got %v", tc.addr, tc.format, tc.err, err)
+		}
+	}
+}
+
+func TestCtl(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	s, err := server.NewServer(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AdminToken = "secret"
+	s.DrainTimeout = 0
+
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	s.Join("Bob")
+	s.Join("Eve")
+
+	run := func(token, format string, args ...string) (string, error) {
+		out := new(bytes.Buffer)
+		c, err := NewCtl(addr, token, format, out, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Timeout = 2 * time.Second
+
+		err = c.Run(context.Background(), args)
+		return out.String(), err
+	}
+
+	if _, err := run("wrong", FormatTable, "sessions"); err == nil {
+		t.Error("Command with wrong token should fail")
+	}
+
+	if _, err := run("secret", FormatTable, "unknown"); err == nil {
+		t.Error("Unknown command should fail")
+	}
+
+	out, err := run("secret", FormatTable, "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "NAME") || !strings.Contains(out, "Bob") || !strings.Contains(out, "Eve") {
+		t.Errorf("Sessions table should list Bob and Eve but got %q", out)
+	}
+
+	out, err = run("secret", FormatJSON, "kick", "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kicked struct{ Kicked int32 }
+	if err := json.Unmarshal([]byte(out), &kicked); err != nil || kicked.Kicked != 1 {
+		t.Errorf("Kicked should be %d but got %q", 1, out)
+	}
+
+	if _, err := run("secret", FormatTable, "ban", "-for", "1h", "Eve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Join("Eve"); err != server.ErrBanned {
+		t.Errorf("Join error should be %v but got %v", server.ErrBanned, err)
+	}
+
+	if _, err := run("secret", FormatTable, "unban", "Eve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Join("Eve"); err != nil {
+		t.Errorf("Join error should be nil but got %v", err)
+	}
+
+	out, err = run("secret", FormatJSON, "stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st struct{ Logins int64 }
+	if err := json.Unmarshal([]byte(out), &st); err != nil || st.Logins != 3 {
+		t.Errorf("Logins should be %d but got %q", 3, out)
+	}
+}
+
+func TestCtlTLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cert, pool := selfSigned(t)
+
+	addr := freeAddr(t)
+	s, err := server.NewServer(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AdminToken = "secret"
+	s.DrainTimeout = 0
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	cases := []struct {
+		tls *tls.Config
+		err bool
+	}{
+		{tls: nil, err: true},
+		{tls: &tls.Config{RootCAs: x509.NewCertPool()}, err: true},
+		{tls: &tls.Config{RootCAs: pool}, err: false},
+	}
+
+	for i, tc := range cases {
+		c, _ := NewCtl(addr, "secret", FormatJSON, new(bytes.Buffer), false)
+		c.TLS = tc.tls
+
+		err := c.Run(ctx, []string{"stats"})
+		if (err != nil) != tc.err {
+			t.Errorf("Error should be %t but got %v (case %d)", tc.err, err, i)
+		}
+	}
+}
+
+func TestAuditVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	l, err := audit.NewLog(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Record(audit.Login, "Bob", "", "")
+	l.Record(audit.Logout, "Bob", "", "")
+	l.Close()
+
+	out := new(bytes.Buffer)
+	c, _ := NewCtl("example:8000", "", FormatJSON, out, false)
+
+	if err := c.Run(context.Background(), []string{"audit", "verify", path}); err != nil {
+		t.Fatal(err)
+	}
+
+	var res audit.Result
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil || res.Events != 2 {
+		t.Errorf("Events should be %d but got %q", 2, out.String())
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	ioutil.WriteFile(path, bytes.Replace(data, []byte("logout"), []byte("login"), 1), 0600)
+
+	if err := c.Run(context.Background(), []string{"audit", "verify", path}); err == nil {
+		t.Error("Tampered audit log should fail verification")
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr := freeAddr(t)
+	s, err := server.NewServer(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AdminToken = "secret"
+	s.DrainTimeout = 0
+	s.History = history.NewStore()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []history.Record{
+		{ID: "1", Time: start, Room: "chat", Type: history.Login, Name: "Alice"},
+		{ID: "2", Time: start.Add(time.Minute), Room: "chat", Type: history.Message, Name: "Alice", Message: "<b>hi</b>"},
+		{ID: "3", Time: start.Add(2 * time.Minute), Room: "ops", Type: history.Message, Name: "Bob", Message: "deploy"},
+		{ID: "4", Time: start.Add(3 * time.Minute), Room: "chat", Type: history.Logout, Name: "Alice"},
+	}
+	for _, r := range records {
+		s.History.Add(r)
+	}
+
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	run := func(args ...string) string {
+		out := new(bytes.Buffer)
+		c, _ := NewCtl(addr, "secret", FormatJSON, out, false)
+		c.Timeout = 2 * time.Second
+
+		if err := c.Run(context.Background(), args); err != nil {
+			t.Fatalf("Command %v should not fail but got %s", args, err)
+		}
+
+		return out.String()
+	}
+
+	jsonl := run("export")
+	exported, err := history.ReadTranscript(strings.NewReader(jsonl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != len(records) || !reflect.DeepEqual(exported[1], records[1]) {
+		t.Errorf("Exported records should be %+v but got %+v", records, exported)
+	}
+
+	if out := run("export", "-room", "chat", "-since", "2026-01-01T12:01:00Z", "-format", "txt"); out != "2026-01-01T12:01:00Z [chat] Alice: <b>hi</b>\n2026-01-01T12:03:00Z [chat] Alice is offline\n" {
+		t.Errorf("Text transcript is unexpected %q", out)
+	}
+
+	if out := run("export", "--format", "html"); !strings.Contains(out, "&lt;b&gt;hi&lt;/b&gt;") {
+		t.Errorf("HTML transcript should escape messages but got %q", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	extra := `{"id":"5","time":"2026-01-01T12:04:00Z","room":"chat","type":"message","name":"Carol","message":"imported"}` + "\n"
+	if err := ioutil.WriteFile(path, []byte(jsonl+extra), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var res struct{ Imported, Skipped int32 }
+	for i, expected := range []struct{ imported, skipped int32 }{{1, 4}, {0, 5}} {
+		if err := json.Unmarshal([]byte(run("import", path)), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Imported != expected.imported || res.Skipped != expected.skipped {
+			t.Errorf("Import %d should import %d and skip %d but got %+v", i+1, expected.imported, expected.skipped, res)
+		}
+	}
+
+	if found := s.History.Search(history.Query{Terms: "imported"}); len(found) != 1 || found[0].Name != "Carol" {
+		t.Errorf("Imported message should be found but got %+v", found)
+	}
+}
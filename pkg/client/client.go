@@ -3,30 +3,59 @@ package client
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sc-chat/test-chat/internal/constants"
-	"github.com/sc-chat/test-chat/internal/debug"
+	"github.com/sc-chat/test-chat/internal/logger"
 	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+	"github.com/sc-chat/test-chat/pkg/sign"
+	"github.com/sc-chat/test-chat/pkg/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 const ms = 500
 
+// EventHandler processes event received from the server
+type EventHandler func(res *chat.ResponseStream)
+
 // Client struct
 type Client struct {
 	Addr    string
 	Name    string
 	Timeout time.Duration
-	Logger  debug.Logger
+	Logger  *logger.Logger
+
+	// Input provides messages line by line
+	Input io.Reader
+	// OnEvent replaces default printing of chat events if set
+	OnEvent EventHandler
+	// Renderer formats printed chat events
+	Renderer *Renderer
+	// TLS encrypts connection to the server, connection is not encrypted if nil
+	TLS *tls.Config
+	// Tracer records spans of requests to the server, spans are not recorded if nil
+	Tracer *trace.Tracer
+	// Downloads is directory of downloaded files, files are saved to working directory if empty
+	Downloads string
+	// Key is X25519 key of the device for direct messages, direct messages are not received if nil
+	Key *e2e.Key
+	// Signer signs outgoing messages, messages are not signed if nil
+	Signer *sign.Key
+	// Pins keeps signing keys of authors trusted on first use
+	Pins *Pins
+	// Devices keeps device keys of clients trusted on first use or confirmed by /verify
+	Devices *Pins
 
 	chatClient chat.ChatClient
 	token      string
@@ -38,13 +67,18 @@ func (c *Client) Run(ctx context.Context) error {
 	connCtx, cancel := context.WithTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	conn, err := grpc.DialContext(connCtx, c.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	creds := grpc.WithInsecure()
+	if c.TLS != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(c.TLS))
+	}
+
+	conn, err := grpc.DialContext(connCtx, c.Addr, creds, grpc.WithBlock())
 	if err != nil {
 		return errors.WithMessage(err, "failed to connect to provided address")
 	}
 	defer conn.Close()
 
-	c.Logger.Debug("%s is connected to %s", c.Name, c.Addr)
+	c.Logger.Debug("Connected", "user", c.Name, "addr", c.Addr)
 
 	c.chatClient = chat.NewChatClient(conn)
 
@@ -52,13 +86,13 @@ func (c *Client) Run(ctx context.Context) error {
 		return errors.WithMessage(err, "failed to login")
 	}
 
-	c.Logger.Debug("Logged in successfully as %s", c.Name)
+	c.Logger.Debug("Logged in successfully", "user", c.Name)
 
 	err = c.stream(ctx)
 
 	c.Logger.Debug("Logging out")
 	if err := c.logout(ctx); err != nil {
-		c.Logger.Debug("Failed to log out: %v", err)
+		c.Logger.Debug("Failed to log out", "err", err)
 	}
 
 	return errors.WithMessage(err, "Stream error")
@@ -69,14 +103,30 @@ func (c *Client) login(ctx context.Context) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	res, err := c.chatClient.Login(ctx, &chat.LoginRequest{
-		Name: c.Name,
-	})
+	ctx, span := c.Tracer.Start(ctx, "client.Login", "user", c.Name)
+	defer span.End()
+
+	req := &chat.LoginRequest{Name: c.Name}
+	if c.Key != nil {
+		req.PublicKey = c.Key.Public()
+	}
+
+	if c.Signer != nil {
+		req.SigningKey = c.Signer.Public()
+	}
+
+	res, err := c.chatClient.Login(trace.Inject(ctx), req)
+	span.SetError(err)
 
 	if err != nil {
 		return "", err
 	}
 
+	// direct messages and signatures are authenticated with the name of the session
+	if res.Name != "" {
+		c.Name = res.Name
+	}
+
 	return res.Token, nil
 }
 
@@ -107,7 +157,11 @@ func (c *Client) stream(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	client, err := c.chatClient.Stream(ctx)
+	// messages received by the server are traced as children of the stream span
+	ctx, span := c.Tracer.Start(ctx, "client.Stream", "user", c.Name)
+	defer span.End()
+
+	client, err := c.chatClient.Stream(trace.Inject(ctx))
 	if err != nil {
 		return err
 	}
@@ -134,43 +188,58 @@ func (c *Client) receive(sc chat.Chat_StreamClient) error {
 			return err
 		}
 
-		// handle event
-		switch evt := res.Event.(type) {
-		case *chat.ResponseStream_ClientLogin:
-			log.Printf("Server: %s is online", evt.ClientLogin.Name)
-		case *chat.ResponseStream_ClientLogout:
-			log.Printf("Server: %s is offline", evt.ClientLogout.Name)
-		case *chat.ResponseStream_ClientMessage:
-			log.Printf("%s: %s", evt.ClientMessage.Name, evt.ClientMessage.Message)
-		case *chat.ResponseStream_ServerShutdown:
-			c.Logger.Debug("The server is shutting down %#v", evt)
+		// the server closes the stream after the deadline of shutdown
+		if _, ok := res.Event.(*chat.ResponseStream_ServerShutdown); ok {
+			c.Logger.Debug("The server is shutting down")
 			c.shutdown = true
+		}
 
-			// kill the client
-			syscall.Kill(os.Getpid(), syscall.SIGTERM)
-		default:
-			c.Logger.Debug("Unexpected event from the server: %T", evt)
+		if c.OnEvent != nil {
+			c.OnEvent(res)
+			continue
+		}
+
+		if dm := res.GetDirectMessage(); dm != nil {
+			log.Print(c.openDirect(dm))
+			continue
+		}
+
+		// handle event
+		line, ok := c.Renderer.Render(res)
+		if !ok {
+			c.Logger.Debug("Unexpected event from the server", "event", fmt.Sprintf("%T", res.Event))
 			return nil
 		}
+
+		if msg := res.GetClientMessage(); msg != nil {
+			line = c.mark(msg) + " " + line
+		}
+
+		log.Print(line)
 	}
 }
 
 func (c *Client) send(client chat.Chat_StreamClient) {
-	sc := bufio.NewScanner(os.Stdin)
+	sc := bufio.NewScanner(c.Input)
 	sc.Split(bufio.ScanLines)
 
 	for {
 		select {
 		case <-client.Context().Done():
 			c.Logger.Debug("Client send loop disconnected")
+			return
 		default:
 			if sc.Scan() {
-				if err := client.Send(&chat.RequestStream{Message: sc.Text()}); err != nil {
-					c.Logger.Debug("Failed to send message: %v", err)
+				if c.command(client, sc.Text()) {
+					continue
+				}
+
+				if err := client.Send(c.request(sc.Text(), nil)); err != nil {
+					c.Logger.Debug("Failed to send message", "err", err)
 					return
 				}
 			} else {
-				c.Logger.Debug("Input scanner failure: %v", sc.Err())
+				c.Logger.Debug("Input scanner failure", "err", sc.Err())
 				return
 			}
 		}
@@ -193,6 +262,11 @@ func NewClient(addr, name string, allowDebug bool) (*Client, error) {
 		Addr:    addr,
 		Name:    name,
 		Timeout: time.Duration(ms) * time.Millisecond,
-		Logger:  debug.NewLogger(allowDebug),
+		Logger:  logger.NewLogger(allowDebug),
+		Input:   os.Stdin,
+
+		Renderer: new(Renderer),
+		Pins:     NewPins(),
+		Devices:  NewPins(),
 	}, nil
 }
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// client commands
+const (
+	// searchCommand finds messages in chat history
+	searchCommand = "/search"
+	// sendCommand uploads file and sends it as attachment
+	sendCommand = "/send"
+	// getCommand downloads attached file
+	getCommand = "/get"
+	// directCommand sends end-to-end encrypted direct message
+	directCommand = "/dm"
+	// verifyCommand prints fingerprints of device and signing keys, pins the key with provided fingerprint
+	verifyCommand = "/verify"
+)
+
+// command method runs client command of the input line, returns false if the line is a message
+func (c *Client) command(client chat.Chat_StreamClient, line string) bool {
+	name, args := line, ""
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		name, args = line[:i], line[i+1:]
+	}
+
+	switch name {
+	case searchCommand:
+		if err := c.search(client.Context(), args); err != nil {
+			log.Printf("Search: %s", err)
+		}
+	case sendCommand:
+		if err := c.sendFile(client, strings.TrimSpace(args)); err != nil {
+			log.Printf("Upload: %s", err)
+		}
+	case getCommand:
+		if err := c.download(client.Context(), strings.TrimSpace(args)); err != nil {
+			log.Printf("Download: %s", err)
+		}
+	case directCommand:
+		if err := c.sendDirect(client, args); err != nil {
+			log.Printf("Direct: %s", err)
+		}
+	case verifyCommand:
+		if err := c.verify(client.Context(), strings.TrimSpace(args)); err != nil {
+			log.Printf("Verify: %s", err)
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// search method prints messages from history matching the query
+func (c *Client) search(ctx context.Context, args string) error {
+	req, err := ParseSearch(args, time.Now())
+	if err != nil {
+		return err
+	}
+	req.Token = c.token
+
+	res, err := c.chatClient.Search(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if len(res.Messages) == 0 {
+		log.Print("Search: nothing found")
+		return nil
+	}
+
+	// oldest message is printed first like in the chat
+	for i := len(res.Messages) - 1; i >= 0; i-- {
+		line, ok := c.Renderer.Render(res.Messages[i])
+		if !ok {
+			continue
+		}
+
+		sent, _ := ptypes.Timestamp(res.Messages[i].Timestamp)
+		log.Printf("Search: [%s] %s", sent.Local().Format("2006-01-02 15:04"), line)
+	}
+
+	return nil
+}
+
+// ParseSearch function returns search request of /search command arguments
+// arguments are terms and filters from:name, room:name, since:time, until:time and limit:n
+// where time is RFC 3339 or duration before now, e.g. since:2h
+func ParseSearch(args string, now time.Time) (*chat.SearchRequest, error) {
+	req := new(chat.SearchRequest)
+
+	var terms []string
+	for _, arg := range strings.Fields(args) {
+		i := strings.IndexByte(arg, ':')
+		if i < 0 {
+			terms = append(terms, arg)
+			continue
+		}
+
+		key, value := arg[:i], arg[i+1:]
+
+		var err error
+		switch key {
+		case "from":
+			req.Author = value
+		case "room":
+			req.Room = value
+		case "since":
+			req.Since, err = parseTime(value, now)
+		case "until":
+			req.Until, err = parseTime(value, now)
+		case "limit":
+			_, err = fmt.Sscanf(value, "%d", &req.Limit)
+		default:
+			terms = append(terms, arg)
+		}
+
+		if err != nil {
+			return nil, errors.Errorf("invalid %s %q", key, value)
+		}
+	}
+
+	req.Query = strings.Join(terms, " ")
+
+	if req.Query == "" && req.Author == "" && req.Room == "" && req.Since == nil && req.Until == nil {
+		return nil, errors.New("usage: /search [from:name] [room:name] [since:2h] [until:time] [limit:n] terms")
+	}
+
+	return req, nil
+}
+
+func parseTime(value string, now time.Time) (*timestamp.Timestamp, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		d, dErr := time.ParseDuration(value)
+		if dErr != nil {
+			return nil, err
+		}
+
+		t = now.Add(-d)
+	}
+
+	return ptypes.TimestampProto(t)
+}
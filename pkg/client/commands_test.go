@@ -0,0 +1,68 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+func TestParseSearch(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		args   string
+		query  string
+		author string
+		room   string
+		since  time.Time
+		until  time.Time
+		limit  int32
+		err    bool
+	}{
+		{args: "deploy done", query: "deploy done"},
+		{args: "from:Bob room:ops deploy", query: "deploy", author: "Bob", room: "ops"},
+		{args: "since:2h from:Bob", author: "Bob", since: now.Add(-2 * time.Hour)},
+		{args: "until:2026-01-01T10:00:00Z limit:5 at 10:30", query: "at 10:30", until: now.Add(-2 * time.Hour), limit: 5},
+		{args: "since:yesterday deploy", err: true},
+		{args: "", err: true},
+	}
+
+	for _, tc := range cases {
+		req, err := ParseSearch(tc.args, now)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Parsing of %q should fail", tc.args)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Parsing of %q should not fail but got %s", tc.args, err)
+			continue
+		}
+
+		if req.Query != tc.query || req.Author != tc.author || req.Room != tc.room || req.Limit != tc.limit {
+			t.Errorf("Request of %q should be %q from %q in %q limit %d but got %+v", tc.args, tc.query, tc.author, tc.room, tc.limit, req)
+		}
+
+		if since := timeOf(req.Since); !since.Equal(tc.since) {
+			t.Errorf("Since of %q should be %s but got %s", tc.args, tc.since, since)
+		}
+
+		if until := timeOf(req.Until); !until.Equal(tc.until) {
+			t.Errorf("Until of %q should be %s but got %s", tc.args, tc.until, until)
+		}
+	}
+}
+
+// timeOf function returns time of the timestamp, zero time if it is not set
+func timeOf(ts *timestamp.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	t, _ := ptypes.Timestamp(ts)
+	return t
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+	"github.com/sc-chat/test-chat/pkg/sign"
+)
+
+// sendDirect method encrypts the message for each pinned device of the recipient and other devices of the client
+// devices which are not pinned are reported and skipped until the user confirms them by /verify
+func (c *Client) sendDirect(client chat.Chat_StreamClient, args string) error {
+	fields := strings.SplitN(strings.TrimSpace(args), " ", 2)
+	if len(fields) < 2 || strings.TrimSpace(fields[1]) == "" {
+		return errors.New("usage: /dm <name> <message>")
+	}
+
+	if c.Key == nil {
+		return errors.New("direct messages require device key")
+	}
+
+	// names are normalized like the server does, they are authenticated by encryption
+	name, to, text := normalizeName(c.Name), normalizeName(fields[0]), strings.TrimSpace(fields[1])
+
+	keys, err := c.devices(client.Context(), to)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return errors.Errorf("%s has no verified devices online", Sanitize(to))
+	}
+
+	// other devices of the client get copy of the message
+	own, err := c.devices(client.Context(), name)
+	if err != nil {
+		return err
+	}
+
+	dm := &chat.DirectMessage{To: to}
+	for _, key := range append(keys, own...) {
+		if bytes.Equal(key, c.Key.Public()) {
+			continue
+		}
+
+		nonce, ciphertext, err := c.Key.Seal(key, name, to, []byte(text))
+		if err != nil {
+			return err
+		}
+
+		dm.Envelopes = append(dm.Envelopes, &chat.Envelope{
+			Device:     e2e.DeviceID(key),
+			Nonce:      nonce,
+			Ciphertext: ciphertext,
+		})
+	}
+
+	if err := client.Send(&chat.RequestStream{Direct: dm}); err != nil {
+		return err
+	}
+
+	log.Print(c.Renderer.Direct(name, to, text))
+
+	return nil
+}
+
+// openDirect method returns line of direct message decrypted by the device key
+// the line is marked if the device of the sender is not pinned
+func (c *Client) openDirect(dm *chat.ResponseStream_Direct) string {
+	if c.Key == nil {
+		return c.Renderer.Direct(dm.From, dm.To, "[encrypted message]")
+	}
+
+	text, err := c.Key.Open(dm.SenderKey, dm.From, dm.To, dm.Nonce, dm.Ciphertext)
+	if err != nil {
+		c.Logger.Debug("Failed to decrypt direct message", "from", dm.From, "err", err)
+		return c.Renderer.Direct(dm.From, dm.To, "[message can not be decrypted]")
+	}
+
+	line := c.Renderer.Direct(dm.From, dm.To, string(text))
+
+	status, err := c.Devices.Check(dm.From, dm.SenderKey)
+	if err != nil {
+		c.Logger.Warn("Failed to pin device key", "user", dm.From, "err", err)
+	}
+
+	if status == PinChanged {
+		return markChanged + " " + line
+	}
+
+	return line
+}
+
+// verify method prints fingerprints of the client devices and signing keys and of this client to be compared by people
+// device or signing key with the fingerprint is pinned if the fingerprint is provided after the name
+func (c *Client) verify(ctx context.Context, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return errors.New("usage: /verify <name> [fingerprint]")
+	}
+
+	name := normalizeName(fields[0])
+	if len(fields) > 1 {
+		keys, err := c.keys(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		return c.confirm(name, strings.Join(fields[1:], ""), keys)
+	}
+
+	if c.Key != nil {
+		log.Printf("Verify: this device %s: %s", e2e.DeviceID(c.Key.Public()), e2e.Fingerprint(c.Key.Public()))
+	}
+
+	if c.Signer != nil {
+		log.Printf("Verify: this signing key: %s", sign.Fingerprint(c.Signer.Public()))
+	}
+
+	pinned, _ := c.Pins.Get(name)
+	for _, key := range pinned {
+		log.Printf("Verify: %s pinned signing key: %s", Sanitize(name), sign.Fingerprint(key))
+	}
+
+	for _, key := range c.Pins.Pending(name) {
+		log.Printf("Verify: %s new signing key: %s", Sanitize(name), sign.Fingerprint(key))
+	}
+
+	keys, err := c.keys(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		log.Printf("Verify: %s has no devices online", Sanitize(name))
+		return nil
+	}
+
+	trusted, _ := c.Devices.Get(name)
+	for _, key := range keys {
+		state := "not verified"
+		if contains(trusted, key) {
+			state = "pinned"
+		}
+
+		log.Printf("Verify: %s device %s (%s): %s", Sanitize(name), e2e.DeviceID(key), state, e2e.Fingerprint(key))
+	}
+
+	return nil
+}
+
+// confirm method pins one of devices or new signing key of the client with the fingerprint compared by the user
+// only full fingerprint is accepted, so that the user has to compare it with the client over another channel
+func (c *Client) confirm(name, fingerprint string, devices [][]byte) error {
+	for _, key := range c.Pins.Pending(name) {
+		if strings.Replace(sign.Fingerprint(key), " ", "", -1) != fingerprint {
+			continue
+		}
+
+		if err := c.Pins.Pin(name, key); err != nil {
+			return err
+		}
+
+		log.Printf("Verify: %s signing key %s is pinned", Sanitize(name), sign.Fingerprint(key))
+		return nil
+	}
+
+	for _, key := range devices {
+		if strings.Replace(e2e.Fingerprint(key), " ", "", -1) != fingerprint {
+			continue
+		}
+
+		if err := c.Devices.Pin(name, key); err != nil {
+			return err
+		}
+
+		log.Printf("Verify: %s device %s is pinned", Sanitize(name), e2e.DeviceID(key))
+		return nil
+	}
+
+	return errors.Errorf("%s has no device or new key with fingerprint %s", Sanitize(name), Sanitize(fingerprint))
+}
+
+// devices method returns pinned keys of devices of the client which are online
+// all devices are pinned if the client is new, other devices are reported to be confirmed by the user
+func (c *Client) devices(ctx context.Context, name string) ([][]byte, error) {
+	keys, err := c.keys(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	trusted, unknown, err := c.Devices.Trusted(name, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range unknown {
+		if c.Key != nil && bytes.Equal(key, c.Key.Public()) {
+			continue
+		}
+
+		log.Printf("Direct: %s device %s is not verified and gets no messages, compare its fingerprint printed by /verify %s with %s over another channel and pin it by /verify %s <fingerprint>",
+			Sanitize(name), e2e.DeviceID(key), Sanitize(name), Sanitize(name), Sanitize(name))
+	}
+
+	return trusted, nil
+}
+
+// keys method returns public keys of devices of the client which are online
+func (c *Client) keys(ctx context.Context, name string) ([][]byte, error) {
+	res, err := c.chatClient.Keys(ctx, &chat.KeysRequest{Token: c.token, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Keys, nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+)
+
+func TestOpenDirect(t *testing.T) {
+	alice, _ := e2e.GenerateKey()
+	bob, _ := e2e.GenerateKey()
+
+	c, _ := NewClient("example:8000", "Bob", false)
+
+	nonce, ciphertext, err := alice.Seal(bob.Public(), "Alice", "Bob", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dm := &chat.ResponseStream_Direct{From: "Alice", To: "Bob", SenderKey: alice.Public(), Nonce: nonce, Ciphertext: ciphertext}
+
+	cases := []struct {
+		name     string
+		key      *e2e.Key
+		from     string
+		expected string
+	}{
+		{name: "no key", from: "Alice", expected: "Direct: Alice → Bob: [encrypted message]"},
+		{name: "valid", key: bob, from: "Alice", expected: "Direct: Alice → Bob: secret"},
+		{name: "other key", key: alice, from: "Alice", expected: "Direct: Alice → Bob: [message can not be decrypted]"},
+		{name: "other sender", key: bob, from: "Eve", expected: "Direct: Eve → Bob: [message can not be decrypted]"},
+	}
+
+	for _, tc := range cases {
+		c.Key = tc.key
+		dm.From = tc.from
+
+		if line := c.openDirect(dm); tc.expected != line {
+			t.Errorf("Line should be %q but got %q (%s)", tc.expected, line, tc.name)
+		}
+	}
+
+	// device key of Alice is pinned by the first message, message from her other device is marked until it is confirmed
+	laptop, _ := e2e.GenerateKey()
+	nonce, ciphertext, err = laptop.Seal(bob.Public(), "Alice", "Bob", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Key = bob
+	other := &chat.ResponseStream_Direct{From: "Alice", To: "Bob", SenderKey: laptop.Public(), Nonce: nonce, Ciphertext: ciphertext}
+
+	if expected, line := "! Direct: Alice → Bob: secret", c.openDirect(other); expected != line {
+		t.Errorf("Line should be %q but got %q", expected, line)
+	}
+
+	if err := c.Devices.Pin("Alice", laptop.Public()); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, line := "Direct: Alice → Bob: secret", c.openDirect(other); expected != line {
+		t.Errorf("Line should be %q but got %q", expected, line)
+	}
+}
+
+func TestConfirmDevice(t *testing.T) {
+	phone, _ := e2e.GenerateKey()
+	laptop, _ := e2e.GenerateKey()
+
+	c, _ := NewClient("example:8000", "Alice", false)
+
+	devices := [][]byte{phone.Public(), laptop.Public()}
+	if _, _, err := c.Devices.Trusted("Bob", devices[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.confirm("Bob", "0000", devices); err == nil {
+		t.Error("Unknown device should not be confirmed")
+	}
+
+	// device id is derived from the key supplied by the server and does not verify anything
+	if err := c.confirm("Bob", e2e.DeviceID(laptop.Public()), devices); err == nil {
+		t.Error("Device should not be confirmed by device id")
+	}
+
+	if err := c.confirm("Bob", strings.Replace(e2e.Fingerprint(laptop.Public()), " ", "", -1), devices); err != nil {
+		t.Fatal(err)
+	}
+
+	if trusted, unknown, _ := c.Devices.Trusted("Bob", devices); len(trusted) != 2 || len(unknown) != 0 {
+		t.Errorf("Devices should be pinned but got %d trusted and %d unknown", len(trusted), len(unknown))
+	}
+}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// sendFile method uploads the file and sends message with the file attached
+func (c *Client) sendFile(client chat.Chat_StreamClient, path string) error {
+	if path == "" {
+		return errors.New("usage: /send <path>")
+	}
+
+	a, err := c.upload(client.Context(), path)
+	if err != nil {
+		return err
+	}
+
+	return client.Send(c.request(a.Name, []string{a.Id}))
+}
+
+// upload method sends the file to the server in chunks, returns reference of kept file
+func (c *Client) upload(ctx context.Context, path string) (*chat.Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash, err := sha256.HashReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	stream, err := c.chatClient.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(path)
+	p := newProgress("Upload", name, size)
+
+	req := &chat.UploadRequest{Token: c.token, Name: name, Size: size, Sha256: hash}
+	buf := make([]byte, blob.ChunkSize)
+
+	for {
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			stream.CloseSend()
+			return nil, err
+		}
+
+		// the first request is sent even for empty file
+		if n > 0 || req.Token != "" {
+			req.Chunk = buf[:n]
+			if err := stream.Send(req); err != nil {
+				// the server has closed the stream, its error is returned by CloseAndRecv
+				break
+			}
+			req = new(chat.UploadRequest)
+			p.add(int64(n))
+		}
+
+		if n < len(buf) {
+			break
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Upload: %s is sent as %s", Sanitize(name), Sanitize(res.Attachment.Id))
+
+	return res.Attachment, nil
+}
+
+// download method saves the file kept by the server in Downloads directory
+// existing files are not overwritten and the content is checked against its hash
+func (c *Client) download(ctx context.Context, id string) (err error) {
+	if id == "" {
+		return errors.New("usage: /get <id>")
+	}
+
+	stream, err := c.chatClient.Download(ctx, &chat.DownloadRequest{Token: c.token, Id: id})
+	if err != nil {
+		return err
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	a := res.Attachment
+	if a == nil {
+		return errors.New("missing attachment")
+	}
+
+	name, err := blob.ValidateName(a.Name)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(c.Downloads, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	w := sha256.NewWriter(f)
+	p := newProgress("Download", name, a.Size)
+
+	for {
+		if _, err := w.Write(res.Chunk); err != nil {
+			return err
+		}
+		p.add(int64(len(res.Chunk)))
+
+		if res, err = stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if w.Size() != a.Size || w.Hash() != strings.ToLower(a.Sha256) {
+		return errors.New("downloaded file does not match its hash")
+	}
+
+	log.Printf("Download: %s is saved to %s", Sanitize(a.Id), Sanitize(path))
+
+	return nil
+}
+
+// progress prints transferred part of a file in steps of 10 percent
+type progress struct {
+	prefix string
+	name   string
+	total  int64
+	done   int64
+	step   int64
+}
+
+func (p *progress) add(n int64) {
+	p.done += n
+
+	var step int64 = 10
+	if p.total > 0 {
+		step = p.done * 10 / p.total
+	}
+
+	if step > p.step {
+		p.step = step
+		log.Printf("%s: %s %d%% (%s of %s)", p.prefix, p.name, step*10, FormatSize(p.done), FormatSize(p.total))
+	}
+}
+
+// newProgress returns progress pointer, the name is sanitized because it can be provided by the server
+func newProgress(prefix, name string, total int64) *progress {
+	return &progress{prefix: prefix, name: Sanitize(name), total: total}
+}
+
+// FormatSize function returns human readable size in bytes
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
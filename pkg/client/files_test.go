@@ -0,0 +1,30 @@
+package client
+
+import "testing"
+
+func TestFormatSize(t *testing.T) {
+	cases := []struct {
+		size     int64
+		expected string
+	}{
+		{size: 0, expected: "0 B"},
+		{size: 1023, expected: "1023 B"},
+		{size: 1024, expected: "1.0 KiB"},
+		{size: 1536, expected: "1.5 KiB"},
+		{size: 10 << 20, expected: "10.0 MiB"},
+		{size: 3 << 30, expected: "3.0 GiB"},
+	}
+
+	for _, tc := range cases {
+		if size := FormatSize(tc.size); tc.expected != size {
+			t.Errorf("Size should be %s but got %s", tc.expected, size)
+		}
+	}
+}
+
+func TestProgressName(t *testing.T) {
+	p := newProgress("Download", "evil\x1b[2J.txt", 10)
+	if expected := Sanitize("evil\x1b[2J.txt"); p.name != expected {
+		t.Errorf("Progress name should be %q but got %q", expected, p.name)
+	}
+}
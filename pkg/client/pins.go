@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// PinStatus is result of checking key of the author against pinned keys
+type PinStatus int
+
+// pin statuses
+const (
+	// PinNew is returned when the key is pinned on first use
+	PinNew PinStatus = iota
+	// PinMatch is returned when the key is one of pinned keys
+	PinMatch
+	// PinChanged is returned when the author has pinned keys and the key is not one of them
+	PinChanged
+)
+
+// Pins keeps keys of authors trusted on first use, each author can have a key for every device
+// keys which are not pinned are kept in memory until the user confirms them
+type Pins struct {
+	path    string
+	keys    map[string][][]byte
+	pending map[string][][]byte
+	mtx     sync.Mutex
+}
+
+// Check method compares key of the author with pinned keys, the key is pinned if the author is new
+// unknown keys of known authors are kept as pending to be confirmed by Pin
+func (p *Pins) Check(name string, key []byte) (PinStatus, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	pinned, ok := p.keys[name]
+	if ok && contains(pinned, key) {
+		return PinMatch, nil
+	} else if ok {
+		if !contains(p.pending[name], key) {
+			p.pending[name] = append(p.pending[name], append([]byte(nil), key...))
+		}
+		return PinChanged, nil
+	}
+
+	p.keys[name] = [][]byte{append([]byte(nil), key...)}
+
+	return PinNew, p.save()
+}
+
+// Trusted method splits keys of the author into pinned and unknown ones, all keys are pinned if the author is new
+// unknown keys are kept as pending to be confirmed by Pin
+func (p *Pins) Trusted(name string, keys [][]byte) ([][]byte, [][]byte, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	pinned, ok := p.keys[name]
+	if !ok {
+		for _, key := range keys {
+			p.keys[name] = append(p.keys[name], append([]byte(nil), key...))
+		}
+		return keys, nil, p.save()
+	}
+
+	var trusted, unknown [][]byte
+	for _, key := range keys {
+		if contains(pinned, key) {
+			trusted = append(trusted, key)
+			continue
+		}
+
+		unknown = append(unknown, key)
+		if !contains(p.pending[name], key) {
+			p.pending[name] = append(p.pending[name], append([]byte(nil), key...))
+		}
+	}
+
+	return trusted, unknown, nil
+}
+
+// Pin method adds the key confirmed by the user to pinned keys of the author
+func (p *Pins) Pin(name string, key []byte) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if !contains(p.keys[name], key) {
+		p.keys[name] = append(p.keys[name], append([]byte(nil), key...))
+	}
+
+	pending := p.pending[name][:0]
+	for _, k := range p.pending[name] {
+		if !bytes.Equal(k, key) {
+			pending = append(pending, k)
+		}
+	}
+	p.pending[name] = pending
+
+	return p.save()
+}
+
+// Get method returns pinned keys of the author, false if the author has no pinned keys
+func (p *Pins) Get(name string) ([][]byte, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	keys, ok := p.keys[name]
+	return append([][]byte(nil), keys...), ok
+}
+
+// Pending method returns keys of the author which are seen but not pinned
+func (p *Pins) Pending(name string) [][]byte {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return append([][]byte(nil), p.pending[name]...)
+}
+
+// save method writes pinned keys to the file, keys are kept in memory only if path is empty
+func (p *Pins) save() error {
+	if p.path == "" {
+		return nil
+	}
+
+	keys := make(map[string][]string, len(p.keys))
+	for name, pinned := range p.keys {
+		for _, key := range pinned {
+			keys[name] = append(keys[name], hex.EncodeToString(key))
+		}
+	}
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.path, data, 0600)
+}
+
+// contains function returns true if the key is one of the keys
+func contains(keys [][]byte, key []byte) bool {
+	for _, k := range keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewPins returns Pins pointer keeping keys in memory
+func NewPins() *Pins {
+	return &Pins{keys: make(map[string][][]byte), pending: make(map[string][][]byte)}
+}
+
+// LoadPins returns Pins pointer with keys read from JSON file of author names and lists of hex keys
+// the file is created when the first key is pinned
+func LoadPins(path string) (*Pins, error) {
+	p := NewPins()
+	p.path = path
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keys map[string][]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, errors.WithMessage(err, "invalid pins file")
+	}
+
+	for name, pinned := range keys {
+		for _, key := range pinned {
+			k, err := hex.DecodeString(key)
+			if err != nil {
+				return nil, errors.WithMessage(err, "invalid key of "+name)
+			}
+			p.keys[name] = append(p.keys[name], k)
+		}
+	}
+
+	return p, nil
+}
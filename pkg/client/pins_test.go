@@ -0,0 +1,112 @@
+package client
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+
+	p, err := LoadPins(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		key      string
+		expected PinStatus
+	}{
+		{name: "Alice", key: "alice", expected: PinNew},
+		{name: "Alice", key: "alice", expected: PinMatch},
+		{name: "Alice", key: "mallory", expected: PinChanged},
+		{name: "Bob", key: "bob", expected: PinNew},
+		{name: "Bob", key: "laptop", expected: PinChanged},
+	}
+
+	for _, tc := range cases {
+		status, err := p.Check(tc.name, []byte(tc.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tc.expected != status {
+			t.Errorf("Status of %s key %s should be %d but got %d", tc.name, tc.key, tc.expected, status)
+		}
+	}
+
+	if pending := p.Pending("Bob"); len(pending) != 1 || string(pending[0]) != "laptop" {
+		t.Errorf("Pending keys should be %s but got %s", "laptop", pending)
+	}
+
+	// every device of the author can have pinned key
+	if err := p.Pin("Bob", []byte("laptop")); err != nil {
+		t.Fatal(err)
+	}
+
+	if pending := p.Pending("Bob"); len(pending) != 0 {
+		t.Errorf("Pinned key should not be pending but got %s", pending)
+	}
+
+	// pinned keys are kept between runs
+	p, err = LoadPins(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, _ := p.Check("Alice", []byte("mallory")); status != PinChanged {
+		t.Errorf("Status should be %d but got %d", PinChanged, status)
+	}
+
+	if status, _ := p.Check("Bob", []byte("laptop")); status != PinMatch {
+		t.Errorf("Status should be %d but got %d", PinMatch, status)
+	}
+
+	if keys, ok := p.Get("Bob"); !ok || len(keys) != 2 || string(keys[0]) != "bob" {
+		t.Errorf("Pinned keys should be %s but got %s", "[bob laptop]", keys)
+	}
+}
+
+func TestPinsTrusted(t *testing.T) {
+	p := NewPins()
+
+	keys := func(keys ...string) [][]byte {
+		res := make([][]byte, 0, len(keys))
+		for _, key := range keys {
+			res = append(res, []byte(key))
+		}
+		return res
+	}
+
+	cases := []struct {
+		name    string
+		keys    [][]byte
+		trusted [][]byte
+		unknown [][]byte
+	}{
+		{name: "first use", keys: keys("phone", "laptop"), trusted: keys("phone", "laptop")},
+		{name: "pinned", keys: keys("laptop"), trusted: keys("laptop")},
+		{name: "new device", keys: keys("phone", "mallory"), trusted: keys("phone"), unknown: keys("mallory")},
+	}
+
+	for _, tc := range cases {
+		trusted, unknown, err := p.Trusted("Bob", tc.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(tc.trusted, trusted) {
+			t.Errorf("Trusted keys should be %s but got %s (%s)", tc.trusted, trusted, tc.name)
+		}
+
+		if !reflect.DeepEqual(tc.unknown, unknown) {
+			t.Errorf("Unknown keys should be %s but got %s (%s)", tc.unknown, unknown, tc.name)
+		}
+	}
+
+	if pending := p.Pending("Bob"); !reflect.DeepEqual(keys("mallory"), pending) {
+		t.Errorf("Pending keys should be %s but got %s", keys("mallory"), pending)
+	}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"golang.org/x/text/unicode/bidi"
+)
+
+const (
+	// fsi and pdi isolate right-to-left text from the surrounding text
+	fsi = '\u2068'
+	pdi = '\u2069'
+)
+
+// nameColors are ANSI foreground colors of client names
+var nameColors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// Renderer formats chat events for terminal
+type Renderer struct {
+	// Color enables colors of client names, each name always gets the same color
+	Color bool
+}
+
+// Render method returns terminal safe line of the event, false for unknown events
+func (r *Renderer) Render(res *chat.ResponseStream) (string, bool) {
+	switch evt := res.Event.(type) {
+	case *chat.ResponseStream_ClientLogin:
+		return fmt.Sprintf("Server: %s is online", r.Name(evt.ClientLogin.Name)), true
+	case *chat.ResponseStream_ClientLogout:
+		return fmt.Sprintf("Server: %s is offline", r.Name(evt.ClientLogout.Name)), true
+	case *chat.ResponseStream_ClientMessage:
+		line := fmt.Sprintf("%s: %s", r.Name(evt.ClientMessage.Name), Sanitize(evt.ClientMessage.Message))
+		for _, a := range evt.ClientMessage.Attachments {
+			line += fmt.Sprintf(" [%s, %s, /get %s]", Sanitize(a.Name), FormatSize(a.Size), Sanitize(a.Id))
+		}
+		return line, true
+	case *chat.ResponseStream_ServerAnnouncement:
+		return fmt.Sprintf("Server: %s", Sanitize(evt.ServerAnnouncement.Message)), true
+	case *chat.ResponseStream_ServerShutdown:
+		if d, ok := ShutdownIn(res); ok {
+			return fmt.Sprintf("Server: server is shutting down in %s", d), true
+		}
+		return "Server: server is shutting down", true
+	}
+
+	return "", false
+}
+
+// Direct method returns terminal safe line of decrypted direct message
+func (r *Renderer) Direct(from, to, text string) string {
+	return fmt.Sprintf("Direct: %s → %s: %s", r.Name(from), r.Name(to), Sanitize(text))
+}
+
+// ShutdownIn function returns time from the shutdown event until the server closes the stream
+// false if the event has no deadline
+func ShutdownIn(res *chat.ResponseStream) (time.Duration, bool) {
+	evt, ok := res.Event.(*chat.ResponseStream_ServerShutdown)
+	if !ok || evt.ServerShutdown.Deadline == nil {
+		return 0, false
+	}
+
+	deadline, err := ptypes.Timestamp(evt.ServerShutdown.Deadline)
+	if err != nil {
+		return 0, false
+	}
+
+	sent, err := ptypes.Timestamp(res.Timestamp)
+	if err != nil {
+		sent = time.Now()
+	}
+
+	if deadline.Before(sent) {
+		return 0, true
+	}
+
+	return deadline.Sub(sent).Round(time.Second), true
+}
+
+// Name method returns sanitized and optionally colored client name
+func (r *Renderer) Name(name string) string {
+	safe := Sanitize(name)
+	if !r.Color {
+		return safe
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", nameColors[h.Sum32()%uint32(len(nameColors))], safe)
+}
+
+// Sanitize function makes text safe for terminal
+// control characters and invalid bytes are shown escaped so that they can't clear screen or rewrite lines,
+// bidi controls are dropped and right-to-left text is isolated so that it can't reorder the rest of the line
+func Sanitize(text string) string {
+	var b strings.Builder
+	var rtl bool
+
+	for i, r := range text {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(text[i:]); size == 1 {
+				fmt.Fprintf(&b, "\\x%02x", text[i])
+				continue
+			}
+		}
+
+		if unicode.IsControl(r) {
+			b.WriteString(escape(r))
+			continue
+		}
+
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.LRO, bidi.RLO, bidi.LRE, bidi.RLE, bidi.PDF, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			continue
+		case bidi.R, bidi.AL:
+			rtl = true
+		}
+
+		b.WriteRune(r)
+	}
+
+	if !rtl {
+		return b.String()
+	}
+
+	return string(fsi) + b.String() + string(pdi)
+}
+
+// escape function returns visible form of control character
+func escape(r rune) string {
+	switch r {
+	case '\t':
+		return "\\t"
+	case '\n':
+		return "\\n"
+	case '\r':
+		return "\\r"
+	case '\x1b':
+		return "\\e"
+	}
+
+	if r < 0x100 {
+		return fmt.Sprintf("\\x%02x", r)
+	}
+
+	return fmt.Sprintf("\\u%04x", r)
+}
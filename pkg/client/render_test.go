@@ -0,0 +1,123 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{text: "hello", expected: "hello"},
+		{text: "привет", expected: "привет"},
+		{text: "\x1b[2J\x1b[H", expected: "\\e[2J\\e[H"},
+		{text: "line\rfake", expected: "line\\rfake"},
+		{text: "bell\a", expected: "bell\\x07"},
+		{text: "\u009b31m", expected: "\\x9b31m"},
+		{text: "bad\xff", expected: "bad\\xff"},
+		{text: "abc\u202egpj.exe", expected: "abcgpj.exe"},
+		{text: "שלום", expected: "\u2068שלום\u2069"},
+		{text: "hi \u2067שלום", expected: "\u2068hi שלום\u2069"},
+	}
+
+	for _, tc := range cases {
+		if text := Sanitize(tc.text); tc.expected != text {
+			t.Errorf("Text should be %q but got %q", tc.expected, text)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := new(Renderer)
+
+	cases := []struct {
+		res      *chat.ResponseStream
+		expected string
+		ok       bool
+	}{
+		{
+			res:      &chat.ResponseStream{Event: &chat.ResponseStream_ClientLogin{ClientLogin: &chat.ResponseStream_Login{Name: "Bob"}}},
+			expected: "Server: Bob is online",
+			ok:       true,
+		},
+		{
+			res:      &chat.ResponseStream{Event: &chat.ResponseStream_ClientLogout{ClientLogout: &chat.ResponseStream_Logout{Name: "Bob"}}},
+			expected: "Server: Bob is offline",
+			ok:       true,
+		},
+		{
+			res:      &chat.ResponseStream{Event: &chat.ResponseStream_ClientMessage{ClientMessage: &chat.ResponseStream_Message{Name: "Eve", Message: "\x1b[2Jhi"}}},
+			expected: "Eve: \\e[2Jhi",
+			ok:       true,
+		},
+		{
+			res: &chat.ResponseStream{Event: &chat.ResponseStream_ClientMessage{ClientMessage: &chat.ResponseStream_Message{
+				Name:        "Bob",
+				Message:     "server.log",
+				Attachments: []*chat.Attachment{{Id: "f00d", Name: "server.log", Size: 1536}},
+			}}},
+			expected: "Bob: server.log [server.log, 1.5 KiB, /get f00d]",
+			ok:       true,
+		},
+		{
+			res:      &chat.ResponseStream{Event: &chat.ResponseStream_ServerAnnouncement{ServerAnnouncement: &chat.ResponseStream_Announcement{Message: "restart"}}},
+			expected: "Server: restart",
+			ok:       true,
+		},
+		{
+			res:      &chat.ResponseStream{Event: &chat.ResponseStream_ServerShutdown{ServerShutdown: &chat.ResponseStream_Shutdown{}}},
+			expected: "Server: server is shutting down",
+			ok:       true,
+		},
+		{
+			res: &chat.ResponseStream{
+				Timestamp: &timestamp.Timestamp{Seconds: 1000},
+				Event:     &chat.ResponseStream_ServerShutdown{ServerShutdown: &chat.ResponseStream_Shutdown{Deadline: &timestamp.Timestamp{Seconds: 1030}}},
+			},
+			expected: "Server: server is shutting down in 30s",
+			ok:       true,
+		},
+		{
+			res: &chat.ResponseStream{},
+		},
+	}
+
+	for _, tc := range cases {
+		line, ok := r.Render(tc.res)
+		if tc.ok != ok || tc.expected != line {
+			t.Errorf("Line should be %q but got %q", tc.expected, line)
+		}
+	}
+}
+
+func TestRenderDirect(t *testing.T) {
+	r := new(Renderer)
+
+	expected := "Direct: Alice → Bob: \\e[2Jhi"
+	if line := r.Direct("Alice", "Bob", "\x1b[2Jhi"); expected != line {
+		t.Errorf("Line should be %q but got %q", expected, line)
+	}
+}
+
+func TestRendererColor(t *testing.T) {
+	r := &Renderer{Color: true}
+
+	bob := r.Name("Bob")
+	if bob != r.Name("Bob") {
+		t.Error("Name should always get the same color")
+	}
+
+	if !strings.HasPrefix(bob, "\x1b[") || !strings.Contains(bob, "Bob") || !strings.HasSuffix(bob, "\x1b[0m") {
+		t.Errorf("Name should be colored but got %q", bob)
+	}
+
+	if evil := r.Name("\x1b[2J"); strings.Contains(evil, "\x1b[2J") {
+		t.Errorf("Name should be sanitized but got %q", evil)
+	}
+}
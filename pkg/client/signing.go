@@ -0,0 +1,62 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/sign"
+	"golang.org/x/text/unicode/norm"
+)
+
+// markers of messages printed before the author
+const (
+	// markVerified is printed for messages signed by pinned key of the author
+	markVerified = "✓"
+	// markUnverified is printed for messages without valid signature
+	markUnverified = "?"
+	// markChanged is printed for messages signed by key which is not one of pinned keys of the author
+	markChanged = "!"
+)
+
+// request method returns message signed by Signer
+// the message and the name are normalized like the server does so that the signature stays valid
+func (c *Client) request(message string, attachments []string) *chat.RequestStream {
+	req := &chat.RequestStream{
+		Message:     norm.NFC.String(message),
+		Attachments: attachments,
+	}
+
+	if c.Signer != nil {
+		req.Signature = c.Signer.Sign(normalizeName(c.Name), req.Message, req.Attachments)
+	}
+
+	return req
+}
+
+// normalizeName function returns the name normalized like the server does for sessions
+func normalizeName(name string) string {
+	return norm.NFKC.String(strings.TrimSpace(name))
+}
+
+// mark method returns marker of the message verified by signature and pinned key of the author
+func (c *Client) mark(msg *chat.ResponseStream_Message) string {
+	ids := make([]string, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		ids = append(ids, a.Id)
+	}
+
+	if !sign.Verify(msg.SigningKey, msg.Name, msg.Message, ids, msg.Signature) {
+		return markUnverified
+	}
+
+	status, err := c.Pins.Check(msg.Name, msg.SigningKey)
+	if err != nil {
+		c.Logger.Warn("Failed to pin signing key", "user", msg.Name, "err", err)
+	}
+
+	if status == PinChanged {
+		return markChanged
+	}
+
+	return markVerified
+}
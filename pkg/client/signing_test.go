@@ -0,0 +1,91 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/sign"
+)
+
+func TestMark(t *testing.T) {
+	alice, _ := sign.GenerateKey()
+	mallory, _ := sign.GenerateKey()
+
+	// full width letter is normalized like the server does for the session name
+	c, _ := NewClient("example:8000", "\uff21lice", false)
+	c.Signer = alice
+
+	// combining accent is normalized like the server does
+	req := c.request("cafe\u0301", []string{"f00d"})
+	if req.Message != "café" {
+		t.Errorf("Message should be %q but got %q", "café", req.Message)
+	}
+
+	if !sign.Verify(alice.Public(), "Alice", req.Message, req.Attachments, req.Signature) {
+		t.Error("Message should be signed with normalized name")
+	}
+
+	message := func(text string, key *sign.Key, sig []byte) *chat.ResponseStream_Message {
+		return &chat.ResponseStream_Message{
+			Name:        "Alice",
+			Message:     text,
+			Attachments: []*chat.Attachment{{Id: "f00d"}},
+			Signature:   sig,
+			SigningKey:  key.Public(),
+		}
+	}
+
+	cases := []struct {
+		name     string
+		msg      *chat.ResponseStream_Message
+		expected string
+	}{
+		{name: "first use", msg: message(req.Message, alice, req.Signature), expected: markVerified},
+		{name: "pinned", msg: message(req.Message, alice, req.Signature), expected: markVerified},
+		{name: "changed text", msg: message("cafe", alice, req.Signature), expected: markUnverified},
+		{name: "not signed", msg: &chat.ResponseStream_Message{Name: "Alice", Message: "hi"}, expected: markUnverified},
+		{name: "other key", msg: message("hi", mallory, mallory.Sign("Alice", "hi", []string{"f00d"})), expected: markChanged},
+		{name: "other key again", msg: message("hi", mallory, mallory.Sign("Alice", "hi", []string{"f00d"})), expected: markChanged},
+	}
+
+	for _, tc := range cases {
+		if mark := c.mark(tc.msg); tc.expected != mark {
+			t.Errorf("Mark should be %s but got %s (%s)", tc.expected, mark, tc.name)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	alice, _ := sign.GenerateKey()
+	laptop, _ := sign.GenerateKey()
+
+	c, _ := NewClient("example:8000", "Bob", false)
+
+	message := func(key *sign.Key) *chat.ResponseStream_Message {
+		return &chat.ResponseStream_Message{Name: "Alice", Message: "hi", Signature: key.Sign("Alice", "hi", nil), SigningKey: key.Public()}
+	}
+
+	if mark := c.mark(message(alice)); mark != markVerified {
+		t.Errorf("Mark should be %s but got %s", markVerified, mark)
+	}
+
+	// key of another device of the author is trusted after the user confirms its fingerprint
+	if mark := c.mark(message(laptop)); mark != markChanged {
+		t.Errorf("Mark should be %s but got %s", markChanged, mark)
+	}
+
+	if err := c.confirm("Alice", "0000", nil); err == nil {
+		t.Error("Unknown fingerprint should not be confirmed")
+	}
+
+	if err := c.confirm("Alice", strings.Replace(sign.Fingerprint(laptop.Public()), " ", "", -1), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []*sign.Key{alice, laptop} {
+		if mark := c.mark(message(key)); mark != markVerified {
+			t.Errorf("Mark should be %s but got %s", markVerified, mark)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// Bus delivers chat events to every server instance
+type Bus interface {
+	// Publish sends event to all subscribers including the publisher itself
+	Publish(res chat.ResponseStream) error
+	// Subscribe returns channel of published events which is closed when ctx is done
+	Subscribe(ctx context.Context) (<-chan chat.ResponseStream, error)
+}
+
+// Presence counts client sessions across all server instances
+type Presence interface {
+	// Join registers new session, returns true if it is the first session of the client
+	Join(name string) (bool, error)
+	// Leave unregisters session, returns true if it was the last session of the client
+	Leave(name string) (bool, error)
+	// Online returns sorted names of clients with at least one session
+	Online() ([]string, error)
+}
+
+// Directory keeps public keys of client devices across all server instances
+type Directory interface {
+	// Register adds device key of the client session
+	Register(name string, key []byte) error
+	// Unregister removes device key of the client session
+	Unregister(name string, key []byte) error
+	// Devices returns device keys of the client on all instances
+	Devices(name string) ([][]byte, error)
+}
+
+// Checker is implemented by backends which can become unavailable
+type Checker interface {
+	// Check returns error if the backend is not healthy
+	Check() error
+}
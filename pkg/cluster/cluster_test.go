@@ -0,0 +1,424 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// fakeRedis is local stand-in for redis server supporting commands used by the cluster
+type fakeRedis struct {
+	l           net.Listener
+	hashes      map[string]map[string]int64
+	instances   map[string]int64
+	subscribers map[*redisConn]bool
+	mtx         sync.Mutex
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &fakeRedis{
+		l:           l,
+		hashes:      make(map[string]map[string]int64),
+		instances:   make(map[string]int64),
+		subscribers: make(map[*redisConn]bool),
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(&redisConn{conn: conn, r: bufio.NewReader(conn)})
+		}
+	}()
+
+	return r
+}
+
+func (r *fakeRedis) serve(c *redisConn) {
+	defer c.Close()
+
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return
+		}
+
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i := range items {
+			args[i] = string(bulk(items[i]))
+		}
+
+		r.mtx.Lock()
+		switch args[0] {
+		case "EVAL":
+			r.eval(c, args[1], args[3:6], args[6:])
+		case "PING":
+			c.conn.Write([]byte("+PONG\r\n"))
+		case "SUBSCRIBE":
+			r.subscribers[c] = true
+			c.write("subscribe", args[1])
+		case "PUBLISH":
+			for sub := range r.subscribers {
+				sub.write("message", args[1], args[2])
+			}
+			c.conn.Write([]byte(":" + strconv.Itoa(len(r.subscribers)) + "\r\n"))
+		default:
+			c.conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+		r.mtx.Unlock()
+	}
+}
+
+// eval method runs presence and device scripts
+func (r *fakeRedis) eval(c *redisConn, script string, keys, args []string) {
+	now, _ := strconv.ParseInt(args[1], 10, 64)
+	ttl, _ := strconv.ParseInt(args[2], 10, 64)
+	name := args[3]
+
+	for id, deadline := range r.instances {
+		if deadline <= now {
+			delete(r.instances, id)
+			delete(r.hashes, redisPresencePrefix+id)
+			delete(r.hashes, redisDevicesPrefix+id)
+		}
+	}
+	r.instances[args[0]] = now + ttl
+
+	hash := r.hashes[keys[1]]
+	if hash == nil {
+		hash = make(map[string]int64)
+		r.hashes[keys[1]] = hash
+	}
+
+	devices := r.hashes[keys[2]]
+	if devices == nil {
+		devices = make(map[string]int64)
+		r.hashes[keys[2]] = devices
+	}
+
+	sessions := func() int64 {
+		var n int64
+		for id := range r.instances {
+			n += r.hashes[redisPresencePrefix+id][name]
+		}
+		return n
+	}
+
+	switch script {
+	case presenceJoinScript:
+		hash[name]++
+		c.conn.Write([]byte(":" + strconv.FormatInt(sessions(), 10) + "\r\n"))
+	case presenceLeaveScript:
+		hash[name]--
+		n := hash[name]
+		if n <= 0 {
+			delete(hash, name)
+		}
+		if n >= 0 {
+			n = sessions()
+		}
+		c.conn.Write([]byte(":" + strconv.FormatInt(n, 10) + "\r\n"))
+	case presenceHeartbeatScript:
+		c.conn.Write([]byte(":0\r\n"))
+	case presenceOnlineScript:
+		var names []string
+		for id := range r.instances {
+			for name := range r.hashes[redisPresencePrefix+id] {
+				names = append(names, name)
+			}
+		}
+		c.write(names...)
+	case presenceRegisterScript:
+		devices[name]++
+		c.conn.Write([]byte(":0\r\n"))
+	case presenceUnregisterScript:
+		if devices[name]--; devices[name] <= 0 {
+			delete(devices, name)
+		}
+		c.conn.Write([]byte(":0\r\n"))
+	case presenceDevicesScript:
+		var keys []string
+		for id := range r.instances {
+			for field := range r.hashes[redisDevicesPrefix+id] {
+				if strings.HasPrefix(field, name) {
+					keys = append(keys, strings.TrimPrefix(field, name))
+				}
+			}
+		}
+		c.write(keys...)
+	default:
+		c.conn.Write([]byte("-ERR unknown script\r\n"))
+	}
+}
+
+func TestRedisPresence(t *testing.T) {
+	r := newFakeRedis(t)
+	defer r.l.Close()
+
+	// two server instances
+	instances := []Presence{
+		NewRedisPresence(r.l.Addr().String()),
+		NewRedisPresence(r.l.Addr().String()),
+	}
+
+	cases := []struct {
+		instance int
+		join     bool
+		name     string
+		ok       bool
+	}{
+		{instance: 0, join: true, name: "Alice", ok: true},
+		{instance: 1, join: true, name: "Alice", ok: false},
+		{instance: 1, join: true, name: "Bob", ok: true},
+		{instance: 0, join: false, name: "Alice", ok: false},
+		{instance: 1, join: false, name: "Alice", ok: true},
+		{instance: 0, join: true, name: "Alice", ok: true},
+	}
+
+	for _, tc := range cases {
+		var ok bool
+		var err error
+
+		if tc.join {
+			ok, err = instances[tc.instance].Join(tc.name)
+		} else {
+			ok, err = instances[tc.instance].Leave(tc.name)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tc.ok != ok {
+			t.Errorf("Ok should be %t but got %t (%+v)", tc.ok, ok, tc)
+		}
+	}
+}
+
+func TestRedisCheck(t *testing.T) {
+	r := newFakeRedis(t)
+
+	checkers := []Checker{
+		NewRedisPresence(r.l.Addr().String()),
+		NewRedisBus(r.l.Addr().String()),
+	}
+
+	for _, c := range checkers {
+		if err := c.Check(); err != nil {
+			t.Errorf("Check should succeed but got %v", err)
+		}
+	}
+
+	r.l.Close()
+
+	// new connections are refused
+	if err := NewRedisPresence(r.l.Addr().String()).Check(); err == nil {
+		t.Error("Check of unavailable redis should fail")
+	}
+}
+
+func TestRedisBus(t *testing.T) {
+	r := newFakeRedis(t)
+	defer r.l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buses := []Bus{
+		NewRedisBus(r.l.Addr().String()),
+		NewRedisBus(r.l.Addr().String()),
+	}
+
+	var subscriptions []<-chan chat.ResponseStream
+	for _, b := range buses {
+		events, err := b.Subscribe(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subscriptions = append(subscriptions, events)
+	}
+
+	err := buses[0].Publish(chat.ResponseStream{
+		Event: &chat.ResponseStream_ClientMessage{
+			ClientMessage: &chat.ResponseStream_Message{Name: "Alice", Message: "hi"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, events := range subscriptions {
+		select {
+		case res := <-events:
+			msg := res.GetClientMessage()
+			if msg == nil || msg.Name != "Alice" || msg.Message != "hi" {
+				t.Errorf("Event should be message from Alice but got %v (instance %d)", res, i)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("Timeout while waiting for event (instance %d)", i)
+		}
+	}
+}
+
+func TestLocalPresence(t *testing.T) {
+	p := NewLocalPresence()
+
+	cases := []struct {
+		join bool
+		name string
+		ok   bool
+	}{
+		{join: true, name: "Alice", ok: true},
+		{join: true, name: "Alice", ok: false},
+		{join: false, name: "Alice", ok: false},
+		{join: false, name: "Bob", ok: false},
+		{join: false, name: "Alice", ok: true},
+	}
+
+	for _, tc := range cases {
+		var ok bool
+		if tc.join {
+			ok, _ = p.Join(tc.name)
+		} else {
+			ok, _ = p.Leave(tc.name)
+		}
+
+		if tc.ok != ok {
+			t.Errorf("Ok should be %t but got %t (%+v)", tc.ok, ok, tc)
+		}
+	}
+}
+
+func TestRedisPresenceRelease(t *testing.T) {
+	r := newFakeRedis(t)
+	defer r.l.Close()
+
+	now := time.Now()
+	clock := func() time.Time {
+		return now
+	}
+
+	crashed := NewRedisPresence(r.l.Addr().String())
+	crashed.now = clock
+	alive := NewRedisPresence(r.l.Addr().String())
+	alive.now = clock
+
+	if _, err := crashed.Join("Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alive.Join("Bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	online, err := alive.Online()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(online, ",") != "Alice,Bob" {
+		t.Errorf("Online should be [Alice Bob] but got %v", online)
+	}
+
+	// only the alive instance sends heartbeats
+	now = now.Add(RedisPresenceTTL / 2)
+	if _, err := alive.eval(presenceHeartbeatScript, ""); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(RedisPresenceTTL / 2)
+
+	online, err = alive.Online()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(online, ",") != "Bob" {
+		t.Errorf("Online should be [Bob] but got %v", online)
+	}
+
+	first, err := alive.Join("Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first {
+		t.Error("Session of crashed instance should be released")
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	r := newFakeRedis(t)
+	defer r.l.Close()
+
+	// device keys are shared by server instances
+	cases := []struct {
+		name      string
+		directory Directory
+		other     Directory
+	}{
+		{name: "local", directory: NewLocalDirectory()},
+		{name: "redis", directory: NewRedisPresence(r.l.Addr().String()), other: NewRedisPresence(r.l.Addr().String())},
+	}
+
+	for _, tc := range cases {
+		other := tc.other
+		if other == nil {
+			other = tc.directory
+		}
+
+		steps := []struct {
+			directory Directory
+			register  bool
+			key       string
+			expected  string
+		}{
+			{directory: tc.directory, register: true, key: "phone", expected: "phone"},
+			{directory: other, register: true, key: "laptop", expected: "laptop,phone"},
+			{directory: tc.directory, register: true, key: "phone", expected: "laptop,phone"},
+			{directory: tc.directory, register: false, key: "phone", expected: "laptop,phone"},
+			{directory: other, register: false, key: "laptop", expected: "phone"},
+			{directory: tc.directory, register: false, key: "phone", expected: ""},
+		}
+
+		for i, step := range steps {
+			var err error
+			if step.register {
+				err = step.directory.Register("Bob", []byte(step.key))
+			} else {
+				err = step.directory.Unregister("Bob", []byte(step.key))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keys, err := other.Devices("Bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make([]string, 0, len(keys))
+			for _, key := range keys {
+				names = append(names, string(key))
+			}
+
+			if devices := strings.Join(names, ","); step.expected != devices {
+				t.Errorf("Devices should be %q but got %q (%s step %d)", step.expected, devices, tc.name, i)
+			}
+		}
+
+		if keys, _ := tc.directory.Devices("Alice"); len(keys) != 0 {
+			t.Errorf("Alice should have no devices but got %d (%s)", len(keys), tc.name)
+		}
+	}
+}
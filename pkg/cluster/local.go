@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// LocalBus implements Bus interface for single server instance
+type LocalBus struct {
+	subscribers map[*subscriber]bool
+	mtx         sync.RWMutex
+}
+
+type subscriber struct {
+	events chan chat.ResponseStream
+	done   <-chan struct{}
+}
+
+// Publish method sends event to all subscribers
+func (b *LocalBus) Publish(res chat.ResponseStream) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- res:
+		case <-sub.done:
+			// subscriber is gone
+		}
+	}
+
+	return nil
+}
+
+// Subscribe method returns channel of published events
+func (b *LocalBus) Subscribe(ctx context.Context) (<-chan chat.ResponseStream, error) {
+	sub := &subscriber{
+		events: make(chan chat.ResponseStream, 100),
+		done:   ctx.Done(),
+	}
+
+	b.mtx.Lock()
+	b.subscribers[sub] = true
+	b.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mtx.Lock()
+		delete(b.subscribers, sub)
+		close(sub.events)
+		b.mtx.Unlock()
+	}()
+
+	return sub.events, nil
+}
+
+// NewLocalBus returns LocalBus pointer
+func NewLocalBus() *LocalBus {
+	return &LocalBus{
+		subscribers: make(map[*subscriber]bool),
+	}
+}
+
+// LocalPresence implements Presence interface for single server instance
+type LocalPresence struct {
+	sessions map[string]int
+	mtx      sync.Mutex
+}
+
+// Join method increments sessions counter of the client
+func (p *LocalPresence) Join(name string) (bool, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.sessions[name]++
+
+	return p.sessions[name] == 1, nil
+}
+
+// Leave method decrements sessions counter of the client
+func (p *LocalPresence) Leave(name string) (bool, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	n, ok := p.sessions[name]
+	if !ok {
+		return false, nil
+	}
+
+	if n > 1 {
+		p.sessions[name]--
+		return false, nil
+	}
+
+	delete(p.sessions, name)
+	return true, nil
+}
+
+// Online method returns sorted names of clients with at least one session
+func (p *LocalPresence) Online() ([]string, error) {
+	p.mtx.Lock()
+	names := make([]string, 0, len(p.sessions))
+	for name := range p.sessions {
+		names = append(names, name)
+	}
+	p.mtx.Unlock()
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// NewLocalPresence returns LocalPresence pointer
+func NewLocalPresence() *LocalPresence {
+	return &LocalPresence{
+		sessions: make(map[string]int),
+	}
+}
+
+// LocalDirectory implements Directory interface for single server instance
+type LocalDirectory struct {
+	keys map[string]map[string]int
+	mtx  sync.Mutex
+}
+
+// Register method increments sessions counter of the device key
+func (d *LocalDirectory) Register(name string, key []byte) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	if d.keys[name] == nil {
+		d.keys[name] = make(map[string]int)
+	}
+	d.keys[name][string(key)]++
+
+	return nil
+}
+
+// Unregister method decrements sessions counter of the device key
+func (d *LocalDirectory) Unregister(name string, key []byte) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	keys := d.keys[name]
+	if keys[string(key)] > 1 {
+		keys[string(key)]--
+		return nil
+	}
+
+	delete(keys, string(key))
+	if len(keys) == 0 {
+		delete(d.keys, name)
+	}
+
+	return nil
+}
+
+// Devices method returns sorted device keys of the client
+func (d *LocalDirectory) Devices(name string) ([][]byte, error) {
+	d.mtx.Lock()
+	ids := make([]string, 0, len(d.keys[name]))
+	for key := range d.keys[name] {
+		ids = append(ids, key)
+	}
+	d.mtx.Unlock()
+
+	sort.Strings(ids)
+
+	keys := make([][]byte, 0, len(ids))
+	for _, key := range ids {
+		keys = append(keys, []byte(key))
+	}
+
+	return keys, nil
+}
+
+// NewLocalDirectory returns LocalDirectory pointer
+func NewLocalDirectory() *LocalDirectory {
+	return &LocalDirectory{
+		keys: make(map[string]map[string]int),
+	}
+}
@@ -0,0 +1,542 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+const (
+	// redisChannel is used to publish chat events
+	redisChannel = "chat:events"
+
+	// redisPresencePrefix is prepended to instance ID to build key of its sessions hash
+	redisPresencePrefix = "chat:presence:"
+
+	// redisDevicesPrefix is prepended to instance ID to build key of its device keys hash
+	redisDevicesPrefix = "chat:devices:"
+
+	// redisInstances is sorted set of live instances scored by heartbeat deadline
+	redisInstances = "chat:instances"
+
+	redisTimeout   = 5 * time.Second
+	redisReconnect = time.Second
+)
+
+// RedisPresenceTTL is time after which sessions of the instance are released unless it sends heartbeats
+const RedisPresenceTTL = 30 * time.Second
+
+// RedisBus implements Bus interface on top of Redis PUBLISH/SUBSCRIBE
+type RedisBus struct {
+	Addr string
+
+	client *redisClient
+}
+
+// Publish method publishes event to redis channel
+func (b *RedisBus) Publish(res chat.ResponseStream) error {
+	payload, err := proto.Marshal(&res)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to encode event")
+	}
+
+	_, err = b.client.do("PUBLISH", redisChannel, string(payload))
+	return err
+}
+
+// Subscribe method subscribes to redis channel, reconnects on failure until ctx is done
+func (b *RedisBus) Subscribe(ctx context.Context) (<-chan chat.ResponseStream, error) {
+	conn, err := b.subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan chat.ResponseStream, 100)
+
+	go func() {
+		defer close(events)
+
+		for {
+			done := make(chan struct{})
+			go func(conn *redisConn) {
+				select {
+				case <-ctx.Done():
+				case <-done:
+				}
+				conn.Close()
+			}(conn)
+
+			b.receive(conn, events)
+			close(done)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			// connection is lost, trying to reconnect
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(redisReconnect):
+				}
+
+				if conn, err = b.subscribe(); err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func (b *RedisBus) subscribe() (*redisConn, error) {
+	conn, err := dialRedis(b.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.do("SUBSCRIBE", redisChannel); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// subscribed connection is idle until something is published
+	conn.conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+// receive method reads published messages until connection is closed
+func (b *RedisBus) receive(conn *redisConn, events chan chat.ResponseStream) {
+	for {
+		reply, err := conn.read()
+		if err != nil {
+			return
+		}
+
+		// message is ["message", channel, payload]
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 || string(bulk(msg[0])) != "message" {
+			continue
+		}
+
+		var res chat.ResponseStream
+		if err := proto.Unmarshal(bulk(msg[2]), &res); err != nil {
+			continue
+		}
+
+		events <- res
+	}
+}
+
+// Check method pings redis
+func (b *RedisBus) Check() error {
+	_, err := b.client.do("PING")
+	return err
+}
+
+// NewRedisBus returns RedisBus pointer
+func NewRedisBus(addr string) *RedisBus {
+	return &RedisBus{
+		Addr:   addr,
+		client: &redisClient{addr: addr},
+	}
+}
+
+// RedisPresence implements Presence and Directory interfaces on top of Redis hashes
+// every instance counts its sessions and device keys in own hashes which expire unless the instance sends heartbeats,
+// so sessions of crashed instance are released after RedisPresenceTTL
+type RedisPresence struct {
+	Addr string
+	// ID identifies the server instance
+	ID string
+
+	client *redisClient
+	now    func() time.Time
+}
+
+// presenceScript is prepended to presence scripts
+// KEYS[1] is set of live instances, KEYS[2] is sessions hash of the instance, KEYS[3] is device keys hash of the instance
+// ARGV[1] is instance ID, ARGV[2] is current time in milliseconds, ARGV[3] is TTL in milliseconds, ARGV[4] is client name
+// device scripts get client name and hex key separated by new line in ARGV[4]
+const presenceScript = `
+local function heartbeat()
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[2])
+	redis.call('ZADD', KEYS[1], ARGV[2] + ARGV[3], ARGV[1])
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+	redis.call('PEXPIRE', KEYS[3], ARGV[3])
+end
+
+local function sessions(name)
+	local n = 0
+	for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+		n = n + tonumber(redis.call('HGET', '` + redisPresencePrefix + `' .. id, name) or 0)
+	end
+	return n
+end
+`
+
+var (
+	// presenceJoinScript returns number of sessions of the client on all instances
+	presenceJoinScript = presenceScript + `
+redis.call('HINCRBY', KEYS[2], ARGV[4], 1)
+heartbeat()
+return sessions(ARGV[4])
+`
+
+	// presenceLeaveScript returns number of sessions of the client left on all instances
+	// or -1 if the client has no sessions on the instance
+	presenceLeaveScript = presenceScript + `
+local n = redis.call('HINCRBY', KEYS[2], ARGV[4], -1)
+if n <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[4])
+end
+heartbeat()
+if n < 0 then
+	return -1
+end
+return sessions(ARGV[4])
+`
+
+	// presenceHeartbeatScript prolongs sessions of the instance
+	presenceHeartbeatScript = presenceScript + `
+heartbeat()
+return 0
+`
+
+	// presenceOnlineScript returns names of clients of all live instances, names can repeat
+	presenceOnlineScript = presenceScript + `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[2])
+local names = {}
+for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	for _, name in ipairs(redis.call('HKEYS', '` + redisPresencePrefix + `' .. id)) do
+		names[#names + 1] = name
+	end
+end
+return names
+`
+
+	// presenceRegisterScript counts sessions of the device key on the instance
+	presenceRegisterScript = presenceScript + `
+redis.call('HINCRBY', KEYS[3], ARGV[4], 1)
+heartbeat()
+return 0
+`
+
+	// presenceUnregisterScript releases session of the device key on the instance
+	presenceUnregisterScript = presenceScript + `
+if redis.call('HINCRBY', KEYS[3], ARGV[4], -1) <= 0 then
+	redis.call('HDEL', KEYS[3], ARGV[4])
+end
+heartbeat()
+return 0
+`
+
+	// presenceDevicesScript returns hex keys of devices of the client on all live instances, keys can repeat
+	// ARGV[4] is client name followed by new line
+	presenceDevicesScript = presenceScript + `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[2])
+local keys = {}
+for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	for _, field in ipairs(redis.call('HKEYS', '` + redisDevicesPrefix + `' .. id)) do
+		if string.sub(field, 1, #ARGV[4]) == ARGV[4] then
+			keys[#keys + 1] = string.sub(field, #ARGV[4] + 1)
+		end
+	end
+end
+return keys
+`
+)
+
+// eval method runs presence script for the client name
+func (p *RedisPresence) eval(script, name string) (interface{}, error) {
+	ms := func(t time.Time) string {
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	}
+
+	return p.client.do("EVAL", script, "3", redisInstances, redisPresencePrefix+p.ID, redisDevicesPrefix+p.ID,
+		p.ID, ms(p.now()), strconv.FormatInt(int64(RedisPresenceTTL/time.Millisecond), 10), name)
+}
+
+// Join method increments sessions counter of the client
+func (p *RedisPresence) Join(name string) (bool, error) {
+	reply, err := p.eval(presenceJoinScript, name)
+	if err != nil {
+		return false, err
+	}
+
+	n, _ := reply.(int64)
+	return n == 1, nil
+}
+
+// Leave method decrements sessions counter of the client
+func (p *RedisPresence) Leave(name string) (bool, error) {
+	reply, err := p.eval(presenceLeaveScript, name)
+	if err != nil {
+		return false, err
+	}
+
+	n, _ := reply.(int64)
+	return n == 0, nil
+}
+
+// Online method returns sorted names of clients with sessions on live instances
+func (p *RedisPresence) Online() ([]string, error) {
+	reply, err := p.eval(presenceOnlineScript, "")
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := reply.([]interface{})
+	seen := make(map[string]bool, len(items))
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		name := string(bulk(item))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Register method counts session of the device key on the instance
+func (p *RedisPresence) Register(name string, key []byte) error {
+	_, err := p.eval(presenceRegisterScript, name+"\n"+hex.EncodeToString(key))
+	return err
+}
+
+// Unregister method releases session of the device key on the instance
+func (p *RedisPresence) Unregister(name string, key []byte) error {
+	_, err := p.eval(presenceUnregisterScript, name+"\n"+hex.EncodeToString(key))
+	return err
+}
+
+// Devices method returns sorted device keys of the client on live instances
+func (p *RedisPresence) Devices(name string) ([][]byte, error) {
+	reply, err := p.eval(presenceDevicesScript, name+"\n")
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := reply.([]interface{})
+	ids := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		id := string(bulk(item))
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	keys := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		key, err := hex.DecodeString(id)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid device key of "+name)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Run method sends heartbeats until ctx is done
+func (p *RedisPresence) Run(ctx context.Context) {
+	ticker := time.NewTicker(RedisPresenceTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// failed heartbeat is retried by the next tick
+			p.eval(presenceHeartbeatScript, "")
+		}
+	}
+}
+
+// Check method pings redis
+func (p *RedisPresence) Check() error {
+	_, err := p.client.do("PING")
+	return err
+}
+
+// NewRedisPresence returns RedisPresence pointer
+func NewRedisPresence(addr string) *RedisPresence {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return &RedisPresence{
+		Addr:   addr,
+		ID:     hex.EncodeToString(id),
+		client: &redisClient{addr: addr},
+		now:    time.Now,
+	}
+}
+
+// redisClient runs commands over single lazily established connection
+type redisClient struct {
+	addr string
+	conn *redisConn
+	mtx  sync.Mutex
+}
+
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn == nil {
+		conn, err := dialRedis(c.addr)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+
+	reply, err := c.conn.do(args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		// connection is broken, it will be established again by the next command
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	return reply, err
+}
+
+// redisError is error reply of the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn implements client side of Redis serialization protocol
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRedis(addr string) (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, redisTimeout)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to connect to redis")
+	}
+
+	return &redisConn{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+	}, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	if err := c.write(args...); err != nil {
+		return nil, err
+	}
+
+	return c.read()
+}
+
+// write method sends command as array of bulk strings
+func (c *redisConn) write(args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// read method reads single reply
+// returns string for simple strings, int64 for integers, []byte for bulk strings and []interface{} for arrays
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("Invalid redis reply")
+	}
+
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	}
+
+	return nil, errors.New("Invalid redis reply")
+}
+
+// bulk returns bulk string reply as bytes
+func bulk(reply interface{}) []byte {
+	b, _ := reply.([]byte)
+	return b
+}
@@ -0,0 +1,560 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/history"
+	"github.com/sc-chat/test-chat/pkg/server"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix starts names of environment variables overriding config keys,
+// e.g. CHAT_RATE_LIMITS_TOKEN_RATE overrides rate_limits.token_rate
+const EnvPrefix = "CHAT_"
+
+// EnvFile is environment variable with path of config file
+const EnvFile = EnvPrefix + "CONFIG"
+
+// EnvAdminToken is environment variable with admin token of chatctl, it is preferred over the flag
+// because command line arguments are visible to other users of the host
+const EnvAdminToken = EnvPrefix + "ADMIN_TOKEN"
+
+// Lookup returns value of environment variable, os.LookupEnv is used by default
+type Lookup func(key string) (string, bool)
+
+// TLS is certificate of the server
+type TLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	// ClientCA verifies optional client certificates, certificate with the client name as common name
+	// lets the session use privileged role of the client
+	ClientCA string `yaml:"client_ca"`
+}
+
+// Buffers are sizes of event queues
+type Buffers struct {
+	// Broadcast is number of events waiting for broadcast
+	Broadcast int `yaml:"broadcast"`
+	// Stream is number of events buffered for each client stream
+	Stream int `yaml:"stream"`
+}
+
+// Auth configures admin access and roles of clients
+type Auth struct {
+	AdminToken string `yaml:"admin_token"`
+	// Roles is roles file in JSON, all clients are users if empty
+	Roles string `yaml:"roles"`
+}
+
+// Storage configures shared and persistent state
+type Storage struct {
+	// Redis is address shared by server instances, single instance if empty
+	Redis string `yaml:"redis"`
+	// State is file keeping bans and mutes between restarts
+	State string `yaml:"state"`
+	// History is file of chat history in JSON lines, history is not kept if empty
+	History string `yaml:"history"`
+	// Files is directory of files uploaded by clients, files are not accepted if empty
+	Files string `yaml:"files"`
+}
+
+// Retention configures purging of chat history
+type Retention struct {
+	// Interval is period of compaction, history is not compacted if zero
+	Interval time.Duration           `yaml:"interval"`
+	Global   history.Rule            `yaml:"global"`
+	Rooms    map[string]history.Rule `yaml:"rooms"`
+	// Hold lists rooms under legal hold, given as room,room in environment
+	Hold []string `yaml:"hold"`
+}
+
+// Rules method returns retention rules of history
+func (r Retention) Rules() history.Retention {
+	return history.Retention{Global: r.Global, Rooms: r.Rooms, Hold: r.Hold}
+}
+
+// Log configures logger
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Moderation configures moderation pipeline
+type Moderation struct {
+	Rules string `yaml:"rules"`
+	Log   string `yaml:"log"`
+}
+
+// Audit configures audit log
+type Audit struct {
+	File     string `yaml:"file"`
+	MaxBytes int64  `yaml:"max_bytes"`
+	Keep     int    `yaml:"keep"`
+}
+
+// Metrics configures metrics endpoint
+type Metrics struct {
+	Addr string `yaml:"addr"`
+}
+
+// Trace configures exporters of trace spans
+type Trace struct {
+	File string `yaml:"file"`
+	OTLP string `yaml:"otlp"`
+}
+
+// IRC configures IRC gateway
+type IRC struct {
+	Addr string `yaml:"addr"`
+}
+
+// Federation configures links to other chat servers
+type Federation struct {
+	Addr string `yaml:"addr"`
+	Name string `yaml:"name"`
+	// Peers are given as name=address,name=address
+	Peers string `yaml:"peers"`
+	Cert  string `yaml:"cert"`
+	Key   string `yaml:"key"`
+	CA    string `yaml:"ca"`
+}
+
+// Server is configuration of chat server
+type Server struct {
+	Addr       string                 `yaml:"addr"`
+	TLS        TLS                    `yaml:"tls"`
+	Buffers    Buffers                `yaml:"buffers"`
+	Auth       Auth                   `yaml:"auth"`
+	Storage    Storage                `yaml:"storage"`
+	Retention  Retention              `yaml:"retention"`
+	FileQuota  blob.Quota             `yaml:"file_quota"`
+	RateLimits server.RateLimitConfig `yaml:"rate_limits"`
+	Limits     server.Limits          `yaml:"limits"`
+	Log        Log                    `yaml:"log"`
+	Moderation Moderation             `yaml:"moderation"`
+	Audit      Audit                  `yaml:"audit"`
+	Metrics    Metrics                `yaml:"metrics"`
+	Trace      Trace                  `yaml:"trace"`
+	IRC        IRC                    `yaml:"irc"`
+	Federation Federation             `yaml:"federation"`
+	Drain      time.Duration          `yaml:"drain"`
+}
+
+// DefaultServer function returns configuration of server started without config file
+func DefaultServer() Server {
+	return Server{
+		Addr: "0.0.0.0:8000",
+		Buffers: Buffers{
+			Broadcast: server.DefaultBroadcastBuffer,
+			Stream:    server.DefaultStreamBuffer,
+		},
+		RateLimits: server.DefaultRateLimits,
+		Limits:     server.DefaultLimits,
+		Log: Log{
+			Level:  "info",
+			Format: logger.FormatText,
+		},
+		Audit: Audit{
+			MaxBytes: 10 << 20,
+			Keep:     10,
+		},
+		Retention: Retention{
+			Interval: server.DefaultCompactInterval,
+		},
+		FileQuota: blob.Quota{
+			MaxFileBytes: 10 << 20,
+			MaxUserBytes: 100 << 20,
+		},
+		Drain: server.DefaultDrainTimeout,
+	}
+}
+
+// Validate method returns error naming the first invalid key
+func (s *Server) Validate() error {
+	switch {
+	case s.Addr == "":
+		return invalid("addr", "must not be empty")
+	case s.TLS.Cert != "" && s.TLS.Key == "":
+		return invalid("tls.key", "must be set with tls.cert")
+	case s.TLS.Key != "" && s.TLS.Cert == "":
+		return invalid("tls.cert", "must be set with tls.key")
+	case s.TLS.ClientCA != "" && s.TLS.Cert == "":
+		return invalid("tls.client_ca", "requires tls.cert")
+	case s.Buffers.Broadcast <= 0:
+		return invalid("buffers.broadcast", "must be positive")
+	case s.Buffers.Stream <= 0:
+		return invalid("buffers.stream", "must be positive")
+	case s.RateLimits.TokenRate < 0:
+		return invalid("rate_limits.token_rate", "must not be negative")
+	case s.RateLimits.TokenBurst < 0:
+		return invalid("rate_limits.token_burst", "must not be negative")
+	case s.RateLimits.NameRate < 0:
+		return invalid("rate_limits.name_rate", "must not be negative")
+	case s.RateLimits.NameBurst < 0:
+		return invalid("rate_limits.name_burst", "must not be negative")
+	case s.RateLimits.Violations < 0:
+		return invalid("rate_limits.violations", "must not be negative")
+	case s.RateLimits.MuteFor < 0:
+		return invalid("rate_limits.mute_for", "must not be negative")
+	case s.Limits.MaxMessageBytes <= 0:
+		return invalid("limits.max_message_bytes", "must be positive")
+	case s.Limits.MaxNameLength <= 0:
+		return invalid("limits.max_name_length", "must be positive")
+	case s.Audit.MaxBytes < 0:
+		return invalid("audit.max_bytes", "must not be negative")
+	case s.Audit.Keep < 0:
+		return invalid("audit.keep", "must not be negative")
+	case s.Drain < 0:
+		return invalid("drain", "must not be negative")
+	case s.Retention.Interval < 0:
+		return invalid("retention.interval", "must not be negative")
+	case s.FileQuota.MaxFileBytes < 0:
+		return invalid("file_quota.max_file_bytes", "must not be negative")
+	case s.FileQuota.MaxUserBytes < 0:
+		return invalid("file_quota.max_user_bytes", "must not be negative")
+	case s.FileQuota.MaxTotalBytes < 0:
+		return invalid("file_quota.max_total_bytes", "must not be negative")
+	}
+
+	if err := validateRule("retention.global", s.Retention.Global); err != nil {
+		return err
+	}
+
+	for room, rule := range s.Retention.Rooms {
+		if err := validateRule("retention.rooms."+room, rule); err != nil {
+			return err
+		}
+	}
+
+	if err := validateLog(s.Log); err != nil {
+		return err
+	}
+
+	if s.Federation.Addr != "" {
+		switch {
+		case s.Federation.Name == "":
+			return invalid("federation.name", "must be set with federation.addr")
+		case s.Federation.Cert == "":
+			return invalid("federation.cert", "must be set with federation.addr")
+		case s.Federation.Key == "":
+			return invalid("federation.key", "must be set with federation.addr")
+		case s.Federation.CA == "":
+			return invalid("federation.ca", "must be set with federation.addr")
+		}
+	}
+
+	return nil
+}
+
+// LoadTLS method returns TLS config of the server, nil if TLS is disabled
+func (s *Server) LoadTLS() (*tls.Config, error) {
+	if s.TLS.Cert == "" {
+		return nil, nil
+	}
+
+	cert, err := s.LoadCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{Certificates: []tls.Certificate{*cert}}
+
+	if s.TLS.ClientCA != "" {
+		if cfg.ClientCAs, err = loadPool(s.TLS.ClientCA); err != nil {
+			return nil, err
+		}
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return cfg, nil
+}
+
+// LoadCertificate method returns TLS certificate of the server, nil if TLS is disabled
+func (s *Server) LoadCertificate() (*tls.Certificate, error) {
+	if s.TLS.Cert == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(s.TLS.Cert, s.TLS.Key)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load certificate")
+	}
+
+	return &cert, nil
+}
+
+// ClientTLS is TLS of connection to the server
+type ClientTLS struct {
+	Enabled bool `yaml:"enabled"`
+	// CA verifies the server certificate, system roots are used if empty
+	CA         string `yaml:"ca"`
+	ServerName string `yaml:"server_name"`
+	// Cert and Key are client certificate proving the client name to the server
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// Client is configuration of chat client
+type Client struct {
+	Addr    string        `yaml:"addr"`
+	Name    string        `yaml:"name"`
+	TLS     ClientTLS     `yaml:"tls"`
+	Timeout time.Duration `yaml:"timeout"`
+	Log     Log           `yaml:"log"`
+	Color   bool          `yaml:"color"`
+	Trace   Trace         `yaml:"trace"`
+	// Downloads is directory of files downloaded by /get, working directory if empty
+	Downloads string `yaml:"downloads"`
+	// Key is file of X25519 key of the device for direct messages, created if it does not exist
+	// new key is generated for each run if empty
+	Key string `yaml:"key"`
+	// SigningKey is file of Ed25519 key signing messages, created if it does not exist
+	// new key is generated for each run if empty
+	SigningKey string `yaml:"signing_key"`
+	// Pins is file of signing keys of authors trusted on first use, keys are kept in memory if empty
+	Pins string `yaml:"pins"`
+	// DevicePins is file of device keys of clients trusted on first use, keys are kept in memory if empty
+	DevicePins string `yaml:"device_pins"`
+}
+
+// DefaultClient function returns configuration of client started without config file
+func DefaultClient() Client {
+	return Client{
+		Addr:    "0.0.0.0:8000",
+		Timeout: 500 * time.Millisecond,
+		Log: Log{
+			Level:  "info",
+			Format: logger.FormatText,
+		},
+	}
+}
+
+// Validate method returns error naming the first invalid key
+func (c *Client) Validate() error {
+	switch {
+	case c.Addr == "":
+		return invalid("addr", "must not be empty")
+	case c.Name == "":
+		return invalid("name", "must not be empty")
+	case c.Timeout <= 0:
+		return invalid("timeout", "must be positive")
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+
+	return validateLog(c.Log)
+}
+
+// LoadTLS method returns TLS config of the client, nil if TLS is disabled
+func (c *Client) LoadTLS() (*tls.Config, error) {
+	return c.TLS.Load()
+}
+
+// Validate method returns error naming the first invalid key
+func (t ClientTLS) Validate() error {
+	switch {
+	case t.CA != "" && !t.Enabled:
+		return invalid("tls.ca", "requires tls.enabled")
+	case t.Cert != "" && !t.Enabled:
+		return invalid("tls.cert", "requires tls.enabled")
+	case t.Cert != "" && t.Key == "":
+		return invalid("tls.key", "must be set with tls.cert")
+	case t.Key != "" && t.Cert == "":
+		return invalid("tls.cert", "must be set with tls.key")
+	}
+
+	return nil
+}
+
+// Load method returns TLS config of connection to the server, nil if TLS is disabled
+func (t ClientTLS) Load() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{ServerName: t.ServerName}
+
+	if t.CA != "" {
+		var err error
+		if cfg.RootCAs, err = loadPool(t.CA); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Failed to load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// loadPool function reads PEM file of CA certificates
+func loadPool(path string) (*x509.CertPool, error) {
+	ca, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load CA")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("Invalid CA certificate")
+	}
+
+	return pool, nil
+}
+
+// Load function reads YAML file into the config and then applies environment overrides,
+// file is skipped if the path is empty and unknown keys in the file are errors
+func Load(path string, v interface{}, lookup Lookup) error {
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.WithMessage(err, "Failed to read config")
+		}
+
+		if err := yaml.UnmarshalStrict(data, v); err != nil {
+			return errors.WithMessage(err, "Invalid config "+path)
+		}
+	}
+
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	return applyEnv(reflect.ValueOf(v).Elem(), "", lookup)
+}
+
+// applyEnv function sets fields of the struct from environment variables named after their yaml keys
+func applyEnv(v reflect.Value, prefix string, lookup Lookup) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := prefix + tag
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, key+".", lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		env := EnvName(key)
+		value, ok := lookup(env)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(field, value); err != nil {
+			return invalid(key, "invalid value of "+env+": "+err.Error())
+		}
+	}
+
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return errors.Errorf("unsupported type %s", field.Type())
+		}
+
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return errors.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// EnvName function returns environment variable overriding the config key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+func validateRule(key string, r history.Rule) error {
+	switch {
+	case r.MaxAge < 0:
+		return invalid(key+".max_age", "must not be negative")
+	case r.MaxCount < 0:
+		return invalid(key+".max_count", "must not be negative")
+	case r.MaxBytes < 0:
+		return invalid(key+".max_bytes", "must not be negative")
+	}
+
+	return nil
+}
+
+func validateLog(l Log) error {
+	if _, err := logger.ParseLevel(l.Level); err != nil {
+		return invalid("log.level", err.Error())
+	}
+
+	if l.Format != logger.FormatText && l.Format != logger.FormatJSON {
+		return invalid("log.format", "must be "+logger.FormatText+" or "+logger.FormatJSON)
+	}
+
+	return nil
+}
+
+func invalid(key, reason string) error {
+	return errors.Errorf("Invalid config %s: %s", key, reason)
+}
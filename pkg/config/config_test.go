@@ -0,0 +1,159 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/history"
+)
+
+func env(vars map[string]string) Lookup {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeConfig(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "chat.yaml")
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+addr: 127.0.0.1:9000
+buffers:
+  stream: 50
+rate_limits:
+  token_rate: 2
+  mute_for: 30s
+log:
+  level: debug
+retention:
+  global:
+    max_age: 720h
+  rooms:
+    support:
+      max_count: 100
+`)
+
+	cfg := DefaultServer()
+	err := Load(path, &cfg, env(map[string]string{
+		"CHAT_ADDR":                      "127.0.0.1:9001",
+		"CHAT_RATE_LIMITS_NAME_RATE":     "3.5",
+		"CHAT_AUTH_ADMIN_TOKEN":          "secret",
+		"CHAT_DRAIN":                     "1m",
+		"CHAT_RETENTION_HOLD":            "legal, audit",
+		"CHAT_FILE_QUOTA_MAX_USER_BYTES": "1024",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		key      string
+		expected interface{}
+		actual   interface{}
+	}{
+		{key: "addr", expected: "127.0.0.1:9001", actual: cfg.Addr},
+		{key: "buffers.stream", expected: 50, actual: cfg.Buffers.Stream},
+		{key: "buffers.broadcast", expected: 1000, actual: cfg.Buffers.Broadcast},
+		{key: "rate_limits.token_rate", expected: 2.0, actual: cfg.RateLimits.TokenRate},
+		{key: "rate_limits.name_rate", expected: 3.5, actual: cfg.RateLimits.NameRate},
+		{key: "rate_limits.mute_for", expected: 30 * time.Second, actual: cfg.RateLimits.MuteFor},
+		{key: "auth.admin_token", expected: "secret", actual: cfg.Auth.AdminToken},
+		{key: "log.level", expected: "debug", actual: cfg.Log.Level},
+		{key: "drain", expected: time.Minute, actual: cfg.Drain},
+		{key: "retention.interval", expected: time.Hour, actual: cfg.Retention.Interval},
+		{key: "retention.global.max_age", expected: 720 * time.Hour, actual: cfg.Retention.Global.MaxAge},
+		{key: "retention.rooms.support.max_count", expected: 100, actual: cfg.Retention.Rooms["support"].MaxCount},
+		{key: "retention.hold", expected: "legal,audit", actual: strings.Join(cfg.Retention.Hold, ",")},
+		{key: "file_quota.max_file_bytes", expected: int64(10 << 20), actual: cfg.FileQuota.MaxFileBytes},
+		{key: "file_quota.max_user_bytes", expected: int64(1024), actual: cfg.FileQuota.MaxUserBytes},
+	}
+
+	for _, tc := range cases {
+		if tc.expected != tc.actual {
+			t.Errorf("%s should be %v but got %v", tc.key, tc.expected, tc.actual)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		env  map[string]string
+		key  string
+	}{
+		{name: "unknown key", file: "adr: 127.0.0.1:9000\n", key: "adr"},
+		{name: "invalid env", env: map[string]string{"CHAT_BUFFERS_STREAM": "many"}, key: "CHAT_BUFFERS_STREAM"},
+		{name: "invalid duration", env: map[string]string{"CHAT_DRAIN": "10"}, key: "drain"},
+	}
+
+	for _, tc := range cases {
+		path := ""
+		if tc.file != "" {
+			path = writeConfig(t, tc.file)
+		}
+
+		cfg := DefaultServer()
+		err := Load(path, &cfg, env(tc.env))
+		if err == nil {
+			t.Errorf("Load should fail (%s)", tc.name)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), tc.key) {
+			t.Errorf("Error should name %s but got %s (%s)", tc.key, err, tc.name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		key    string
+		modify func(s *Server)
+	}{
+		{key: "addr", modify: func(s *Server) { s.Addr = "" }},
+		{key: "tls.key", modify: func(s *Server) { s.TLS.Cert = "cert.pem" }},
+		{key: "tls.client_ca", modify: func(s *Server) { s.TLS.ClientCA = "ca.pem" }},
+		{key: "buffers.broadcast", modify: func(s *Server) { s.Buffers.Broadcast = 0 }},
+		{key: "rate_limits.token_rate", modify: func(s *Server) { s.RateLimits.TokenRate = -1 }},
+		{key: "limits.max_name_length", modify: func(s *Server) { s.Limits.MaxNameLength = 0 }},
+		{key: "log.level", modify: func(s *Server) { s.Log.Level = "verbose" }},
+		{key: "log.format", modify: func(s *Server) { s.Log.Format = "xml" }},
+		{key: "federation.name", modify: func(s *Server) { s.Federation.Addr = "0.0.0.0:8001" }},
+		{key: "file_quota.max_total_bytes", modify: func(s *Server) { s.FileQuota.MaxTotalBytes = -1 }},
+		{key: "retention.global.max_age", modify: func(s *Server) { s.Retention.Global.MaxAge = -time.Hour }},
+		{key: "retention.rooms.support.max_bytes", modify: func(s *Server) {
+			s.Retention.Rooms = map[string]history.Rule{"support": {MaxBytes: -1}}
+		}},
+	}
+
+	for _, tc := range cases {
+		cfg := DefaultServer()
+		tc.modify(&cfg)
+
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), " "+tc.key+":") {
+			t.Errorf("Error should name %s but got %v", tc.key, err)
+		}
+	}
+
+	c := DefaultClient()
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), " name:") {
+		t.Errorf("Error should name name but got %v", err)
+	}
+}
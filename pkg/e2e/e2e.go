@@ -0,0 +1,158 @@
+package e2e
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+)
+
+// info binds derived keys to direct messages of the chat
+const info = "chat direct message v1"
+
+// ErrDecrypt is returned when ciphertext is not encrypted for the key or is changed
+var ErrDecrypt = errors.New("failed to decrypt message")
+
+// Key is X25519 key of a device
+type Key struct {
+	private *ecdh.PrivateKey
+}
+
+// Public method returns public key of the device published at login
+func (k *Key) Public() []byte {
+	return k.private.PublicKey().Bytes()
+}
+
+// Seal method encrypts direct message for the device of the peer
+// names of the sender and the recipient are authenticated with the message
+func (k *Key) Seal(peer []byte, from, to string, plaintext []byte) ([]byte, []byte, error) {
+	aead, err := k.aead(peer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	return nonce, aead.Seal(nil, nonce, plaintext, additional(from, to)), nil
+}
+
+// Open method decrypts direct message sent by the device of the peer
+func (k *Key) Open(peer []byte, from, to string, nonce, ciphertext []byte) ([]byte, error) {
+	aead, err := k.aead(peer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additional(from, to))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// aead method returns cipher keyed by shared secret of the device and the peer
+func (k *Key) aead(peer []byte) (cipher.AEAD, error) {
+	pub, err := ecdh.X25519().NewPublicKey(peer)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid public key")
+	}
+
+	secret, err := k.private.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	// both sides derive the same key, so public keys are ordered
+	own := k.Public()
+	keys := string(own) + string(peer)
+	if string(peer) < string(own) {
+		keys = string(peer) + string(own)
+	}
+
+	key, err := hkdf.Key(sha256.New, secret, []byte(keys), info, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func additional(from, to string) []byte {
+	return []byte(from + "\x00" + to)
+}
+
+// ValidKey function returns true if provided bytes are X25519 public key
+func ValidKey(pub []byte) bool {
+	_, err := ecdh.X25519().NewPublicKey(pub)
+	return err == nil
+}
+
+// DeviceID function returns short id of the device public key, first 8 bytes of its hash
+func DeviceID(pub []byte) string {
+	return sha256.NewHash(string(pub))[:16]
+}
+
+// Fingerprint function returns fingerprint of the public key compared by people to verify the device
+func Fingerprint(pub []byte) string {
+	return sha256.Fingerprint(pub)
+}
+
+// GenerateKey returns Key pointer with new random key
+func GenerateKey() (*Key, error) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{private: private}, nil
+}
+
+// LoadKey returns Key pointer with key read from the file
+// new key is generated and written to the file if it does not exist
+func LoadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		k, err := GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return k, ioutil.WriteFile(path, []byte(hex.EncodeToString(k.private.Bytes())+"\n"), 0600)
+	} else if err != nil {
+		return nil, err
+	}
+
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid key file")
+	}
+
+	private, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid key file")
+	}
+
+	return &Key{private: private}, nil
+}
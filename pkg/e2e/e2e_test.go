@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	alice, _ := GenerateKey()
+	bob, _ := GenerateKey()
+	eve, _ := GenerateKey()
+
+	nonce, ciphertext, err := alice.Seal(bob.Public(), "Alice", "Bob", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := bob.Open(alice.Public(), "Alice", "Bob", nonce, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plaintext) != "secret" {
+		t.Errorf("Message should be %s but got %s", "secret", plaintext)
+	}
+
+	changed := append([]byte{}, ciphertext...)
+	changed[0] ^= 1
+
+	cases := []struct {
+		name       string
+		key        *Key
+		peer       []byte
+		from       string
+		nonce      []byte
+		ciphertext []byte
+	}{
+		{name: "other recipient", key: eve, peer: alice.Public(), from: "Alice", nonce: nonce, ciphertext: ciphertext},
+		{name: "other sender key", key: bob, peer: eve.Public(), from: "Alice", nonce: nonce, ciphertext: ciphertext},
+		{name: "other sender name", key: bob, peer: alice.Public(), from: "Eve", nonce: nonce, ciphertext: ciphertext},
+		{name: "changed ciphertext", key: bob, peer: alice.Public(), from: "Alice", nonce: nonce, ciphertext: changed},
+		{name: "short nonce", key: bob, peer: alice.Public(), from: "Alice", nonce: nonce[:4], ciphertext: ciphertext},
+	}
+
+	for _, tc := range cases {
+		if _, err := tc.key.Open(tc.peer, tc.from, "Bob", tc.nonce, tc.ciphertext); err == nil {
+			t.Errorf("Open should fail (%s)", tc.name)
+		}
+	}
+
+	if _, _, err := alice.Seal([]byte("short"), "Alice", "Bob", nil); err == nil {
+		t.Error("Seal should fail for invalid public key")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "device.key")
+
+	k, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a, b := Fingerprint(k.Public()), Fingerprint(loaded.Public()); a != b {
+		t.Errorf("Fingerprint should be %s but got %s", a, b)
+	}
+
+	if !ValidKey(k.Public()) || ValidKey([]byte("short")) {
+		t.Error("Only X25519 public keys should be valid")
+	}
+
+	if id := DeviceID(k.Public()); len(id) != 16 {
+		t.Errorf("Device id should have %d digits but got %s", 16, id)
+	}
+}
@@ -0,0 +1,344 @@
+package federation
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NewFederation returns Federation pointer
+// remote clients become part of the chat server roster
+func NewFederation(name, addr string, cfg *tls.Config, peers map[string]string, chatServer *server.Server, allowDebug bool) (*Federation, error) {
+	if name == "" || strings.Contains(name, "@") {
+		return nil, errors.New("Invalid server name")
+	}
+
+	// basic server address validation
+	if addr == "" {
+		return nil, errors.New("Invalid address")
+	}
+
+	if cfg == nil {
+		return nil, errors.New("TLS config is required")
+	}
+
+	if chatServer == nil {
+		return nil, errors.New("Invalid chat server")
+	}
+
+	f := &Federation{
+		Name:   name,
+		Addr:   addr,
+		TLS:    cfg,
+		Peers:  peers,
+		Chat:   chatServer,
+		Logger: logger.NewLogger(allowDebug),
+
+		remote: make(map[string]map[string]bool),
+	}
+
+	chatServer.Rosters = append(chatServer.Rosters, f)
+
+	return f, nil
+}
+
+// Federation struct exchanges chat events with servers of other deployments
+// clients of remote servers are addressed as name@server
+type Federation struct {
+	Name   string
+	Addr   string
+	TLS    *tls.Config
+	Peers  map[string]string
+	Chat   *server.Server
+	Logger *logger.Logger
+
+	// remote contains online clients by server name
+	remote map[string]map[string]bool
+	mtx    sync.RWMutex
+}
+
+// Run method
+func (f *Federation) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := grpc.NewServer(grpc.Creds(serverCredentials(f.TLS)))
+	chat.RegisterFederationServer(srv, f)
+
+	l, err := net.Listen("tcp", f.Addr)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to start on provided address")
+	}
+
+	events, err := f.Chat.Bus.Subscribe(ctx)
+	if err != nil {
+		l.Close()
+		return errors.WithMessage(err, "Failed to subscribe to event bus")
+	}
+
+	var links []*link
+	for name, addr := range f.Peers {
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(clientCredentials(f.TLS, name)))
+		if err != nil {
+			l.Close()
+			return errors.WithMessage(err, "Failed to connect to peer "+name)
+		}
+		defer conn.Close()
+
+		lnk := newLink(f, name, chat.NewFederationClient(conn))
+		links = append(links, lnk)
+
+		go lnk.run(ctx)
+	}
+
+	f.Logger.Info("Federation is listening", "server", f.Name, "addr", f.Addr)
+
+	go func() {
+		for res := range events {
+			if local(res) {
+				for _, lnk := range links {
+					lnk.enqueue(res)
+				}
+			}
+		}
+	}()
+
+	go func() {
+		sErr := srv.Serve(l)
+		if sErr != nil {
+			f.Logger.Error("Federation serve error", "err", sErr)
+		}
+		cancel()
+	}()
+
+	<-ctx.Done()
+
+	f.Logger.Info("Federation is shutting down")
+
+	srv.GracefulStop()
+	return nil
+}
+
+// Push method receives events from peer server
+func (f *Federation) Push(ctx context.Context, req *chat.PushRequest) (*chat.PushResponse, error) {
+	origin, err := f.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Snapshot {
+		f.sync(origin, req.Online)
+	}
+
+	for _, res := range req.Events {
+		f.receive(origin, res)
+	}
+
+	return new(chat.PushResponse), nil
+}
+
+// Online method returns remote clients addressed as name@server
+func (f *Federation) Online() []string {
+	f.mtx.RLock()
+	var online []string
+	for origin, names := range f.remote {
+		for name := range names {
+			online = append(online, address(name, origin))
+		}
+	}
+	f.mtx.RUnlock()
+
+	sort.Strings(online)
+
+	return online
+}
+
+// authenticate method returns name of the peer which certificate is used for the connection
+func (f *Federation) authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "Unknown peer")
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "Client certificate is required")
+	}
+
+	cert := info.State.VerifiedChains[0][0]
+	for name := range f.Peers {
+		if cert.VerifyHostname(name) == nil {
+			return name, nil
+		}
+	}
+
+	return "", status.Error(codes.PermissionDenied, "Unknown peer certificate")
+}
+
+// sync method replaces presence of the peer clients with snapshot
+func (f *Federation) sync(origin string, online []string) {
+	snapshot := make(map[string]bool)
+	for _, name := range online {
+		if f.valid(origin, name) {
+			snapshot[name] = true
+		}
+	}
+
+	f.mtx.RLock()
+	var gone []string
+	for name := range f.remote[origin] {
+		if !snapshot[name] {
+			gone = append(gone, name)
+		}
+	}
+	f.mtx.RUnlock()
+
+	for _, name := range gone {
+		if f.setOnline(origin, name, false) {
+			f.broadcastLogout(address(name, origin))
+		}
+	}
+
+	for _, name := range online {
+		if snapshot[name] && f.setOnline(origin, name, true) {
+			f.broadcastLogin(address(name, origin))
+		}
+	}
+}
+
+// receive method spreads peer event to local clients
+func (f *Federation) receive(origin string, res *chat.ResponseStream) {
+	switch evt := res.Event.(type) {
+	case *chat.ResponseStream_ClientLogin:
+		if f.valid(origin, evt.ClientLogin.Name) && f.setOnline(origin, evt.ClientLogin.Name, true) {
+			f.broadcastLogin(address(evt.ClientLogin.Name, origin))
+		}
+	case *chat.ResponseStream_ClientLogout:
+		if f.setOnline(origin, evt.ClientLogout.Name, false) {
+			f.broadcastLogout(address(evt.ClientLogout.Name, origin))
+		}
+	case *chat.ResponseStream_ClientMessage:
+		if !f.valid(origin, evt.ClientMessage.Name) {
+			return
+		}
+
+		name := address(evt.ClientMessage.Name, origin)
+		f.Logger.Debug("Remote client has sent a message", "user", name, "message", evt.ClientMessage.Message)
+
+		// remote messages pass the same rate limits and checks as messages of local clients
+		// remote clients have no sessions, so the session limit is applied to the address too
+		if err := f.Chat.Throttle(name, name); err != nil {
+			f.Logger.Info("Message of remote client is throttled", "user", name, "err", err)
+			return
+		}
+
+		if err := f.Chat.Say(name, evt.ClientMessage.Message); err != nil {
+			f.Logger.Info("Message of remote client is rejected", "user", name, "err", err)
+		}
+	}
+}
+
+// valid method returns true if name of the peer client is normalized and satisfies the name policy
+func (f *Federation) valid(origin, name string) bool {
+	normalized, err := f.Chat.ValidateName(name)
+	if err == nil && normalized == name {
+		return true
+	}
+
+	f.Logger.Warn("Peer has sent invalid name", "peer", origin, "user", name, "err", err)
+
+	return false
+}
+
+// setOnline method updates presence of remote client, returns true if it was changed
+func (f *Federation) setOnline(origin, name string, online bool) bool {
+	if name == "" || strings.Contains(name, "@") {
+		return false
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	names, ok := f.remote[origin]
+	if !ok {
+		names = make(map[string]bool)
+		f.remote[origin] = names
+	}
+
+	if names[name] == online {
+		return false
+	}
+
+	if online {
+		names[name] = true
+	} else {
+		delete(names, name)
+	}
+
+	return true
+}
+
+func (f *Federation) broadcastLogin(name string) {
+	f.Logger.Debug("Remote client is online", "user", name)
+
+	f.Chat.Publish(chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ClientLogin{
+			ClientLogin: &chat.ResponseStream_Login{
+				Name: name,
+			},
+		},
+	})
+}
+
+func (f *Federation) broadcastLogout(name string) {
+	f.Logger.Debug("Remote client is offline", "user", name)
+
+	f.Chat.Publish(chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ClientLogout{
+			ClientLogout: &chat.ResponseStream_Logout{
+				Name: name,
+			},
+		},
+	})
+}
+
+// local returns true if event is produced by client of this deployment
+func local(res chat.ResponseStream) bool {
+	var name string
+
+	switch evt := res.Event.(type) {
+	case *chat.ResponseStream_ClientLogin:
+		name = evt.ClientLogin.Name
+	case *chat.ResponseStream_ClientLogout:
+		name = evt.ClientLogout.Name
+	case *chat.ResponseStream_ClientMessage:
+		name = evt.ClientMessage.Name
+	default:
+		return false
+	}
+
+	return !strings.Contains(name, "@")
+}
+
+// address returns name of remote client
+func address(name, origin string) string {
+	return name + "@" + origin
+}
@@ -0,0 +1,366 @@
+package federation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCA{cert: cert, key: key, pem: pemBlock("CERTIFICATE", der)}
+}
+
+// files method writes certificate of the peer and CA, returns their paths
+func (ca *testCA) files(t *testing.T, dir, name string) (string, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ioutil.WriteFile(certFile, pemBlock("CERTIFICATE", der), 0600)
+	ioutil.WriteFile(keyFile, pemBlock("EC PRIVATE KEY", keyDer), 0600)
+	ioutil.WriteFile(caFile, ca.pem, 0600)
+
+	return certFile, keyFile, caFile
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func runChat(t *testing.T, ctx context.Context) *server.Server {
+	s, err := server.NewServer(freeAddr(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Run(ctx)
+
+	return s
+}
+
+func loadTLS(t *testing.T, ca *testCA, dir, name string) *tls.Config {
+	cfg, err := LoadTLS(ca.files(t, dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func waitEvent(t *testing.T, events <-chan chat.ResponseStream, match func(res chat.ResponseStream) bool) {
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case res := <-events:
+			if match(res) {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timeout while waiting for event")
+		}
+	}
+}
+
+func TestFederation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "federation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t)
+
+	chatA, chatB := runChat(t, ctx), runChat(t, ctx)
+	addrA, addrB := freeAddr(t), freeAddr(t)
+
+	fedA, err := NewFederation("a", addrA, loadTLS(t, ca, dir, "a"), map[string]string{"b": addrB}, chatA, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fedB, err := NewFederation("b", addrB, loadTLS(t, ca, dir, "b"), map[string]string{"a": addrA}, chatB, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// peer b is down, events are queued
+	go fedA.Run(ctx)
+
+	time.Sleep(100 * time.Millisecond)
+	chatA.Join("Alice")
+	chatA.Say("Alice", "hi")
+
+	eventsB, _ := chatB.Bus.Subscribe(ctx)
+	eventsA, _ := chatA.Bus.Subscribe(ctx)
+
+	go fedB.Run(ctx)
+
+	waitEvent(t, eventsB, func(res chat.ResponseStream) bool {
+		login := res.GetClientLogin()
+		return login != nil && login.Name == "Alice@a"
+	})
+
+	waitEvent(t, eventsB, func(res chat.ResponseStream) bool {
+		msg := res.GetClientMessage()
+		return msg != nil && msg.Name == "Alice@a" && msg.Message == "hi"
+	})
+
+	online := chatB.Online()
+	if len(online) != 1 || online[0] != "Alice@a" {
+		t.Errorf("Online should be [Alice@a] but got %v", online)
+	}
+
+	chatB.Join("Bob")
+
+	waitEvent(t, eventsA, func(res chat.ResponseStream) bool {
+		login := res.GetClientLogin()
+		return login != nil && login.Name == "Bob@b"
+	})
+
+	// unknown peer is rejected
+	cfg := loadTLS(t, ca, dir, "c")
+	conn, err := grpc.Dial(addrB, grpc.WithTransportCredentials(clientCredentials(cfg, "b")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pushCtx, pushCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer pushCancel()
+
+	_, err = chat.NewFederationClient(conn).Push(pushCtx, new(chat.PushRequest), grpc.FailFast(false))
+	if s, _ := status.FromError(err); s.Code() != codes.PermissionDenied {
+		t.Errorf("Error should be %s but got %v", codes.PermissionDenied, err)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	s, _ := server.NewServer("example:8000", false)
+	s.Restrictions.Mute("Mallory@b", time.Hour)
+
+	f, err := NewFederation("a", "example:8001", new(tls.Config), map[string]string{"b": "example:8002"}, s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := func(name, text string) *chat.ResponseStream {
+		return &chat.ResponseStream{
+			Event: &chat.ResponseStream_ClientMessage{
+				ClientMessage: &chat.ResponseStream_Message{Name: name, Message: text},
+			},
+		}
+	}
+
+	cases := []struct {
+		res  *chat.ResponseStream
+		name string
+	}{
+		{res: message("Bob", "hi"), name: "Bob@b"},
+		{res: message("Bob", "\x1b[2Jhi"), name: ""},
+		{res: message("Bob\r\nQUIT", "hi"), name: ""},
+		{res: message("Eve@c", "hi"), name: ""},
+		{res: message("Mallory", "hi"), name: ""},
+		{
+			res:  &chat.ResponseStream{Event: &chat.ResponseStream_ClientLogin{ClientLogin: &chat.ResponseStream_Login{Name: "Bob"}}},
+			name: "Bob@b",
+		},
+		{
+			res:  &chat.ResponseStream{Event: &chat.ResponseStream_ClientLogin{ClientLogin: &chat.ResponseStream_Login{Name: "Bob Dylan"}}},
+			name: "",
+		},
+	}
+
+	for _, tc := range cases {
+		f.receive("b", tc.res)
+
+		var name string
+		select {
+		case res := <-s.Broadcast:
+			if msg := res.GetClientMessage(); msg != nil {
+				name = msg.Name
+			}
+			if login := res.GetClientLogin(); login != nil {
+				name = login.Name
+			}
+		default:
+		}
+
+		if tc.name != name {
+			t.Errorf("Name of published event should be %q but got %q (%v)", tc.name, name, tc.res)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	s, _ := server.NewServer("example:8000", false)
+
+	f, err := NewFederation("a", "example:8001", new(tls.Config), map[string]string{"b": "example:8002"}, s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.sync("b", []string{"Bob", "Bob\r\nQUIT", "Bob Dylan", "\x1b[2JEve"})
+
+	var names []string
+	for len(s.Broadcast) > 0 {
+		res := <-s.Broadcast
+		if login := res.GetClientLogin(); login != nil {
+			names = append(names, login.Name)
+		}
+	}
+
+	if expected := []string{"Bob@b"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("Logins should be %q but got %q", expected, names)
+	}
+}
+
+func TestReceiveThrottle(t *testing.T) {
+	s, _ := server.NewServer("example:8000", false)
+	s.RateLimits = server.NewRateLimits(server.RateLimitConfig{TokenRate: 0.001, TokenBurst: 2})
+
+	f, err := NewFederation("a", "example:8001", new(tls.Config), map[string]string{"b": "example:8002"}, s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		f.receive("b", &chat.ResponseStream{
+			Event: &chat.ResponseStream_ClientMessage{
+				ClientMessage: &chat.ResponseStream_Message{Name: "Bob", Message: "hi"},
+			},
+		})
+	}
+
+	if n := len(s.Broadcast); n != 2 {
+		t.Errorf("Published messages of remote client should be %d but got %d", 2, n)
+	}
+}
+
+func TestParsePeers(t *testing.T) {
+	cases := []struct {
+		value string
+		peers map[string]string
+		ok    bool
+	}{
+		{
+			value: "",
+			peers: map[string]string{},
+			ok:    true,
+		},
+		{
+			value: "a=127.0.0.1:9000, b=example.com:9000",
+			peers: map[string]string{"a": "127.0.0.1:9000", "b": "example.com:9000"},
+			ok:    true,
+		},
+		{
+			value: "a",
+			ok:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		peers, err := ParsePeers(tc.value)
+
+		if tc.ok != (err == nil) {
+			t.Errorf("Ok should be %t but got %v (%+v)", tc.ok, err, tc)
+			continue
+		}
+
+		if len(tc.peers) != len(peers) {
+			t.Errorf("Peers should be %v but got %v", tc.peers, peers)
+		}
+
+		for name, addr := range tc.peers {
+			if peers[name] != addr {
+				t.Errorf("Peer %s should be %s but got %s", name, addr, peers[name])
+			}
+		}
+	}
+}
+
+func pemBlock(kind string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+}
@@ -0,0 +1,128 @@
+package federation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+const (
+	// queueSize limits events kept for unavailable peer, the oldest events are dropped
+	queueSize = 10000
+
+	// batchSize limits events sent by single push
+	batchSize = 100
+
+	retryInterval = time.Second
+	pushTimeout   = 5 * time.Second
+)
+
+// link delivers events of local clients to single peer
+// events are queued while the peer is unavailable
+type link struct {
+	fed    *Federation
+	name   string
+	client chat.FederationClient
+
+	queue []*chat.ResponseStream
+	// synced is false until presence snapshot is delivered after connection is (re)established
+	synced bool
+	// dropped counts events removed from the full queue
+	dropped int
+	notify  chan struct{}
+	mtx     sync.Mutex
+}
+
+func newLink(fed *Federation, name string, client chat.FederationClient) *link {
+	return &link{
+		fed:    fed,
+		name:   name,
+		client: client,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// enqueue method adds event to the queue
+func (l *link) enqueue(res chat.ResponseStream) {
+	l.mtx.Lock()
+	if len(l.queue) >= queueSize {
+		l.fed.Logger.Warn("Queue of peer is full, dropping event", "peer", l.name)
+		l.queue = l.queue[1:]
+		l.dropped++
+	}
+	l.queue = append(l.queue, &res)
+	l.mtx.Unlock()
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run method delivers queued events until ctx is done
+func (l *link) run(ctx context.Context) {
+	for {
+		l.flush(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.notify:
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// flush method pushes queued events while the peer is available
+func (l *link) flush(ctx context.Context) {
+	for {
+		l.mtx.Lock()
+		n := len(l.queue)
+		if n > batchSize {
+			n = batchSize
+		}
+		events := l.queue[:n:n]
+		synced := l.synced
+		dropped := l.dropped
+		l.mtx.Unlock()
+
+		if synced && n == 0 {
+			return
+		}
+
+		req := &chat.PushRequest{Events: events}
+		if !synced {
+			req.Snapshot = true
+			req.Online = l.fed.Chat.Clients.Online()
+		}
+
+		pushCtx, cancel := context.WithTimeout(ctx, pushTimeout)
+		_, err := l.client.Push(pushCtx, req)
+		cancel()
+
+		if err != nil {
+			if synced {
+				l.fed.Logger.Debug("Peer is unavailable", "peer", l.name, "err", err)
+			}
+
+			l.mtx.Lock()
+			l.synced = false
+			l.mtx.Unlock()
+			return
+		}
+
+		if !synced {
+			l.fed.Logger.Info("Peer is connected", "peer", l.name)
+		}
+
+		l.mtx.Lock()
+		// events dropped during the push are already removed from the queue
+		if k := n - (l.dropped - dropped); k > 0 {
+			l.queue = l.queue[k:]
+		}
+		l.synced = true
+		l.mtx.Unlock()
+	}
+}
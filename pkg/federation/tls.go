@@ -0,0 +1,72 @@
+package federation
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// LoadTLS returns TLS config with server certificate and CA which signs certificates of all peers
+func LoadTLS(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load certificate")
+	}
+
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to load CA")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("Invalid CA certificate")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+	}, nil
+}
+
+// ParsePeers parses peers list in name=address,name=address format
+func ParsePeers(s string) (map[string]string, error) {
+	peers := make(map[string]string)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("Invalid peer %q", item)
+		}
+
+		peers[parts[0]] = parts[1]
+	}
+
+	return peers, nil
+}
+
+// serverCredentials returns credentials which require client certificate signed by CA
+func serverCredentials(cfg *tls.Config) credentials.TransportCredentials {
+	cfg = cfg.Clone()
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+
+	return credentials.NewTLS(cfg)
+}
+
+// clientCredentials returns credentials which verify that the peer certificate is issued for the peer name
+func clientCredentials(cfg *tls.Config, peer string) credentials.TransportCredentials {
+	cfg = cfg.Clone()
+	cfg.ServerName = peer
+
+	return credentials.NewTLS(cfg)
+}
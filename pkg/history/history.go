@@ -0,0 +1,345 @@
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+// record types
+const (
+	Login   = "login"
+	Logout  = "logout"
+	Message = "message"
+)
+
+// DefaultLimit is number of messages returned by search without limit
+const DefaultLimit = 20
+
+// Record is chat event kept in history
+type Record struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Room    string    `json:"room"`
+	Type    string    `json:"type"`
+	Name    string    `json:"name"`
+	Message string    `json:"message,omitempty"`
+
+	// Attachments and signature of the message are kept so that exported history is imported without loss
+	Attachments []*chat.Attachment `json:"attachments,omitempty"`
+	Signature   []byte             `json:"signature,omitempty"`
+	SigningKey  []byte             `json:"signing_key,omitempty"`
+}
+
+// Event method returns chat event of the record
+func (r Record) Event() chat.ResponseStream {
+	ts, _ := ptypes.TimestampProto(r.Time)
+	res := chat.ResponseStream{Id: r.ID, Timestamp: ts}
+
+	switch r.Type {
+	case Login:
+		res.Event = &chat.ResponseStream_ClientLogin{ClientLogin: &chat.ResponseStream_Login{Name: r.Name}}
+	case Logout:
+		res.Event = &chat.ResponseStream_ClientLogout{ClientLogout: &chat.ResponseStream_Logout{Name: r.Name}}
+	default:
+		res.Event = &chat.ResponseStream_ClientMessage{
+			ClientMessage: &chat.ResponseStream_Message{
+				Name:        r.Name,
+				Message:     r.Message,
+				Attachments: r.Attachments,
+				Signature:   r.Signature,
+				SigningKey:  r.SigningKey,
+			},
+		}
+	}
+
+	return res
+}
+
+// NewRecord function returns record of chat event in the room, false if the event is not kept in history
+// returns error if the event has no valid timestamp
+func NewRecord(res chat.ResponseStream, room string) (Record, bool, error) {
+	r := Record{ID: res.Id, Room: room}
+	if r.ID == "" {
+		return r, false, nil
+	}
+
+	switch event := res.Event.(type) {
+	case *chat.ResponseStream_ClientLogin:
+		r.Type, r.Name = Login, event.ClientLogin.Name
+	case *chat.ResponseStream_ClientLogout:
+		r.Type, r.Name = Logout, event.ClientLogout.Name
+	case *chat.ResponseStream_ClientMessage:
+		msg := event.ClientMessage
+		r.Type, r.Name, r.Message = Message, msg.Name, msg.Message
+		r.Attachments, r.Signature, r.SigningKey = msg.Attachments, msg.Signature, msg.SigningKey
+	default:
+		return r, false, nil
+	}
+
+	var err error
+	if r.Time, err = ptypes.Timestamp(res.Timestamp); err != nil {
+		return r, false, errors.WithMessage(err, "invalid timestamp")
+	}
+
+	return r, true, nil
+}
+
+// NewID function returns unique id of event, ids of later events sort after earlier ones
+func NewID(t time.Time) string {
+	var id [12]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixNano()))
+	rand.Read(id[8:])
+
+	return hex.EncodeToString(id[:])
+}
+
+// Query describes searched messages, empty fields match all messages
+type Query struct {
+	Terms  string
+	Author string
+	Room   string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+// match method returns true if the record passes filters of the query
+func (q Query) match(r Record) bool {
+	switch {
+	case r.Type != Message:
+		return false
+	case q.Author != "" && fold(q.Author) != fold(r.Name):
+		return false
+	case q.Room != "" && q.Room != r.Room:
+		return false
+	case !q.Since.IsZero() && r.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !r.Time.Before(q.Until):
+		return false
+	}
+
+	return true
+}
+
+// Store keeps chat history ordered by time with search index of messages
+// records are appended to JSON lines file if the store is opened from file
+type Store struct {
+	records   []Record
+	byID      map[string]Record
+	index     *Index
+	file      *os.File
+	retention Retention
+	mtx       sync.RWMutex
+
+	// records added while compaction rewrites history file, nil if compaction is not running
+	added      []Record
+	compactMtx sync.Mutex
+}
+
+// Add method adds record to history, returns false if record with the same id is already kept
+func (s *Store) Add(r Record) (bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.byID[r.ID]; ok {
+		return false, nil
+	}
+
+	if s.file != nil {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := s.file.Write(append(data, '\n')); err != nil {
+			return false, errors.WithMessage(err, "failed to write history")
+		}
+	}
+
+	s.insert(r)
+	if s.added != nil {
+		s.added = append(s.added, r)
+	}
+
+	return true, nil
+}
+
+// insert method keeps records ordered by time, imported and federated records can be older than the last one
+func (s *Store) insert(r Record) {
+	i := sort.Search(len(s.records), func(i int) bool {
+		return s.records[i].Time.After(r.Time)
+	})
+
+	s.records = append(s.records, Record{})
+	copy(s.records[i+1:], s.records[i:])
+	s.records[i] = r
+
+	s.byID[r.ID] = r
+	if r.Type == Message {
+		s.index.Add(r.ID, r.Message)
+	}
+}
+
+// Search method returns messages matching the query, newest first
+func (s *Store) Search(q Query) []Record {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+
+	terms := Tokenize(q.Terms)
+	if len(terms) == 0 {
+		return s.scan(q)
+	}
+
+	var res []Record
+	for id := range s.index.Lookup(terms) {
+		if r := s.byID[id]; q.match(r) {
+			res = append(res, r)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time.After(res[j].Time)
+	})
+
+	if len(res) > q.Limit {
+		res = res[:q.Limit]
+	}
+
+	return res
+}
+
+// scan method returns records matching filters of the query from the newest one
+func (s *Store) scan(q Query) []Record {
+	var res []Record
+	for i := len(s.records) - 1; i >= 0 && len(res) < q.Limit; i-- {
+		if q.match(s.records[i]) {
+			res = append(res, s.records[i])
+		}
+	}
+
+	return res
+}
+
+// Range method returns records of all types from the time in the room ordered by time
+// records of all rooms are returned if room is empty
+func (s *Store) Range(since time.Time, room string) []Record {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	i := sort.Search(len(s.records), func(i int) bool {
+		return !s.records[i].Time.Before(since)
+	})
+
+	var res []Record
+	for _, r := range s.records[i:] {
+		if room == "" || r.Room == room {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
+
+// Len method returns number of kept records
+func (s *Store) Len() int {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return len(s.records)
+}
+
+// Check method returns error if history file is gone or its directory is not writable
+// compaction creates new file in the directory of the history file
+func (s *Store) Check() error {
+	s.mtx.RLock()
+	file := s.file
+	s.mtx.RUnlock()
+
+	if file == nil {
+		return nil
+	}
+
+	if _, err := os.Stat(file.Name()); err != nil {
+		return errors.WithMessage(err, "history file is unavailable")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file.Name()), ".check")
+	if err != nil {
+		return errors.WithMessage(err, "history directory is not writable")
+	}
+	tmp.Close()
+
+	return os.Remove(tmp.Name())
+}
+
+// Close method closes history file
+func (s *Store) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	return s.file.Close()
+}
+
+// NewStore returns Store pointer which keeps history in memory only
+func NewStore() *Store {
+	return &Store{
+		byID:  make(map[string]Record),
+		index: NewIndex(),
+	}
+}
+
+// OpenStore returns Store pointer with history read from JSON lines file, new records are appended to the file
+func OpenStore(path string) (*Store, error) {
+	s := NewStore()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open history")
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+
+	for line := 1; sc.Scan(); line++ {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			f.Close()
+			return nil, errors.Wrapf(err, "invalid history record at line %d", line)
+		}
+
+		if _, ok := s.byID[r.ID]; !ok {
+			s.insert(r)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, errors.WithMessage(err, "failed to read history")
+	}
+
+	s.file = f
+
+	return s, nil
+}
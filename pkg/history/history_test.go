@@ -0,0 +1,143 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "Hello, World!", expected: []string{"hello", "world"}},
+		{text: "STRASSE straße", expected: []string{"strasse", "strasse"}},
+		{text: "Привет, МИР", expected: []string{"привет", "мир"}},
+		{text: "ＦＵＬＬ width", expected: []string{"full", "width"}},
+		{text: "café", expected: []string{"café"}},
+		{text: "--- ...", expected: []string{}},
+	}
+
+	for _, tc := range cases {
+		if terms := Tokenize(tc.text); !reflect.DeepEqual(tc.expected, terms) {
+			t.Errorf("Terms of %q should be %q but got %q", tc.text, tc.expected, terms)
+		}
+	}
+}
+
+func TestStoreSearch(t *testing.T) {
+	s := NewStore()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{ID: "1", Time: start, Room: "chat", Type: Login, Name: "Alice"},
+		{ID: "2", Time: start.Add(time.Minute), Room: "chat", Type: Message, Name: "Alice", Message: "Deploy is done"},
+		{ID: "3", Time: start.Add(2 * time.Minute), Room: "chat", Type: Message, Name: "Bob", Message: "deploy failed again"},
+		{ID: "4", Time: start.Add(3 * time.Minute), Room: "ops", Type: Message, Name: "Bob", Message: "DEPLOY is done"},
+		{ID: "5", Time: start.Add(30 * time.Second), Room: "chat", Type: Message, Name: "Carol", Message: "who deploys?"},
+	}
+
+	for _, r := range records {
+		if ok, err := s.Add(r); !ok || err != nil {
+			t.Fatalf("Record %s should be added but got %t, %v", r.ID, ok, err)
+		}
+	}
+
+	if ok, _ := s.Add(records[1]); ok {
+		t.Error("Record with the same id should not be added")
+	}
+
+	cases := []struct {
+		name     string
+		query    Query
+		expected []string
+	}{
+		{name: "terms", query: Query{Terms: "deploy"}, expected: []string{"4", "3", "2"}},
+		{name: "all terms", query: Query{Terms: "deploy done"}, expected: []string{"4", "2"}},
+		{name: "author", query: Query{Terms: "deploy", Author: "bob"}, expected: []string{"4", "3"}},
+		{name: "room", query: Query{Terms: "deploy", Room: "chat"}, expected: []string{"3", "2"}},
+		{name: "time range", query: Query{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, expected: []string{"3", "2"}},
+		{name: "filters only", query: Query{Author: "Carol"}, expected: []string{"5"}},
+		{name: "limit", query: Query{Limit: 2}, expected: []string{"4", "3"}},
+		{name: "not found", query: Query{Terms: "rollback"}, expected: nil},
+	}
+
+	for _, tc := range cases {
+		var ids []string
+		for _, r := range s.Search(tc.query) {
+			ids = append(ids, r.ID)
+		}
+
+		if !reflect.DeepEqual(tc.expected, ids) {
+			t.Errorf("Results should be %v but got %v (%s)", tc.expected, ids, tc.name)
+		}
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := Record{ID: NewID(time.Now()), Time: time.Now().UTC(), Room: "chat", Type: Message, Name: "Alice", Message: "kept"}
+	if _, err := s.Add(r); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	res := s.Search(Query{Terms: "KEPT"})
+	if len(res) != 1 || res[0].ID != r.ID {
+		t.Errorf("Record %s should be found after reopening but got %v", r.ID, res)
+	}
+
+	if ok, _ := s.Add(r); ok {
+		t.Error("Record read from file should not be added again")
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	r := Record{ID: "1", Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Room: "chat", Type: Logout, Name: "Bob"}
+
+	res := r.Event()
+	if name := res.GetClientLogout().GetName(); name != "Bob" {
+		t.Errorf("Name should be %s but got %s", "Bob", name)
+	}
+
+	back, ok, err := NewRecord(res, "chat")
+	if err != nil || !ok || !reflect.DeepEqual(back, r) {
+		t.Errorf("Record should be %+v but got %+v (%v)", r, back, err)
+	}
+
+	// attachments and signature of messages are kept
+	r = Record{
+		ID: "2", Time: r.Time, Room: "chat", Type: Message, Name: "Bob", Message: "report",
+		Attachments: []*chat.Attachment{{Id: "f1", Name: "report.pdf", Size: 1024, Sha256: "00"}},
+		Signature:   []byte{1, 2, 3},
+		SigningKey:  []byte{4, 5, 6},
+	}
+
+	back, ok, err = NewRecord(r.Event(), "chat")
+	if err != nil || !ok || !reflect.DeepEqual(back, r) {
+		t.Errorf("Record should be %+v but got %+v (%v)", r, back, err)
+	}
+
+	// event without timestamp is rejected instead of being kept at 1970
+	res = r.Event()
+	res.Timestamp = nil
+	if _, ok, err := NewRecord(res, "chat"); err == nil || ok {
+		t.Errorf("Event without timestamp should be rejected but got %t, %v", ok, err)
+	}
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tokenize function splits text into search terms
+// text is normalized to NFKC and case folded, terms are sequences of letters, digits and marks
+func Tokenize(text string) []string {
+	text = fold(norm.NFKC.String(text))
+
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+// fold function folds case of text independently of language, e.g. "Straße" and "STRASSE" are the same
+// caser is created for each call because it is not safe for concurrent use
+func fold(text string) string {
+	return cases.Fold().String(text)
+}
+
+// Index is inverted index of message terms
+type Index struct {
+	postings map[string]map[string]struct{}
+}
+
+// Add method indexes terms of the message with the id
+func (i *Index) Add(id, text string) {
+	for _, term := range Tokenize(text) {
+		ids, ok := i.postings[term]
+		if !ok {
+			ids = make(map[string]struct{})
+			i.postings[term] = ids
+		}
+
+		ids[id] = struct{}{}
+	}
+}
+
+// Remove method removes terms of the message with the id
+func (i *Index) Remove(id, text string) {
+	for _, term := range Tokenize(text) {
+		delete(i.postings[term], id)
+
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+}
+
+// Lookup method returns ids of messages containing all terms
+func (i *Index) Lookup(terms []string) map[string]struct{} {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	// intersection starts from the rarest term
+	smallest := i.postings[terms[0]]
+	for _, term := range terms[1:] {
+		if len(i.postings[term]) < len(smallest) {
+			smallest = i.postings[term]
+		}
+	}
+
+	res := make(map[string]struct{})
+
+next:
+	for id := range smallest {
+		for _, term := range terms {
+			if _, ok := i.postings[term][id]; !ok {
+				continue next
+			}
+		}
+
+		res[id] = struct{}{}
+	}
+
+	return res
+}
+
+// Terms method returns number of indexed terms
+func (i *Index) Terms() int {
+	return len(i.postings)
+}
+
+// NewIndex returns Index pointer
+func NewIndex() *Index {
+	return &Index{postings: make(map[string]map[string]struct{})}
+}
@@ -0,0 +1,220 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Rule limits records of a room, zero values are not limited
+type Rule struct {
+	MaxAge   time.Duration `yaml:"max_age"`
+	MaxCount int           `yaml:"max_count"`
+	MaxBytes int64         `yaml:"max_bytes"`
+}
+
+// limited method returns true if the rule limits anything
+func (r Rule) limited() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0 || r.MaxBytes > 0
+}
+
+// Retention defines which records are purged by compaction
+type Retention struct {
+	// Global applies to rooms without their own rule
+	Global Rule `yaml:"global"`
+	// Rooms replace global rule for the room
+	Rooms map[string]Rule `yaml:"rooms"`
+	// Hold lists rooms under legal hold which are never purged
+	Hold []string `yaml:"hold"`
+}
+
+// Rule method returns rule of the room, false if records of the room are not purged
+func (r Retention) Rule(room string) (Rule, bool) {
+	for _, held := range r.Hold {
+		if held == room {
+			return Rule{}, false
+		}
+	}
+
+	rule, ok := r.Rooms[room]
+	if !ok {
+		rule = r.Global
+	}
+
+	return rule, rule.limited()
+}
+
+// expired method returns ids of records exceeding rules of their rooms, records are ordered by time
+func (r Retention) expired(records []Record, now time.Time) map[string]bool {
+	type usage struct {
+		count int
+		bytes int64
+	}
+
+	used := make(map[string]*usage)
+	expired := make(map[string]bool)
+
+	// newest records are kept, so limits are counted from the end
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+
+		rule, ok := r.Rule(rec.Room)
+		if !ok {
+			continue
+		}
+
+		u, ok := used[rec.Room]
+		if !ok {
+			u = new(usage)
+			used[rec.Room] = u
+		}
+
+		u.count++
+		u.bytes += rec.size()
+
+		if (rule.MaxAge > 0 && now.Sub(rec.Time) > rule.MaxAge) ||
+			(rule.MaxCount > 0 && u.count > rule.MaxCount) ||
+			(rule.MaxBytes > 0 && u.bytes > rule.MaxBytes) {
+			expired[rec.ID] = true
+		}
+	}
+
+	return expired
+}
+
+// size method returns size of the record in history file
+func (r Record) size() int64 {
+	data, _ := json.Marshal(r)
+	return int64(len(data)) + 1
+}
+
+// SetRetention method replaces retention rules applied by compaction
+func (s *Store) SetRetention(r Retention) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.retention = r
+}
+
+// Compact method purges records exceeding retention rules and rewrites history file
+// kept records are written to temporary file without blocking Add, records are purged only after the file is replaced
+// returns number of purged records
+func (s *Store) Compact(now time.Time) (int, error) {
+	s.compactMtx.Lock()
+	defer s.compactMtx.Unlock()
+
+	s.mtx.Lock()
+	records := append([]Record(nil), s.records...)
+	retention := s.retention
+	file := s.file
+	if file != nil {
+		s.added = []Record{}
+	}
+	s.mtx.Unlock()
+
+	expired := retention.expired(records, now)
+	if len(expired) == 0 {
+		s.stopAdded()
+		return 0, nil
+	}
+
+	var tmp *os.File
+	if file != nil {
+		kept := make([]Record, 0, len(records)-len(expired))
+		for _, r := range records {
+			if !expired[r.ID] {
+				kept = append(kept, r)
+			}
+		}
+
+		var err error
+		if tmp, err = writeTemp(file.Name(), kept); err != nil {
+			s.stopAdded()
+			return 0, errors.WithMessage(err, "failed to compact history")
+		}
+		defer os.Remove(tmp.Name())
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if tmp != nil {
+		added := s.added
+		s.added = nil
+
+		if err := s.replace(tmp, added); err != nil {
+			return 0, errors.WithMessage(err, "failed to compact history")
+		}
+	}
+
+	kept := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		if !expired[r.ID] {
+			kept = append(kept, r)
+			continue
+		}
+
+		delete(s.byID, r.ID)
+		if r.Type == Message {
+			s.index.Remove(r.ID, r.Message)
+		}
+	}
+	s.records = kept
+
+	return len(expired), nil
+}
+
+// stopAdded method stops collecting records added during compaction
+func (s *Store) stopAdded() {
+	s.mtx.Lock()
+	s.added = nil
+	s.mtx.Unlock()
+}
+
+// writeTemp function writes records to temporary file next to history file
+func writeTemp(path string, records []Record) (*os.File, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := WriteTranscript(tmp, FormatJSONL, records); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+// replace method appends records added during compaction to temporary file and replaces history file with it atomically
+func (s *Store) replace(tmp *os.File, added []Record) error {
+	if err := WriteTranscript(tmp, FormatJSONL, added); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	path := s.file.Name()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// new records are appended to the new file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	s.file.Close()
+	s.file = f
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompact(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	message := func(id, room string, age time.Duration, text string) Record {
+		return Record{ID: id, Time: now.Add(-age), Room: room, Type: Message, Name: "Alice", Message: text}
+	}
+
+	records := []Record{
+		message("1", "chat", 72*time.Hour, "old news"),
+		message("2", "chat", time.Hour, "fresh news"),
+		message("3", "ops", 4*time.Hour, "first alert"),
+		message("4", "ops", 3*time.Hour, "second alert"),
+		message("5", "ops", 2*time.Hour, "third alert"),
+		message("6", "legal", 720*time.Hour, "contract"),
+		message("7", "big", 2*time.Hour, "0123456789"),
+		message("8", "big", time.Hour, "9876543210"),
+	}
+
+	size := records[7].size()
+
+	cases := []struct {
+		name      string
+		retention Retention
+		purged    []string
+	}{
+		{name: "no rules", retention: Retention{}},
+		{name: "max age", retention: Retention{Global: Rule{MaxAge: 48 * time.Hour}}, purged: []string{"1", "6"}},
+		{name: "max count", retention: Retention{Rooms: map[string]Rule{"ops": {MaxCount: 2}}}, purged: []string{"3"}},
+		{name: "max bytes", retention: Retention{Rooms: map[string]Rule{"big": {MaxBytes: size}}}, purged: []string{"7"}},
+		{name: "room rule", retention: Retention{
+			Global: Rule{MaxAge: 48 * time.Hour},
+			Rooms:  map[string]Rule{"chat": {MaxAge: 96 * time.Hour}},
+		}, purged: []string{"6"}},
+		{name: "legal hold", retention: Retention{Global: Rule{MaxAge: 48 * time.Hour}, Hold: []string{"legal"}}, purged: []string{"1"}},
+	}
+
+	for _, tc := range cases {
+		s := NewStore()
+		for _, r := range records {
+			s.Add(r)
+		}
+		s.SetRetention(tc.retention)
+
+		n, err := s.Compact(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != len(tc.purged) {
+			t.Errorf("Purged records should be %d but got %d (%s)", len(tc.purged), n, tc.name)
+		}
+
+		for _, id := range tc.purged {
+			if _, ok := s.byID[id]; ok {
+				t.Errorf("Record %s should be purged (%s)", id, tc.name)
+			}
+		}
+
+		if l := s.Len(); l != len(records)-len(tc.purged) {
+			t.Errorf("Kept records should be %d but got %d (%s)", len(records)-len(tc.purged), l, tc.name)
+		}
+	}
+}
+
+func TestCompactFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC()
+
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		r := Record{ID: fmt.Sprint(i), Time: now.Add(time.Duration(i) * time.Second), Room: "chat", Type: Message, Name: "Alice", Message: fmt.Sprintf("message %d", i)}
+		if _, err := s.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.SetRetention(Retention{Global: Rule{MaxCount: 2}})
+	if n, err := s.Compact(now); n != 3 || err != nil {
+		t.Fatalf("Compact should purge %d records but got %d, %v", 3, n, err)
+	}
+
+	if res := s.Search(Query{Terms: "message"}); len(res) != 2 {
+		t.Errorf("Purged records should be removed from index but got %d results", len(res))
+	}
+
+	// records added after compaction are appended to the rewritten file
+	if _, err := s.Add(Record{ID: "5", Time: now.Add(time.Minute), Room: "chat", Type: Message, Name: "Bob", Message: "later"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var ids []string
+	for _, r := range s.Range(time.Time{}, "") {
+		ids = append(ids, r.ID)
+	}
+
+	if expected := []string{"3", "4", "5"}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("Records should be %v after reopening but got %v", expected, ids)
+	}
+}
+
+func TestCompactFailed(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+
+	s, err := OpenStore(filepath.Join(dir, "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.Add(Record{ID: fmt.Sprint(i), Time: now.Add(time.Duration(i) * time.Second), Room: "chat", Type: Message, Name: "Alice", Message: "message"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// temporary file can not be created without directory
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetRetention(Retention{Global: Rule{MaxCount: 2}})
+	if n, err := s.Compact(now); n != 0 || err == nil {
+		t.Fatalf("Compact should fail without purging but got %d, %v", n, err)
+	}
+
+	if l := s.Len(); l != 5 {
+		t.Errorf("Records should be kept when history file is not rewritten but got %d", l)
+	}
+
+	if res := s.Search(Query{Terms: "message"}); len(res) != 5 {
+		t.Errorf("Records should be kept in index when history file is not rewritten but got %d results", len(res))
+	}
+}
+
+func TestCompactConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	now := time.Now().UTC()
+
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, err := s.Add(Record{ID: fmt.Sprint("old", i), Time: now.Add(-time.Hour), Room: "chat", Type: Message, Name: "Alice", Message: "old"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.SetRetention(Retention{Global: Rule{MaxAge: time.Minute}})
+
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := s.Add(Record{ID: fmt.Sprint("new", i), Time: now, Room: "chat", Type: Message, Name: "Bob", Message: "new"}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	if _, err := s.Compact(now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// records added during compaction are kept in the rewritten file
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if res := s.Search(Query{Terms: "new", Limit: 1000}); len(res) != 100 {
+		t.Errorf("Records added during compaction should be %d but got %d", 100, len(res))
+	}
+
+	if res := s.Search(Query{Terms: "old", Limit: 1000}); len(res) != 0 {
+		t.Errorf("Expired records should be purged but got %d", len(res))
+	}
+}
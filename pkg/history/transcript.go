@@ -0,0 +1,125 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// transcript formats
+const (
+	FormatJSONL = "jsonl"
+	FormatText  = "txt"
+	FormatHTML  = "html"
+)
+
+// htmlTranscript is page of exported history, text is escaped by the template
+var htmlTranscript = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Chat transcript</title>
+</head>
+<body>
+<table>
+{{- range .}}
+<tr id="{{.ID}}" class="{{.Type}}"><td>{{time .Time}}</td><td>{{.Room}}</td><td>{{.Name}}</td><td>
+{{- if eq .Type "login"}}is online{{else if eq .Type "logout"}}is offline{{else}}{{.Message}}{{range .Attachments}} [{{.Name}}]{{end}}{{end -}}
+</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteTranscript function writes records in the format
+// only JSON lines keep everything needed to import records back
+func WriteTranscript(w io.Writer, format string, records []Record) error {
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+	case FormatText:
+		b := bufio.NewWriter(w)
+		for _, r := range records {
+			b.WriteString(r.Time.UTC().Format(time.RFC3339) + " [" + r.Room + "] " + text(r) + "\n")
+		}
+		return b.Flush()
+	case FormatHTML:
+		return htmlTranscript.Execute(w, records)
+	default:
+		return errors.Errorf("Unknown transcript format %q", format)
+	}
+
+	return nil
+}
+
+// text function returns plain text line of the record like the client prints it
+func text(r Record) string {
+	switch r.Type {
+	case Login:
+		return fmt.Sprintf("%s is online", r.Name)
+	case Logout:
+		return fmt.Sprintf("%s is offline", r.Name)
+	}
+
+	line := r.Name + ": " + r.Message
+	for _, a := range r.Attachments {
+		line += " [" + a.Name + "]"
+	}
+
+	return line
+}
+
+// ReadTranscript function reads records from JSON lines
+func ReadTranscript(r io.Reader) ([]Record, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+
+	var records []Record
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, errors.Wrapf(err, "invalid record at line %d", line)
+		}
+
+		if err := rec.Validate(); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("invalid record at line %d", line))
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, sc.Err()
+}
+
+// Validate method checks that the record can be kept in history
+func (r Record) Validate() error {
+	switch {
+	case r.ID == "":
+		return errors.New("id is required")
+	case r.Time.IsZero():
+		return errors.New("time is required")
+	case r.Name == "":
+		return errors.New("name is required")
+	case r.Type != Login && r.Type != Logout && r.Type != Message:
+		return errors.Errorf("unknown type %q", r.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package irc
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Message represents single IRC protocol line
+type Message struct {
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+// NewMessage returns Message pointer
+func NewMessage(prefix, command string, params ...string) *Message {
+	return &Message{
+		Prefix:  prefix,
+		Command: command,
+		Params:  params,
+	}
+}
+
+// ParseMessage parses IRC line without trailing CRLF
+func ParseMessage(line string) (*Message, error) {
+	line = strings.TrimRight(line, "\r\n")
+
+	m := new(Message)
+
+	if strings.HasPrefix(line, ":") {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			return nil, errors.New("Missing command")
+		}
+
+		m.Prefix, line = line[1:i], line[i+1:]
+	}
+
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+
+		if strings.HasPrefix(line, ":") {
+			// trailing parameter takes the rest of the line
+			m.Params = append(m.Params, line[1:])
+			break
+		}
+
+		i := strings.IndexByte(line, ' ')
+		if i < 0 {
+			i = len(line)
+		}
+
+		if i > 0 {
+			m.Params = append(m.Params, line[:i])
+		}
+		line = line[i:]
+	}
+
+	if len(m.Params) == 0 {
+		return nil, errors.New("Missing command")
+	}
+
+	m.Command, m.Params = strings.ToUpper(m.Params[0]), m.Params[1:]
+
+	return m, nil
+}
+
+// Param method returns parameter by index or empty string
+func (m *Message) Param(i int) string {
+	if i < 0 || i >= len(m.Params) {
+		return ""
+	}
+
+	return m.Params[i]
+}
+
+// String method returns IRC line without trailing CRLF
+// CR, LF and NUL are stripped so that parameters can't inject other lines
+func (m *Message) String() string {
+	var b strings.Builder
+
+	if m.Prefix != "" {
+		b.WriteString(":")
+		b.WriteString(strip(m.Prefix))
+		b.WriteString(" ")
+	}
+
+	b.WriteString(strip(m.Command))
+
+	for i, p := range m.Params {
+		p = strip(p)
+		b.WriteString(" ")
+
+		// last parameter can contain spaces
+		if i == len(m.Params)-1 && (p == "" || p[0] == ':' || strings.IndexByte(p, ' ') >= 0) {
+			b.WriteString(":")
+		}
+
+		b.WriteString(p)
+	}
+
+	return b.String()
+}
+
+// strip function removes characters which are not allowed in IRC lines
+func strip(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == 0 {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,92 @@
+package irc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMessage(t *testing.T) {
+	cases := []struct {
+		line    string
+		message *Message
+		ok      bool
+	}{
+		{
+			line:    "NICK Alice",
+			message: NewMessage("", "NICK", "Alice"),
+			ok:      true,
+		},
+		{
+			line:    "privmsg #chat :hello there\r\n",
+			message: NewMessage("", "PRIVMSG", "#chat", "hello there"),
+			ok:      true,
+		},
+		{
+			line:    ":Bob!Bob@chat PRIVMSG #chat ::)",
+			message: NewMessage("Bob!Bob@chat", "PRIVMSG", "#chat", ":)"),
+			ok:      true,
+		},
+		{
+			line:    "USER alice 0 *  :Alice Liddell",
+			message: NewMessage("", "USER", "alice", "0", "*", "Alice Liddell"),
+			ok:      true,
+		},
+		{
+			line: ":prefix-only",
+			ok:   false,
+		},
+		{
+			line: "",
+			ok:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		m, err := ParseMessage(tc.line)
+
+		if tc.ok != (err == nil) {
+			t.Errorf("Ok should be %t but got %v (%+v)", tc.ok, err, tc)
+			continue
+		}
+
+		if tc.ok && !reflect.DeepEqual(tc.message, m) {
+			t.Errorf("Message should be %+v but got %+v", tc.message, m)
+		}
+	}
+}
+
+func TestMessageString(t *testing.T) {
+	cases := []struct {
+		message *Message
+		line    string
+	}{
+		{
+			message: NewMessage("chat", "001", "Alice", "Welcome to the chat Alice"),
+			line:    ":chat 001 Alice :Welcome to the chat Alice",
+		},
+		{
+			message: NewMessage("Bob!Bob@chat", "JOIN", "#chat"),
+			line:    ":Bob!Bob@chat JOIN #chat",
+		},
+		{
+			message: NewMessage("", "PRIVMSG", "#chat", ":)"),
+			line:    "PRIVMSG #chat ::)",
+		},
+		{
+			message: NewMessage("chat", "CAP", "*", "LS", ""),
+			line:    ":chat CAP * LS :",
+		},
+		{
+			message: NewMessage("Bob!Bob@chat", "PRIVMSG", "#chat", "hi\r\nQUIT :bye\x00"),
+			line:    ":Bob!Bob@chat PRIVMSG #chat :hiQUIT :bye",
+		},
+	}
+
+	for _, tc := range cases {
+		line := tc.message.String()
+
+		if tc.line != line {
+			t.Errorf("Line should be %q but got %q", tc.line, line)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package irc
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+const (
+	// serverName is used as prefix of server replies
+	serverName = "chat"
+
+	// channel is the only IRC channel mapped onto the chat
+	channel = "#" + server.DefaultRoom
+)
+
+// NewServer returns Server pointer
+func NewServer(addr string, chat *server.Server, allowDebug bool) (*Server, error) {
+	// basic server address validation
+	if addr == "" {
+		return nil, errors.New("Invalid address")
+	}
+
+	if chat == nil {
+		return nil, errors.New("Invalid chat server")
+	}
+
+	return &Server{
+		Addr:   addr,
+		Chat:   chat,
+		Logger: logger.NewLogger(allowDebug),
+	}, nil
+}
+
+// Server struct accepts IRC connections and maps them onto chat sessions
+type Server struct {
+	Addr   string
+	Chat   *server.Server
+	Logger *logger.Logger
+
+	conns map[net.Conn]bool
+	mtx   sync.Mutex
+}
+
+// Run method
+func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to start on provided address")
+	}
+
+	s.Logger.Info("IRC server is listening", "addr", s.Addr)
+
+	s.mtx.Lock()
+	s.conns = make(map[net.Conn]bool)
+	s.mtx.Unlock()
+
+	// joined clients are drained by the chat server, remaining connections are closed when it is stopped
+	go func() {
+		<-ctx.Done()
+		l.Close()
+		<-s.Chat.Stopped()
+		s.closeConns()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.Logger.Info("IRC server is shutting down")
+				return nil
+			}
+
+			return errors.WithMessage(err, "Failed to accept connection")
+		}
+
+		s.addConn(conn)
+		go func() {
+			defer s.removeConn(conn)
+			newSession(ctx, s, conn).serve()
+		}()
+	}
+}
+
+func (s *Server) addConn(conn net.Conn) {
+	s.mtx.Lock()
+	s.conns[conn] = true
+	s.mtx.Unlock()
+}
+
+func (s *Server) removeConn(conn net.Conn) {
+	s.mtx.Lock()
+	delete(s.conns, conn)
+	s.mtx.Unlock()
+
+	conn.Close()
+}
+
+func (s *Server) closeConns() {
+	s.mtx.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mtx.Unlock()
+}
@@ -0,0 +1,437 @@
+package irc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+// numeric replies
+const (
+	rplWelcome          = "001"
+	rplYourHost         = "002"
+	rplCreated          = "003"
+	rplMyInfo           = "004"
+	rplUModeIs          = "221"
+	rplEndOfWho         = "315"
+	rplChannelModeIs    = "324"
+	rplNamReply         = "353"
+	rplEndOfNames       = "366"
+	errNoSuchNick       = "401"
+	errNoSuchChannel    = "403"
+	errCannotSendToChan = "404"
+	errNoTextToSend     = "412"
+	errUnknownCommand   = "421"
+	errNoMotd           = "422"
+	errNoNicknameGiven  = "431"
+	errErroneusNickname = "432"
+	errNotOnChannel     = "442"
+	errNotRegistered    = "451"
+	errNeedMoreParams   = "461"
+	errAlreadyRegistred = "462"
+	errBannedFromChan   = "474"
+)
+
+// session represents single IRC connection
+type session struct {
+	ctx  context.Context
+	srv  *Server
+	conn net.Conn
+
+	nick       string
+	user       string
+	registered bool
+
+	// token of the chat session, empty until the client joins the channel
+	token string
+	// parted is closed when the client leaves the channel itself
+	parted chan struct{}
+
+	writeMtx sync.Mutex
+}
+
+func newSession(ctx context.Context, srv *Server, conn net.Conn) *session {
+	return &session{
+		ctx:  ctx,
+		srv:  srv,
+		conn: conn,
+	}
+}
+
+// serve method reads client commands until the connection is closed
+func (s *session) serve() {
+	defer s.leave()
+
+	sc := bufio.NewScanner(s.conn)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+
+		m, err := ParseMessage(sc.Text())
+		if err != nil {
+			s.srv.Logger.Debug("Invalid IRC line", "peer", s.conn.RemoteAddr(), "err", err)
+			continue
+		}
+
+		if !s.handle(m) {
+			return
+		}
+	}
+}
+
+// handle method processes single command, returns false when connection should be closed
+func (s *session) handle(m *Message) bool {
+	switch m.Command {
+	case "PING":
+		s.reply("PONG", serverName, m.Param(0))
+		return true
+	case "QUIT":
+		s.send(NewMessage("", "ERROR", "Closing link"))
+		return false
+	case "CAP":
+		if m.Param(0) == "LS" {
+			s.send(NewMessage(serverName, "CAP", "*", "LS", ""))
+		}
+		return true
+	case "PASS":
+		return true
+	case "NICK":
+		s.handleNick(m)
+		return true
+	case "USER":
+		s.handleUser(m)
+		return true
+	}
+
+	if !s.registered {
+		s.numeric(errNotRegistered, "You have not registered")
+		return true
+	}
+
+	switch m.Command {
+	case "JOIN":
+		s.handleJoin(m)
+	case "PART":
+		s.handlePart(m)
+	case "PRIVMSG":
+		s.handlePrivmsg(m)
+	case "NAMES":
+		s.names()
+	case "MODE":
+		s.handleMode(m)
+	case "WHO":
+		s.numeric(rplEndOfWho, m.Param(0), "End of WHO list")
+	default:
+		s.numeric(errUnknownCommand, m.Command, "Unknown command")
+	}
+
+	return true
+}
+
+func (s *session) handleNick(m *Message) {
+	if m.Param(0) == "" {
+		s.numeric(errNoNicknameGiven, "No nickname given")
+		return
+	}
+
+	if s.registered {
+		s.send(NewMessage(serverName, "NOTICE", s.nick, "Nickname changes are not supported"))
+		return
+	}
+
+	nick, err := s.srv.Chat.ValidateName(m.Param(0))
+	if err != nil {
+		s.numeric(errErroneusNickname, m.Param(0), "Erroneous nickname ("+err.Error()+")")
+		return
+	}
+
+	s.nick = nick
+	s.register()
+}
+
+func (s *session) handleUser(m *Message) {
+	if s.registered {
+		s.numeric(errAlreadyRegistred, "You may not reregister")
+		return
+	}
+
+	if m.Param(0) == "" {
+		s.numeric(errNeedMoreParams, "USER", "Not enough parameters")
+		return
+	}
+
+	s.user = m.Param(0)
+	s.register()
+}
+
+// register method completes registration once both NICK and USER are received
+func (s *session) register() {
+	if s.nick == "" || s.user == "" {
+		return
+	}
+
+	s.registered = true
+
+	s.numeric(rplWelcome, "Welcome to the chat "+s.nick)
+	s.numeric(rplYourHost, "Your host is "+serverName)
+	s.numeric(rplCreated, "This server bridges IRC to the chat")
+	s.numeric(rplMyInfo, serverName, "chat", "i", "nt")
+	s.numeric(errNoMotd, "MOTD File is missing")
+
+	s.srv.Logger.Debug("IRC client has registered", "user", s.nick, "peer", s.conn.RemoteAddr())
+}
+
+func (s *session) handleJoin(m *Message) {
+	if m.Param(0) == "" {
+		s.numeric(errNeedMoreParams, "JOIN", "Not enough parameters")
+		return
+	}
+
+	if m.Param(0) == "0" {
+		s.part()
+		return
+	}
+
+	for _, ch := range strings.Split(m.Param(0), ",") {
+		if ch != channel {
+			s.numeric(errNoSuchChannel, ch, "No such channel")
+			continue
+		}
+
+		s.join()
+	}
+}
+
+func (s *session) handlePart(m *Message) {
+	if m.Param(0) == "" {
+		s.numeric(errNeedMoreParams, "PART", "Not enough parameters")
+		return
+	}
+
+	for _, ch := range strings.Split(m.Param(0), ",") {
+		if ch != channel {
+			s.numeric(errNoSuchChannel, ch, "No such channel")
+			continue
+		}
+
+		if s.token == "" {
+			s.numeric(errNotOnChannel, ch, "You're not on that channel")
+			continue
+		}
+
+		s.part()
+	}
+}
+
+func (s *session) handlePrivmsg(m *Message) {
+	target, text := m.Param(0), m.Param(1)
+
+	switch {
+	case target == "":
+		s.numeric(errNeedMoreParams, "PRIVMSG", "Not enough parameters")
+	case text == "":
+		s.numeric(errNoTextToSend, "No text to send")
+	case target != channel:
+		s.numeric(errNoSuchNick, target, "No such nick/channel")
+	case s.token == "":
+		s.numeric(errCannotSendToChan, target, "Cannot send to channel")
+	default:
+		s.srv.Logger.Debug("IRC client has sent a message", "user", s.nick, "message", text)
+
+		err := s.srv.Chat.Throttle(s.nick, s.token)
+		if err == nil {
+			err = s.srv.Chat.Say(s.nick, text)
+		}
+
+		switch err {
+		case server.ErrThrottled:
+			s.numeric(errCannotSendToChan, target, "Cannot send to channel (you are sending messages too fast)")
+		case server.ErrDropped:
+			s.numeric(errCannotSendToChan, target, "Cannot send to channel (message is rejected by moderation)")
+		case server.ErrForbidden:
+			s.numeric(errCannotSendToChan, target, "Cannot send to channel (you are not allowed to post)")
+		case server.ErrMuted:
+			s.numeric(errCannotSendToChan, target, "Cannot send to channel (you are muted)")
+		case nil:
+		default:
+			s.numeric(errCannotSendToChan, target, "Cannot send to channel ("+strings.ToLower(err.Error())+")")
+		}
+	}
+}
+
+func (s *session) handleMode(m *Message) {
+	switch m.Param(0) {
+	case channel:
+		s.numeric(rplChannelModeIs, channel, "+")
+	case s.nick:
+		s.numeric(rplUModeIs, "+")
+	default:
+		s.numeric(errNoSuchNick, m.Param(0), "No such nick/channel")
+	}
+}
+
+// join method opens chat session and starts forwarding chat events
+func (s *session) join() {
+	if s.token != "" {
+		return
+	}
+
+	if !s.srv.Chat.Permissions.Allowed(s.nick, server.DefaultRoom, server.PermLogin) {
+		s.numeric(errBannedFromChan, channel, "Cannot join channel (you are not allowed to log in)")
+		return
+	}
+
+	token, err := s.srv.Chat.Join(s.nick)
+	if err == server.ErrBanned {
+		s.numeric(errBannedFromChan, channel, "Cannot join channel (you are banned)")
+		return
+	} else if err != nil {
+		s.srv.Logger.Warn("Failed to join", "user", s.nick, "err", err)
+		return
+	}
+
+	s.token = token
+	s.parted = make(chan struct{})
+
+	stream := s.srv.Chat.Clients.AddStream(s.token)
+	go s.forward(stream, s.parted)
+
+	s.send(NewMessage(s.prefix(s.nick), "JOIN", channel))
+	s.names()
+}
+
+// part method leaves the channel if the client has joined it
+func (s *session) part() {
+	if s.token == "" {
+		return
+	}
+
+	s.leave()
+	s.send(NewMessage(s.prefix(s.nick), "PART", channel))
+}
+
+// leave method closes chat session if the client has joined the channel
+func (s *session) leave() {
+	if s.token == "" {
+		return
+	}
+
+	token := s.token
+	s.token = ""
+
+	close(s.parted)
+	s.srv.Chat.Clients.CloseStream(token)
+
+	// the server notifies clients itself during shutdown
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	s.srv.Chat.Leave(token)
+}
+
+func (s *session) names() {
+	online := s.srv.Chat.Online()
+	for i := range online {
+		online[i] = nickname(online[i])
+	}
+
+	s.numeric(rplNamReply, "=", channel, strings.Join(online, " "))
+	s.numeric(rplEndOfNames, channel, "End of /NAMES list")
+}
+
+// forward method translates chat events into IRC lines
+func (s *session) forward(stream chan chat.ResponseStream, parted chan struct{}) {
+	var shutdown bool
+
+	for res := range stream {
+		switch evt := res.Event.(type) {
+		case *chat.ResponseStream_ClientLogin:
+			if evt.ClientLogin.Name != s.nick {
+				s.send(NewMessage(s.prefix(evt.ClientLogin.Name), "JOIN", channel))
+			}
+		case *chat.ResponseStream_ClientLogout:
+			if evt.ClientLogout.Name != s.nick {
+				s.send(NewMessage(s.prefix(evt.ClientLogout.Name), "QUIT", "offline"))
+			}
+		case *chat.ResponseStream_ClientMessage:
+			// IRC clients echo own messages themselves
+			if evt.ClientMessage.Name != s.nick {
+				s.send(NewMessage(s.prefix(evt.ClientMessage.Name), "PRIVMSG", channel, evt.ClientMessage.Message))
+			}
+		case *chat.ResponseStream_ServerAnnouncement:
+			s.send(NewMessage(serverName, "NOTICE", channel, evt.ServerAnnouncement.Message))
+		case *chat.ResponseStream_ServerShutdown:
+			// the stream is closed by the server at the deadline
+			shutdown = true
+			s.send(NewMessage(serverName, "NOTICE", channel, shutdownNotice(evt.ServerShutdown)))
+		default:
+			s.srv.Logger.Debug("Unexpected event for IRC client", "user", s.nick, "event", fmt.Sprintf("%T", evt))
+		}
+	}
+	select {
+	case <-parted:
+	default:
+		// stream is closed by the server
+		if shutdown {
+			s.send(NewMessage("", "ERROR", "Server is shutting down"))
+		} else {
+			s.send(NewMessage(serverName, "KICK", channel, nickname(s.nick), "Session is closed by the server"))
+		}
+		s.conn.Close()
+	}
+}
+
+// shutdownNotice function returns notice about shutdown with remaining time
+func shutdownNotice(evt *chat.ResponseStream_Shutdown) string {
+	deadline, err := ptypes.Timestamp(evt.Deadline)
+	if err != nil {
+		return "Server is shutting down"
+	}
+
+	return "Server is shutting down in " + time.Until(deadline).Round(time.Second).String()
+}
+
+// numeric method sends numeric reply addressed to the client
+func (s *session) numeric(code string, params ...string) {
+	nick := s.nick
+	if nick == "" {
+		nick = "*"
+	}
+
+	s.send(NewMessage(serverName, code, append([]string{nick}, params...)...))
+}
+
+func (s *session) reply(command string, params ...string) {
+	s.send(NewMessage(serverName, command, params...))
+}
+
+func (s *session) send(m *Message) {
+	s.writeMtx.Lock()
+	defer s.writeMtx.Unlock()
+
+	if _, err := s.conn.Write([]byte(m.String() + "\r\n")); err != nil {
+		s.srv.Logger.Debug("Failed to write to IRC client", "user", s.nick, "err", err)
+	}
+}
+
+// prefix method returns IRC user mask for the chat name
+func (s *session) prefix(name string) string {
+	nick := nickname(name)
+	return nick + "!" + nick + "@" + serverName
+}
+
+// nickname returns IRC safe representation of the chat name
+func nickname(name string) string {
+	return strings.Replace(name, " ", "_", -1)
+}
@@ -0,0 +1,142 @@
+package irc
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/server"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+// client is IRC connection of the test
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *client) send(line string) {
+	if _, err := c.conn.Write([]byte(line + "\r\n")); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// expect method reads lines until one contains the text
+func (c *client) expect(text string) string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("Line with %q should be received but got %v", text, err)
+		}
+
+		if strings.Contains(line, text) {
+			return strings.TrimRight(line, "\r\n")
+		}
+	}
+}
+
+func dial(t *testing.T, addr string) *client {
+	var conn net.Conn
+	var err error
+
+	// the IRC server is started in background
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func register(t *testing.T, addr, nick string) *client {
+	c := dial(t, addr)
+	c.send("NICK " + nick)
+	c.send("USER " + strings.ToLower(nick) + " 0 * :" + nick)
+	c.expect(" 001 " + nick + " ")
+
+	return c
+}
+
+func TestSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chat, err := server.NewServer(freeAddr(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go chat.Run(ctx)
+
+	srv, err := NewServer(freeAddr(t), chat, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Run(ctx)
+
+	anonymous := dial(t, srv.Addr)
+	defer anonymous.conn.Close()
+
+	anonymous.send("JOIN #chat")
+	anonymous.expect(" 451 * ")
+
+	alice := register(t, srv.Addr, "Alice")
+	defer alice.conn.Close()
+
+	alice.send("JOIN #chat")
+	alice.expect(":Alice!Alice@chat JOIN #chat")
+	alice.expect(" 366 Alice #chat ")
+
+	bob := register(t, srv.Addr, "Bob")
+	defer bob.conn.Close()
+
+	bob.send("JOIN #other,#chat")
+	bob.expect(" 403 Bob #other ")
+	bob.expect(":Bob!Bob@chat JOIN #chat")
+	alice.expect(":Bob!Bob@chat JOIN #chat")
+
+	bob.send("NAMES")
+	if line := bob.expect(" 353 Bob "); !strings.HasSuffix(line, ":Alice Bob") {
+		t.Errorf("Names should be %q but got %q", "Alice Bob", line)
+	}
+
+	bob.send("PRIVMSG #chat :hello there")
+	alice.expect(":Bob!Bob@chat PRIVMSG #chat :hello there")
+
+	bob.send("PRIVMSG #chat :\x01ACTION waves\x01")
+	bob.expect(" 404 Bob #chat :Cannot send to channel (message contains invalid characters)")
+
+	bob.send("PING token")
+	bob.expect("PONG chat token")
+
+	bob.send("PART #chat")
+	bob.expect(":Bob!Bob@chat PART #chat")
+	alice.expect(":Bob!Bob@chat QUIT offline")
+
+	bob.send("PRIVMSG #chat :after part")
+	bob.expect(" 404 Bob #chat ")
+
+	alice.send("QUIT")
+	alice.expect("ERROR :Closing link")
+}
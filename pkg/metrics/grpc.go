@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+
+	"google.golang.org/grpc/stats"
+)
+
+type methodKey struct{}
+
+// RPCHandler implements grpc stats.Handler and observes latency of each RPC by method
+// latency of streaming RPC is the duration of the stream
+type RPCHandler struct {
+	Latency *HistogramVec
+}
+
+// TagRPC method keeps method name in the context of the RPC
+func (h *RPCHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, methodKey{}, info.FullMethodName)
+}
+
+// HandleRPC method observes latency when the RPC ends
+func (h *RPCHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	end, ok := s.(*stats.End)
+	if !ok {
+		return
+	}
+
+	method, _ := ctx.Value(methodKey{}).(string)
+	h.Latency.Observe(method, end.EndTime.Sub(end.BeginTime).Seconds())
+}
+
+// TagConn method returns the context unchanged
+func (h *RPCHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn method does nothing
+func (h *RPCHandler) HandleConn(ctx context.Context, s stats.ConnStats) {}
+
+// NewRPCHandler returns RPCHandler pointer with latency histograms registered in the registry
+func NewRPCHandler(r *Registry, name string) *RPCHandler {
+	return &RPCHandler{
+		Latency: r.NewHistogramVec(name, "Latency of gRPC calls in seconds by method.", "method", DefaultBuckets),
+	}
+}
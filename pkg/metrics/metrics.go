@@ -0,0 +1,344 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// metric types of the exposition format
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// DefaultBuckets are upper bounds of latency histograms in seconds
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metric is written by the registry
+type metric interface {
+	write(w *bufio.Writer, name string)
+}
+
+type entry struct {
+	name   string
+	help   string
+	typ    string
+	metric metric
+}
+
+// Registry keeps metrics and writes them in Prometheus text format
+type Registry struct {
+	entries []entry
+	mtx     sync.RWMutex
+}
+
+func (r *Registry) add(name, help, typ string, m metric) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.entries = append(r.entries, entry{name: name, help: help, typ: typ, metric: m})
+}
+
+// NewCounter method registers counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := new(Counter)
+	r.add(name, help, counterType, c)
+
+	return c
+}
+
+// NewCounterFunc method registers counter which value is returned by the function
+func (r *Registry) NewCounterFunc(name, help string, f func() float64) {
+	r.add(name, help, counterType, valueFunc(f))
+}
+
+// NewGaugeFunc method registers gauge which value is returned by the function
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.add(name, help, gaugeType, valueFunc(f))
+}
+
+// NewHistogramVec method registers histograms partitioned by the label
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{
+		label:      label,
+		buckets:    buckets,
+		histograms: make(map[string]*histogram),
+	}
+	r.add(name, help, histogramType, h)
+
+	return h
+}
+
+// WriteTo method writes all metrics in Prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.RLock()
+	entries := r.entries
+	r.mtx.RUnlock()
+
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+
+	for _, e := range entries {
+		b.WriteString("# HELP " + e.name + " " + e.help + "\n")
+		b.WriteString("# TYPE " + e.name + " " + e.typ + "\n")
+		e.metric.write(b, e.name)
+	}
+
+	err := b.Flush()
+
+	return cw.n, err
+}
+
+// ServeHTTP method implements http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// Counter is monotonically increasing value
+type Counter struct {
+	value int64
+}
+
+// Inc method increments the counter
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.value, 1)
+}
+
+// Value method returns current value of the counter
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+func (c *Counter) write(w *bufio.Writer, name string) {
+	writeSample(w, name, "", float64(c.Value()))
+}
+
+type valueFunc func() float64
+
+func (f valueFunc) write(w *bufio.Writer, name string) {
+	writeSample(w, name, "", f())
+}
+
+// HistogramVec counts observations in buckets for each label value
+type HistogramVec struct {
+	label      string
+	buckets    []float64
+	histograms map[string]*histogram
+	mtx        sync.Mutex
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe method adds observation for the label value
+func (h *HistogramVec) Observe(value string, v float64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	hist, ok := h.histograms[value]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.histograms[value] = hist
+	}
+
+	// buckets are cumulative
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hist.counts[i]++
+		}
+	}
+
+	hist.count++
+	hist.sum += v
+}
+
+// Count method returns number of observations for the label value
+func (h *HistogramVec) Count(value string) uint64 {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if hist, ok := h.histograms[value]; ok {
+		return hist.count
+	}
+
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, name string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	values := make([]string, 0, len(h.histograms))
+	for value := range h.histograms {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	for _, value := range values {
+		hist := h.histograms[value]
+		label := h.label + "=" + strconv.Quote(value)
+
+		for i, upper := range h.buckets {
+			writeSample(w, name+"_bucket", label+`,le="`+formatFloat(upper)+`"`, float64(hist.counts[i]))
+		}
+
+		writeSample(w, name+"_bucket", label+`,le="+Inf"`, float64(hist.count))
+		writeSample(w, name+"_sum", label, hist.sum)
+		writeSample(w, name+"_count", label, float64(hist.count))
+	}
+}
+
+// Rate counts events per second over the window
+type Rate struct {
+	now func() time.Time
+
+	// counts of events by second
+	counts []int64
+	last   int64
+	mtx    sync.Mutex
+}
+
+// Add method counts events
+func (r *Rate) Add(n int64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.counts[r.advance()] += n
+}
+
+// PerSecond method returns average number of events per second over the window
+func (r *Rate) PerSecond() float64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	current := r.advance()
+
+	// current second is not complete
+	var sum int64
+	for i, n := range r.counts {
+		if i != current {
+			sum += n
+		}
+	}
+
+	return float64(sum) / float64(len(r.counts)-1)
+}
+
+// advance method clears seconds passed since the last call and returns index of current second
+func (r *Rate) advance() int {
+	sec := r.now().Unix()
+	size := int64(len(r.counts))
+
+	if sec-r.last >= size {
+		for i := range r.counts {
+			r.counts[i] = 0
+		}
+	} else {
+		for s := r.last + 1; s <= sec; s++ {
+			r.counts[s%size] = 0
+		}
+	}
+
+	if sec > r.last {
+		r.last = sec
+	}
+
+	return int(r.last % size)
+}
+
+// NewRate returns Rate pointer, window is rounded to seconds
+func NewRate(window time.Duration) *Rate {
+	seconds := int(window / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return &Rate{
+		now:    time.Now,
+		counts: make([]int64, seconds+1),
+	}
+}
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteString("{" + labels + "}")
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// Serve function serves registry at /metrics until the context is done
+func Serve(ctx context.Context, addr string, r *Registry) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to start on provided address")
+	}
+
+	srv := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// NewRegistry returns Registry pointer
+func NewRegistry() *Registry {
+	return new(Registry)
+}
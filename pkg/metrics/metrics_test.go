@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.NewCounter("chat_test_total", "Test counter.")
+	c.Inc()
+	c.Inc()
+
+	r.NewGaugeFunc("chat_test_queue", "Test gauge.", func() float64 { return 1.5 })
+
+	h := r.NewHistogramVec("chat_test_seconds", "Test histogram.", "method", []float64{0.1, 1})
+	h.Observe("/chat.Chat/Login", 0.05)
+	h.Observe("/chat.Chat/Login", 0.5)
+	h.Observe("/chat.Chat/Login", 2)
+
+	out := new(bytes.Buffer)
+	if _, err := r.WriteTo(out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"# HELP chat_test_total Test counter.",
+		"# TYPE chat_test_total counter",
+		"chat_test_total 2",
+		"# TYPE chat_test_queue gauge",
+		"chat_test_queue 1.5",
+		"# TYPE chat_test_seconds histogram",
+		`chat_test_seconds_bucket{method="/chat.Chat/Login",le="0.1"} 1`,
+		`chat_test_seconds_bucket{method="/chat.Chat/Login",le="1"} 2`,
+		`chat_test_seconds_bucket{method="/chat.Chat/Login",le="+Inf"} 3`,
+		`chat_test_seconds_sum{method="/chat.Chat/Login"} 2.55`,
+		`chat_test_seconds_count{method="/chat.Chat/Login"} 3`,
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Output should contain %q but got %q", line, out.String())
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	r := NewRate(2 * time.Second)
+	r.now = func() time.Time { return now }
+
+	cases := []struct {
+		add       int64
+		advance   time.Duration
+		perSecond float64
+	}{
+		// current second is not counted
+		{add: 4, advance: 0, perSecond: 0},
+		{add: 2, advance: time.Second, perSecond: 2},
+		{add: 0, advance: time.Second, perSecond: 3},
+		{add: 0, advance: time.Second, perSecond: 1},
+		{add: 0, advance: 10 * time.Second, perSecond: 0},
+	}
+
+	for i, tc := range cases {
+		now = now.Add(tc.advance)
+		r.Add(tc.add)
+
+		if perSecond := r.PerSecond(); perSecond != tc.perSecond {
+			t.Errorf("Rate of case %d should be %g but got %g", i, tc.perSecond, perSecond)
+		}
+	}
+}
+
+func TestRPCHandler(t *testing.T) {
+	h := NewRPCHandler(NewRegistry(), "chat_rpc_duration_seconds")
+
+	ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/chat.Chat/Login"})
+
+	begin := time.Now()
+	h.HandleRPC(ctx, &stats.Begin{BeginTime: begin})
+	h.HandleRPC(ctx, &stats.End{BeginTime: begin, EndTime: begin.Add(time.Millisecond)})
+
+	if count := h.Latency.Count("/chat.Chat/Login"); count != 1 {
+		t.Errorf("Count should be %d but got %d", 1, count)
+	}
+}
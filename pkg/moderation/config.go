@@ -0,0 +1,135 @@
+package moderation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config struct describes filters of the pipeline
+//
+//	{
+//	  "mute_for": "10m",
+//	  "words": {"list": ["darn"], "action": "mask"},
+//	  "regex": [{"name": "card", "pattern": "\\d{4} \\d{4} \\d{4} \\d{4}", "action": "drop"}],
+//	  "links": {"deny": ["evil.example.com"], "action": "flag"}
+//	}
+//
+// filters run in order words, regex rules, links
+type Config struct {
+	MuteFor string        `json:"mute_for"`
+	Words   *WordsConfig  `json:"words"`
+	Regex   []RegexConfig `json:"regex"`
+	Links   *Links        `json:"links"`
+}
+
+// WordsConfig struct describes word list filter
+type WordsConfig struct {
+	List   []string `json:"list"`
+	Action Action   `json:"action"`
+}
+
+// RegexConfig struct describes regex filter
+type RegexConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Action  Action `json:"action"`
+}
+
+// Filters method returns filters described by the config
+// every filter requires action, missing action would be zero Allow which never blocks anything
+func (c Config) Filters() ([]Filter, error) {
+	var filters []Filter
+
+	if c.Words != nil {
+		if err := validateAction("words", c.Words.Action); err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, NewWordList(c.Words.List, c.Words.Action))
+	}
+
+	for _, rc := range c.Regex {
+		if err := validateAction("regex "+rc.Name, rc.Action); err != nil {
+			return nil, err
+		}
+
+		r, err := NewRegex(rc.Name, rc.Pattern, rc.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, r)
+	}
+
+	if c.Links != nil {
+		if err := validateAction("links", c.Links.Action); err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, c.Links)
+	}
+
+	return filters, nil
+}
+
+// validateAction function returns error if action of the filter is missing or unknown
+func validateAction(filter string, a Action) error {
+	if _, ok := actionNames[a]; !ok || a == Allow {
+		return errors.Errorf("action of %s filter is missing or unknown", filter)
+	}
+
+	return nil
+}
+
+// ReadConfig function reads config from JSON file
+func ReadConfig(path string) (Config, error) {
+	var c Config
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, errors.WithMessage(err, "failed to read moderation rules")
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.WithMessage(err, "failed to parse moderation rules")
+	}
+
+	return c, nil
+}
+
+// Apply method replaces filters and mute duration of the pipeline with ones from the config
+func (p *Pipeline) Apply(c Config) error {
+	filters, err := c.Filters()
+	if err != nil {
+		return err
+	}
+
+	muteFor := defaultMuteFor
+	if c.MuteFor != "" {
+		if muteFor, err = time.ParseDuration(c.MuteFor); err != nil {
+			return errors.WithMessage(err, "invalid mute_for")
+		}
+	}
+
+	p.Replace(filters, muteFor)
+
+	return nil
+}
+
+// LoadPipeline returns Pipeline pointer with filters from JSON file
+func LoadPipeline(path string) (*Pipeline, error) {
+	c, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := NewPipeline()
+	if err := p.Apply(c); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,165 @@
+package moderation
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// mask function replaces each character of the text by asterisk
+func mask(text string) string {
+	return strings.Repeat("*", utf8.RuneCountInString(text))
+}
+
+// wordPattern finds words of any script
+var wordPattern = regexp.MustCompile(`[\p{L}\p{M}\p{N}_]+`)
+
+// WordList filter matches whole words case-insensitively
+type WordList struct {
+	Action Action
+	words  map[string]bool
+}
+
+// Check method implements Filter interface
+func (w *WordList) Check(m Message) Verdict {
+	var matched bool
+
+	text := wordPattern.ReplaceAllStringFunc(m.Text, func(word string) string {
+		if !w.words[strings.ToLower(word)] {
+			return word
+		}
+
+		matched = true
+		return mask(word)
+	})
+
+	if !matched {
+		return Verdict{}
+	}
+
+	v := Verdict{Action: w.Action, Rule: "words"}
+	if w.Action == Mask {
+		v.Text = text
+	}
+
+	return v
+}
+
+// NewWordList returns WordList pointer
+func NewWordList(words []string, action Action) *WordList {
+	w := &WordList{
+		Action: action,
+		words:  make(map[string]bool, len(words)),
+	}
+
+	for _, word := range words {
+		w.words[strings.ToLower(word)] = true
+	}
+
+	return w
+}
+
+// Regex filter matches regular expression
+type Regex struct {
+	Name   string
+	Action Action
+	re     *regexp.Regexp
+}
+
+// Check method implements Filter interface
+func (r *Regex) Check(m Message) Verdict {
+	if !r.re.MatchString(m.Text) {
+		return Verdict{}
+	}
+
+	v := Verdict{Action: r.Action, Rule: "regex:" + r.Name}
+	if r.Action == Mask {
+		v.Text = r.re.ReplaceAllStringFunc(m.Text, mask)
+	}
+
+	return v
+}
+
+// NewRegex returns Regex pointer
+func NewRegex(name, pattern string, action Action) (*Regex, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid pattern of rule "+name)
+	}
+
+	return &Regex{Name: name, Action: action, re: re}, nil
+}
+
+// linkPattern finds links with scheme and links starting with www
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)[^\s<>"]+`)
+
+// Links filter matches links by host
+// links to denied hosts and, if allowed hosts are set, links to other hosts are matched
+// hosts match their subdomains
+type Links struct {
+	Allow  []string
+	Deny   []string
+	Action Action
+}
+
+// Check method implements Filter interface
+func (l *Links) Check(m Message) Verdict {
+	var matched bool
+
+	text := linkPattern.ReplaceAllStringFunc(m.Text, func(link string) string {
+		if !l.matches(host(link)) {
+			return link
+		}
+
+		matched = true
+		return mask(link)
+	})
+
+	if !matched {
+		return Verdict{}
+	}
+
+	v := Verdict{Action: l.Action, Rule: "links"}
+	if l.Action == Mask {
+		v.Text = text
+	}
+
+	return v
+}
+
+func (l *Links) matches(h string) bool {
+	if matchHost(h, l.Deny) {
+		return true
+	}
+
+	return len(l.Allow) > 0 && !matchHost(h, l.Allow)
+}
+
+// host function returns lower case host of the link
+func host(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
+// matchHost function returns true if the host is one of the hosts or their subdomain
+func matchHost(h string, hosts []string) bool {
+	for _, d := range hosts {
+		d = strings.ToLower(d)
+		if h == d || strings.HasSuffix(h, "."+d) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,209 @@
+package moderation
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultMuteFor is duration of the mute action if not configured
+const defaultMuteFor = 10 * time.Minute
+
+// Action taken on a message
+type Action int
+
+// actions ordered by severity
+const (
+	Allow Action = iota
+	Mask
+	Flag
+	Drop
+	Mute
+)
+
+var actionNames = map[Action]string{
+	Allow: "allow",
+	Mask:  "mask",
+	Flag:  "flag",
+	Drop:  "drop",
+	Mute:  "mute",
+}
+
+// String method returns name of the action
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// MarshalText method implements encoding.TextMarshaler
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText method implements encoding.TextUnmarshaler
+func (a *Action) UnmarshalText(text []byte) error {
+	for action, name := range actionNames {
+		if name == string(text) {
+			*a = action
+			return nil
+		}
+	}
+
+	return errors.Errorf("Unknown action %q", text)
+}
+
+// Message sent by the client
+type Message struct {
+	Name string
+	Text string
+}
+
+// Verdict of single filter
+type Verdict struct {
+	Action Action
+	// Text replaces message text if action is Mask
+	Text string
+	Rule string
+}
+
+// Filter checks messages, custom filters can be added to the pipeline
+type Filter interface {
+	Check(m Message) Verdict
+}
+
+// FilterFunc adapts function to Filter interface
+type FilterFunc func(m Message) Verdict
+
+// Check method calls the function
+func (f FilterFunc) Check(m Message) Verdict {
+	return f(m)
+}
+
+// Result of the pipeline
+type Result struct {
+	// Action is the most severe action of all filters
+	Action Action
+	// Text is message text after masking
+	Text string
+	// Verdicts are non allowing verdicts of filters
+	Verdicts []Verdict
+	// MuteFor is duration of the mute action
+	MuteFor time.Duration
+}
+
+// Record of action taken on a message
+type Record struct {
+	Time    time.Time `json:"time"`
+	Name    string    `json:"name"`
+	Message string    `json:"message"`
+	Action  Action    `json:"action"`
+	Rule    string    `json:"rule"`
+}
+
+// Recorder keeps records of taken actions
+type Recorder interface {
+	Record(r Record)
+}
+
+// JSONRecorder writes records as JSON lines
+type JSONRecorder struct {
+	w   io.Writer
+	mtx sync.Mutex
+}
+
+// Record method writes record as single JSON line
+func (j *JSONRecorder) Record(r Record) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	json.NewEncoder(j.w).Encode(r)
+}
+
+// NewJSONRecorder returns JSONRecorder pointer
+func NewJSONRecorder(w io.Writer) *JSONRecorder {
+	return &JSONRecorder{w: w}
+}
+
+// Pipeline runs message through filters in order
+// masked text is passed to the next filter, pipeline stops on Drop or Mute
+type Pipeline struct {
+	// Recorder gets record of every action taken, records are not kept if nil
+	Recorder Recorder
+
+	filters []Filter
+	muteFor time.Duration
+	mtx     sync.RWMutex
+}
+
+// Add method appends filter to the pipeline
+func (p *Pipeline) Add(f Filter) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.filters = append(p.filters, f)
+}
+
+// Replace method replaces filters of the pipeline and duration of the mute action
+func (p *Pipeline) Replace(filters []Filter, muteFor time.Duration) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.filters = filters
+	p.muteFor = muteFor
+}
+
+// Check method runs message of the client through filters
+func (p *Pipeline) Check(name, text string) Result {
+	p.mtx.RLock()
+	filters := p.filters
+	res := Result{Text: text, MuteFor: p.muteFor}
+	p.mtx.RUnlock()
+
+	for _, f := range filters {
+		v := f.Check(Message{Name: name, Text: res.Text})
+		if v.Action == Allow {
+			continue
+		}
+
+		res.Verdicts = append(res.Verdicts, v)
+		p.record(name, text, v)
+
+		if v.Action == Mask {
+			res.Text = v.Text
+		}
+
+		if v.Action > res.Action {
+			res.Action = v.Action
+		}
+
+		if res.Action >= Drop {
+			break
+		}
+	}
+
+	return res
+}
+
+func (p *Pipeline) record(name, text string, v Verdict) {
+	if p.Recorder == nil {
+		return
+	}
+
+	p.Recorder.Record(Record{
+		Time:    time.Now(),
+		Name:    name,
+		Message: text,
+		Action:  v.Action,
+		Rule:    v.Rule,
+	})
+}
+
+// NewPipeline returns Pipeline pointer
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{
+		filters: filters,
+		muteFor: defaultMuteFor,
+	}
+}
@@ -0,0 +1,167 @@
+package moderation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWordList(t *testing.T) {
+	w := NewWordList([]string{"darn", "Мат"}, Mask)
+
+	cases := []struct {
+		text   string
+		action Action
+		masked string
+	}{
+		{text: "hello", action: Allow},
+		{text: "darnation", action: Allow},
+		{text: "oh DARN it", action: Mask, masked: "oh **** it"},
+		{text: "darn,darn", action: Mask, masked: "****,****"},
+		{text: "это мат", action: Mask, masked: "это ***"},
+	}
+
+	for _, tc := range cases {
+		v := w.Check(Message{Name: "Bob", Text: tc.text})
+		if tc.action != v.Action || tc.masked != v.Text {
+			t.Errorf("Verdict of %q should be %s %q but got %s %q", tc.text, tc.action, tc.masked, v.Action, v.Text)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	cases := []struct {
+		links  *Links
+		text   string
+		action Action
+	}{
+		{links: &Links{Deny: []string{"evil.com"}, Action: Drop}, text: "see https://evil.com/x", action: Drop},
+		{links: &Links{Deny: []string{"evil.com"}, Action: Drop}, text: "see www.sub.EVIL.com", action: Drop},
+		{links: &Links{Deny: []string{"evil.com"}, Action: Drop}, text: "see https://notevil.com", action: Allow},
+		{links: &Links{Allow: []string{"example.com"}, Action: Flag}, text: "see https://docs.example.com/a", action: Allow},
+		{links: &Links{Allow: []string{"example.com"}, Action: Flag}, text: "see ftp://other.org", action: Flag},
+		{links: &Links{Allow: []string{"example.com"}, Action: Flag}, text: "no links here", action: Allow},
+	}
+
+	for _, tc := range cases {
+		if v := tc.links.Check(Message{Text: tc.text}); tc.action != v.Action {
+			t.Errorf("Action of %q should be %s but got %s", tc.text, tc.action, v.Action)
+		}
+	}
+
+	v := (&Links{Deny: []string{"evil.com"}, Action: Mask}).Check(Message{Text: "go http://evil.com now"})
+	if v.Text != "go *************** now" {
+		t.Errorf("Text should be masked but got %q", v.Text)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	card, err := NewRegex("card", `\d{4}-\d{4}`, Drop)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+
+	p := NewPipeline(NewWordList([]string{"darn"}, Mask), card)
+	p.Recorder = NewJSONRecorder(out)
+	p.Add(FilterFunc(func(m Message) Verdict {
+		if m.Name == "Spammer" {
+			return Verdict{Action: Mute, Rule: "spammer"}
+		}
+
+		return Verdict{}
+	}))
+
+	cases := []struct {
+		name   string
+		text   string
+		action Action
+		result string
+	}{
+		{name: "Bob", text: "hello", action: Allow, result: "hello"},
+		{name: "Bob", text: "darn", action: Mask, result: "****"},
+		{name: "Bob", text: "darn 1234-5678", action: Drop, result: "**** 1234-5678"},
+		{name: "Spammer", text: "hi", action: Mute, result: "hi"},
+	}
+
+	for _, tc := range cases {
+		res := p.Check(tc.name, tc.text)
+		if tc.action != res.Action || tc.result != res.Text {
+			t.Errorf("Result of %q should be %s %q but got %s %q", tc.text, tc.action, tc.result, res.Action, res.Text)
+		}
+	}
+
+	// every action is recorded with original message
+	var records []Record
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("Records should be %d but got %d", 4, len(records))
+	}
+
+	if r := records[2]; r.Action != Drop || r.Rule != "regex:card" || r.Message != "darn 1234-5678" {
+		t.Errorf("Record should be drop of the message but got %+v", r)
+	}
+}
+
+func TestLoadPipeline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moderation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.json")
+	rules := `{
+		"mute_for": "1h",
+		"words": {"list": ["darn"], "action": "mask"},
+		"regex": [{"name": "shout", "pattern": "^[A-Z !]{10,}$", "action": "flag"}],
+		"links": {"deny": ["evil.com"], "action": "mute"}
+	}`
+	if err := ioutil.WriteFile(path, []byte(rules), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPipeline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := p.Check("Bob", "see www.evil.com")
+	if res.Action != Mute || res.MuteFor != time.Hour {
+		t.Errorf("Result should be mute for 1h but got %s for %s", res.Action, res.MuteFor)
+	}
+
+	if res := p.Check("Bob", "HELLO EVERYONE!"); res.Action != Flag {
+		t.Errorf("Action should be %s but got %s", Flag, res.Action)
+	}
+
+	bad := []string{
+		`{"words": {"list": ["x"], "action": "explode"}}`,
+		`{"regex": [{"name": "bad", "pattern": "(", "action": "drop"}]}`,
+		`{"mute_for": "forever"}`,
+		`{"words": {"list": ["x"]}}`,
+		`{"words": {"list": ["x"], "acton": "drop"}}`,
+		`{"regex": [{"name": "card", "pattern": "\\d+"}]}`,
+		`{"links": {"deny": ["evil.com"], "action": "allow"}}`,
+	}
+
+	for _, rules := range bad {
+		ioutil.WriteFile(path, []byte(rules), 0600)
+		if _, err := LoadPipeline(path); err == nil {
+			t.Errorf("Rules %s should be rejected", rules)
+		}
+	}
+}
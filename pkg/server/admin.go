@@ -0,0 +1,296 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Admin implements Admin service for running server
+type Admin struct {
+	Server *Server
+}
+
+// ListSessions method returns all client sessions identified by token prefixes
+func (a *Admin) ListSessions(ctx context.Context, req *chat.ListSessionsRequest) (*chat.ListSessionsResponse, error) {
+	res := new(chat.ListSessionsResponse)
+
+	for _, session := range a.Server.Clients.Sessions() {
+		res.Sessions = append(res.Sessions, &chat.Session{
+			Name: session.Name,
+			Id:   logger.TokenPrefix(session.Token),
+		})
+	}
+
+	return res, nil
+}
+
+// Kick method closes client sessions by name or single session by ID
+func (a *Admin) Kick(ctx context.Context, req *chat.KickRequest) (*chat.KickResponse, error) {
+	var kicked int
+	var name, detail string
+
+	switch target := req.Target.(type) {
+	case *chat.KickRequest_Name:
+		name = NormalizeName(target.Name)
+		kicked = a.Server.KickName(name)
+	case *chat.KickRequest_Id:
+		token, err := a.session(target.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if name, ok = a.Server.Kick(token); ok {
+			kicked = 1
+		}
+		detail = "session " + target.Id
+	default:
+		return nil, status.Error(codes.InvalidArgument, "name or session ID is required")
+	}
+
+	if kicked == 0 {
+		return nil, status.Error(codes.NotFound, "Session not found")
+	}
+
+	a.Server.record(audit.Kick, actor(ctx), name, detail)
+
+	return &chat.KickResponse{Kicked: int32(kicked)}, nil
+}
+
+// session method returns token of the session by its ID
+func (a *Admin) session(id string) (string, error) {
+	var tokens []string
+	for _, session := range a.Server.Clients.Sessions() {
+		if logger.TokenPrefix(session.Token) == id {
+			tokens = append(tokens, session.Token)
+		}
+	}
+
+	switch len(tokens) {
+	case 0:
+		return "", status.Error(codes.NotFound, "Session not found")
+	case 1:
+		return tokens[0], nil
+	}
+
+	return "", status.Error(codes.FailedPrecondition, "Session ID is ambiguous, kick the client by name")
+}
+
+// Ban method forbids client to log in and closes its sessions
+func (a *Admin) Ban(ctx context.Context, req *chat.BanRequest) (*chat.BanResponse, error) {
+	name := NormalizeName(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	// zero duration lifts the ban, missing one is a mistake
+	if req.Duration == nil {
+		return nil, status.Error(codes.InvalidArgument, "duration is required")
+	}
+
+	d, err := ptypes.Duration(req.Duration)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid duration")
+	}
+
+	a.Server.Restrictions.Ban(name, d)
+
+	if d <= 0 {
+		a.Server.Logger.Info("Client is unbanned", "user", name, "actor", actor(ctx))
+		a.Server.record(audit.Unban, actor(ctx), name, "")
+		return new(chat.BanResponse), nil
+	}
+
+	a.Server.Logger.Info("Client is banned", "user", name, "for", d, "actor", actor(ctx))
+	a.Server.record(audit.Ban, actor(ctx), name, "for "+d.String())
+
+	return &chat.BanResponse{Kicked: int32(a.Server.KickName(name))}, nil
+}
+
+// Mute method forbids client to send messages
+func (a *Admin) Mute(ctx context.Context, req *chat.MuteRequest) (*chat.MuteResponse, error) {
+	name := NormalizeName(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	// zero duration lifts the mute, missing one is a mistake
+	if req.Duration == nil {
+		return nil, status.Error(codes.InvalidArgument, "duration is required")
+	}
+
+	d, err := ptypes.Duration(req.Duration)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid duration")
+	}
+
+	a.Server.Restrictions.Mute(name, d)
+
+	if d <= 0 {
+		a.Server.Logger.Info("Client is unmuted", "user", name, "actor", actor(ctx))
+		a.Server.record(audit.Unmute, actor(ctx), name, "")
+		return new(chat.MuteResponse), nil
+	}
+
+	a.Server.Logger.Info("Client is muted", "user", name, "for", d, "actor", actor(ctx))
+	a.Server.record(audit.Mute, actor(ctx), name, "for "+d.String())
+
+	return new(chat.MuteResponse), nil
+}
+
+// Announce method sends server announcement to all chat members
+func (a *Admin) Announce(ctx context.Context, req *chat.AnnounceRequest) (*chat.AnnounceResponse, error) {
+	if req.Message == "" {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+
+	message, err := a.Server.ValidateMessage(req.Message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	a.Server.Announce(message)
+	a.Server.record(audit.Announce, actor(ctx), "", message)
+
+	return new(chat.AnnounceResponse), nil
+}
+
+// ReloadRoles method reads roles of clients from the roles file again
+func (a *Admin) ReloadRoles(ctx context.Context, req *chat.ReloadRolesRequest) (*chat.ReloadRolesResponse, error) {
+	if err := a.Server.Permissions.Reload(); err != nil {
+		a.Server.record(audit.Reload, actor(ctx), "roles", "failed: "+err.Error())
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	a.Server.record(audit.Reload, actor(ctx), "roles", "")
+
+	a.Server.Logger.Info("Roles have been reloaded", "actor", actor(ctx))
+
+	return new(chat.ReloadRolesResponse), nil
+}
+
+// SetLogLevel method changes level of the server logger
+func (a *Admin) SetLogLevel(ctx context.Context, req *chat.SetLogLevelRequest) (*chat.SetLogLevelResponse, error) {
+	current := a.Server.Logger.Level()
+	if req.Level == "" {
+		return &chat.SetLogLevelResponse{Level: current.String(), Previous: current.String()}, nil
+	}
+
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	previous := a.Server.Logger.SetLevel(level)
+	a.Server.Logger.Info("Log level is changed", "level", level, "previous", previous, "actor", actor(ctx))
+	a.Server.record(audit.LogLevel, actor(ctx), "", level.String())
+
+	return &chat.SetLogLevelResponse{Level: level.String(), Previous: previous.String()}, nil
+}
+
+// Stats method returns server statistics
+func (a *Admin) Stats(ctx context.Context, req *chat.StatsRequest) (*chat.StatsResponse, error) {
+	s := a.Server
+
+	return &chat.StatsResponse{
+		Online:         int32(len(s.Online())),
+		Sessions:       int32(len(s.Clients.Sessions())),
+		Logins:         atomic.LoadInt64(&s.stats.logins),
+		Messages:       atomic.LoadInt64(&s.stats.messages),
+		BroadcastQueue: int32(len(s.Broadcast)),
+		Uptime:         ptypes.DurationProto(time.Since(s.started)),
+	}, nil
+}
+
+// Export method sends records of chat history ordered by time
+func (a *Admin) Export(req *chat.ExportRequest, srv chat.Admin_ExportServer) error {
+	if a.Server.History == nil {
+		return status.Error(codes.FailedPrecondition, "History is not kept")
+	}
+
+	var since time.Time
+	if req.Since != nil {
+		var err error
+		if since, err = ptypes.Timestamp(req.Since); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid since")
+		}
+	}
+
+	records := a.Server.History.Range(since, req.Room)
+	for _, r := range records {
+		event := r.Event()
+		if err := srv.Send(&chat.HistoryRecord{Room: r.Room, Event: &event}); err != nil {
+			return err
+		}
+	}
+
+	a.Server.record(audit.Export, actor(srv.Context()), req.Room, fmt.Sprintf("%d records", len(records)))
+
+	return nil
+}
+
+// Import method adds records to chat history, records with kept ids are skipped so import can be repeated
+// imported records are not sent to clients
+func (a *Admin) Import(srv chat.Admin_ImportServer) error {
+	if a.Server.History == nil {
+		return status.Error(codes.FailedPrecondition, "History is not kept")
+	}
+
+	res := new(chat.ImportResponse)
+	defer func() {
+		a.Server.record(audit.Import, actor(srv.Context()), "", fmt.Sprintf("%d imported, %d skipped", res.Imported, res.Skipped))
+	}()
+
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			return srv.SendAndClose(res)
+		} else if err != nil {
+			return err
+		}
+
+		if req.Event == nil {
+			return status.Error(codes.InvalidArgument, "event is required")
+		}
+
+		room := req.Room
+		if room == "" {
+			room = DefaultRoom
+		}
+
+		r, ok, err := history.NewRecord(*req.Event, room)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "event %q: %s", req.Event.Id, err)
+		} else if !ok {
+			return status.Errorf(codes.InvalidArgument, "event %q can not be kept in history", req.Event.Id)
+		}
+
+		if err := r.Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "event %q: %s", r.ID, err)
+		}
+
+		added, err := a.Server.History.Add(r)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		if added {
+			res.Imported++
+		} else {
+			res.Skipped++
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/sc-chat/test-chat/internal/constants"
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/moderation"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminKick(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	a := &Admin{Server: s}
+
+	s.Join("Bob")
+	s.Join("Bob")
+	aliceToken, _ := s.Join("Alice")
+
+	// drain login events
+	for len(s.Broadcast) > 0 {
+		<-s.Broadcast
+	}
+
+	list, err := a.ListSessions(context.Background(), new(chat.ListSessionsRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, session := range list.Sessions {
+		if session.Name == "Alice" && session.Id != logger.TokenPrefix(aliceToken) {
+			t.Errorf("Session ID should be %s but got %s", logger.TokenPrefix(aliceToken), session.Id)
+		}
+	}
+
+	cases := []struct {
+		req    *chat.KickRequest
+		kicked int32
+		code   codes.Code
+	}{
+		{
+			req:    &chat.KickRequest{Target: &chat.KickRequest_Name{Name: "Bob"}},
+			kicked: 2,
+			code:   codes.OK,
+		},
+		{
+			req:  &chat.KickRequest{Target: &chat.KickRequest_Name{Name: "Bob"}},
+			code: codes.NotFound,
+		},
+		{
+			// tokens are not accepted as session IDs
+			req:  &chat.KickRequest{Target: &chat.KickRequest_Id{Id: aliceToken}},
+			code: codes.NotFound,
+		},
+		{
+			req:    &chat.KickRequest{Target: &chat.KickRequest_Id{Id: logger.TokenPrefix(aliceToken)}},
+			kicked: 1,
+			code:   codes.OK,
+		},
+		{
+			req:  new(chat.KickRequest),
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		res, err := a.Kick(context.Background(), tc.req)
+
+		if code := status.Code(err); tc.code != code {
+			t.Errorf("Code should be %s but got %s (%+v)", tc.code, code, tc)
+			continue
+		}
+
+		if err == nil && tc.kicked != res.Kicked {
+			t.Errorf("Kicked should be %d but got %d (%+v)", tc.kicked, res.Kicked, tc)
+		}
+	}
+
+	// each client goes offline once
+	var offline []string
+	for len(s.Broadcast) > 0 {
+		res := <-s.Broadcast
+		if logout := res.GetClientLogout(); logout != nil {
+			offline = append(offline, logout.Name)
+		}
+	}
+
+	if len(offline) != 2 || offline[0] != "Bob" || offline[1] != "Alice" {
+		t.Errorf("Offline should be [Bob Alice] but got %v", offline)
+	}
+}
+
+func TestAdminBanAndMute(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	a := &Admin{Server: s}
+
+	s.Join("Eve")
+
+	res, err := a.Ban(context.Background(), &chat.BanRequest{Name: "Eve", Duration: ptypes.DurationProto(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Kicked != 1 {
+		t.Errorf("Kicked should be %d but got %d", 1, res.Kicked)
+	}
+
+	if _, err := s.Join("Eve"); err != ErrBanned {
+		t.Errorf("Error should be %v but got %v", ErrBanned, err)
+	}
+
+	// missing duration does not lift the ban
+	_, err = a.Ban(context.Background(), &chat.BanRequest{Name: "Eve"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Code should be %s but got %s", codes.InvalidArgument, status.Code(err))
+	}
+
+	if _, err := s.Join("Eve"); err != ErrBanned {
+		t.Errorf("Error should be %v but got %v", ErrBanned, err)
+	}
+
+	// target name is normalized like names of clients
+	if _, err := a.Ban(context.Background(), &chat.BanRequest{Name: " \uff25ve", Duration: ptypes.DurationProto(0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Join("Eve"); err != nil {
+		t.Errorf("Error should be nil but got %v", err)
+	}
+
+	if _, err := a.Mute(context.Background(), &chat.MuteRequest{Name: "Alice", Duration: ptypes.DurationProto(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Say("Alice", "hi"); err != ErrMuted {
+		t.Errorf("Error should be %v but got %v", ErrMuted, err)
+	}
+
+	// missing duration does not lift the mute
+	_, err = a.Mute(context.Background(), &chat.MuteRequest{Name: "Alice"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Code should be %s but got %s", codes.InvalidArgument, status.Code(err))
+	}
+
+	if err := s.Say("Alice", "hi"); err != ErrMuted {
+		t.Errorf("Error should be %v but got %v", ErrMuted, err)
+	}
+
+	if _, err := a.Mute(context.Background(), &chat.MuteRequest{Name: "Alice", Duration: ptypes.DurationProto(0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Say("Alice", "hi"); err != nil {
+		t.Errorf("Error should be nil but got %v", err)
+	}
+}
+
+func TestAdminAnnounce(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	a := &Admin{Server: s}
+
+	cases := []struct {
+		message string
+		code    codes.Code
+	}{
+		{message: "restart at 5pm", code: codes.OK},
+		{message: "", code: codes.InvalidArgument},
+		{message: "\x1b[2Jrestart", code: codes.InvalidArgument},
+		{message: strings.Repeat("a", DefaultLimits.MaxMessageBytes+1), code: codes.InvalidArgument},
+	}
+
+	for _, tc := range cases {
+		_, err := a.Announce(context.Background(), &chat.AnnounceRequest{Message: tc.message})
+
+		if code := status.Code(err); tc.code != code {
+			t.Errorf("Code should be %s but got %s (%q)", tc.code, code, tc.message)
+		}
+	}
+}
+
+func TestAdminInterceptor(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.AdminToken = "secret"
+	s.Permissions.Set(RolesConfig{
+		Users: map[string]Role{"Alice": RoleAdmin, "Bob": RoleModerator, "Dave": RoleAdmin, "Eve": RoleNone},
+	})
+
+	// Alice and Bob prove their names with admin token, Dave only claims the name
+	login := func(name string, md metadata.MD) string {
+		res, err := s.Login(metadata.NewIncomingContext(context.Background(), md), &chat.LoginRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Token
+	}
+	alice := login("Alice", metadata.Pairs(constants.AdminTokenHeader, "secret"))
+	bob := login("Bob", metadata.Pairs(constants.AdminTokenHeader, "secret"))
+	carol := login("Carol", nil)
+	dave := login("Dave", metadata.Pairs(constants.AdminTokenHeader, "wrong"))
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	cases := []struct {
+		method string
+		req    interface{}
+		header string
+		token  string
+		code   codes.Code
+	}{
+		{method: "/chat.Chat/Login", req: &chat.LoginRequest{Name: "Bob"}, code: codes.OK},
+		{method: "/chat.Chat/Login", req: &chat.LoginRequest{Name: "Eve"}, code: codes.PermissionDenied},
+		{method: "/chat.Admin/Kick", code: codes.Unauthenticated},
+		{method: "/chat.Admin/Kick", header: constants.AdminTokenHeader, token: "wrong", code: codes.PermissionDenied},
+		{method: "/chat.Admin/Kick", header: constants.AdminTokenHeader, token: "secret", code: codes.OK},
+		{method: "/chat.Admin/Kick", header: constants.TokenHeader, token: "wrong", code: codes.Unauthenticated},
+		{method: "/chat.Admin/Kick", header: constants.TokenHeader, token: carol, code: codes.PermissionDenied},
+		{method: "/chat.Admin/Kick", header: constants.TokenHeader, token: bob, code: codes.OK},
+		{method: "/chat.Admin/Ban", header: constants.TokenHeader, token: bob, code: codes.PermissionDenied},
+		{method: "/chat.Admin/Ban", header: constants.TokenHeader, token: alice, code: codes.OK},
+		{method: "/chat.Admin/Ban", header: constants.TokenHeader, token: dave, code: codes.PermissionDenied},
+	}
+
+	for _, tc := range cases {
+		ctx := context.Background()
+		if tc.header != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(tc.header, tc.token))
+		}
+
+		_, err := s.unaryInterceptor(ctx, tc.req, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+
+		if code := status.Code(err); tc.code != code {
+			t.Errorf("Code should be %s but got %s (%+v)", tc.code, code, tc)
+		}
+	}
+}
+
+func TestCredential(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.AdminToken = "secret"
+
+	certificate := func(name string) context.Context {
+		state := tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: name}}}},
+		}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+
+	cases := []struct {
+		ctx context.Context
+		ok  bool
+	}{
+		{ctx: context.Background(), ok: false},
+		{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(constants.AdminTokenHeader, "secret")), ok: true},
+		{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(constants.AdminTokenHeader, "wrong")), ok: false},
+		{ctx: certificate("Alice"), ok: true},
+		{ctx: certificate("Bob"), ok: false},
+		{ctx: peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}), ok: false},
+	}
+
+	for i, tc := range cases {
+		if ok := s.credential(tc.ctx, "Alice"); tc.ok != ok {
+			t.Errorf("Credential should be %t but got %t (case %d)", tc.ok, ok, i)
+		}
+	}
+}
+
+func TestSayForbidden(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Permissions.Set(RolesConfig{
+		Rooms: map[string]RoomConfig{DefaultRoom: {Default: RoleGuest}},
+	})
+
+	if err := s.Say("Dave", "hi"); err != ErrForbidden {
+		t.Errorf("Error should be %v but got %v", ErrForbidden, err)
+	}
+}
+
+func TestSayModeration(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Permissions.Set(RolesConfig{Users: map[string]Role{"Mod": RoleModerator}})
+	s.Moderation = moderation.NewPipeline(
+		moderation.NewWordList([]string{"darn"}, moderation.Mask),
+		moderation.NewWordList([]string{"spam"}, moderation.Drop),
+		moderation.NewWordList([]string{"hmm"}, moderation.Flag),
+		moderation.NewWordList([]string{"buy"}, moderation.Mute),
+	)
+
+	mod, _ := s.Join("Mod")
+	stream := s.Clients.AddStream(mod)
+	s.verified.add(mod)
+
+	// drain login events
+	for len(s.Broadcast) > 0 {
+		<-s.Broadcast
+	}
+
+	cases := []struct {
+		message string
+		err     error
+		said    string
+	}{
+		{message: "oh darn", said: "oh ****"},
+		{message: "spam", err: ErrDropped},
+		{message: "hmm darn", said: "hmm ****"},
+		{message: "buy now", err: ErrMuted},
+		{message: "hello", err: ErrMuted},
+	}
+
+	for _, tc := range cases {
+		if err := s.Say("Bob", tc.message); tc.err != err {
+			t.Errorf("Error of %q should be %v but got %v", tc.message, tc.err, err)
+			continue
+		}
+
+		if tc.err != nil {
+			continue
+		}
+
+		res := <-s.Broadcast
+		if said := res.GetClientMessage().Message; tc.said != said {
+			t.Errorf("Message should be %q but got %q", tc.said, said)
+		}
+	}
+
+	select {
+	case res := <-stream:
+		if msg := res.GetServerAnnouncement().Message; !strings.Contains(msg, "flagged") {
+			t.Errorf("Moderator should be notified about flagged message but got %q", msg)
+		}
+	default:
+		t.Error("Moderator should be notified about flagged message")
+	}
+}
+
+func TestAdminAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	s, _ := NewServer("example:8000", false)
+	s.AdminToken = "secret"
+	if s.Audit, err = audit.NewLog(path, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Audit.Close()
+
+	token, _ := s.Join("Eve")
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return (&Admin{Server: s}).Ban(ctx, req.(*chat.BanRequest))
+	}
+
+	req := &chat.BanRequest{Name: "Eve", Duration: ptypes.DurationProto(time.Hour)}
+	info := &grpc.UnaryServerInfo{FullMethod: "/chat.Admin/Ban"}
+
+	wrong := metadata.NewIncomingContext(context.Background(), metadata.Pairs(constants.AdminTokenHeader, "wrong"))
+	s.unaryInterceptor(wrong, req, info, handler)
+
+	valid := metadata.NewIncomingContext(context.Background(), metadata.Pairs(constants.AdminTokenHeader, "secret"))
+	if _, err := s.unaryInterceptor(valid, req, info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	// zero duration is recorded as unban
+	unban := &chat.BanRequest{Name: "Eve", Duration: ptypes.DurationProto(0)}
+	if _, err := s.unaryInterceptor(valid, unban, info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), token) {
+		t.Error("Audit log should not contain full tokens")
+	}
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e audit.Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, e.Type+":"+e.Actor)
+	}
+
+	expected := []string{"login:Eve", "auth_failed:", "ban:admin", "logout:Eve", "unban:admin"}
+	if strings.Join(types, " ") != strings.Join(expected, " ") {
+		t.Errorf("Events should be %v but got %v", expected, types)
+	}
+
+	if _, err := audit.VerifyFiles(path); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxDevices limits number of envelopes of single direct message
+const maxDevices = 32
+
+// envelopeOverhead is size of AEAD tag and padding allowed in ciphertext besides the message
+const envelopeOverhead = 64
+
+var (
+	// ErrNoDeviceKey is returned when client without public key sends direct message
+	ErrNoDeviceKey = errors.New("direct messages require device key")
+
+	// ErrRecipientOffline is returned when recipient of direct message has no devices online
+	ErrRecipientOffline = errors.New("recipient has no devices online")
+
+	// ErrInvalidDirect is returned when direct message has no envelopes or too many of them
+	ErrInvalidDirect = errors.New("invalid direct message")
+)
+
+// sessionKeys keeps public keys of client sessions by token
+type sessionKeys struct {
+	keys map[string][]byte
+	mtx  sync.RWMutex
+}
+
+func (d *sessionKeys) set(token string, key []byte) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.keys[token] = key
+}
+
+func (d *sessionKeys) get(token string) []byte {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+
+	return d.keys[token]
+}
+
+func (d *sessionKeys) remove(token string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	delete(d.keys, token)
+}
+
+func newSessionKeys() *sessionKeys {
+	return &sessionKeys{keys: make(map[string][]byte)}
+}
+
+// addDevice method keeps public key of the session and registers it in shared directory
+func (s *Server) addDevice(name, token string, key []byte) {
+	s.devices.set(token, key)
+
+	if err := s.Directory.Register(name, key); err != nil {
+		s.Logger.Warn("Failed to register device", "user", name, "err", err)
+	}
+}
+
+// removeDevice method removes public key of the session from this instance and shared directory
+func (s *Server) removeDevice(name, token string) {
+	key := s.devices.get(token)
+	if key == nil {
+		return
+	}
+	s.devices.remove(token)
+
+	if err := s.Directory.Unregister(name, key); err != nil {
+		s.Logger.Warn("Failed to unregister device", "user", name, "err", err)
+	}
+}
+
+// Keys method returns public keys of devices of the client which are online on any server instance
+func (s *Server) Keys(ctx context.Context, req *chat.KeysRequest) (*chat.KeysResponse, error) {
+	if _, ok := s.Clients.GetNameByToken(req.Token); !ok {
+		return nil, status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	keys, err := s.Directory.Devices(NormalizeName(req.Name))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &chat.KeysResponse{Keys: keys}, nil
+}
+
+// direct method publishes envelopes of direct message for devices of the recipient and other devices of the sender
+// envelopes are routed through the bus to instances of the devices, the server has no keys of the devices,
+// so it can not read the message
+func (s *Server) direct(name, token string, dm *chat.DirectMessage) error {
+	if s.stopping() {
+		return ErrNotServing
+	}
+
+	if !s.Permissions.Allowed(name, DefaultRoom, PermPost) {
+		return ErrForbidden
+	}
+
+	if s.Restrictions.Muted(name) {
+		return ErrMuted
+	}
+
+	if len(dm.Envelopes) == 0 || len(dm.Envelopes) > maxDevices {
+		return ErrInvalidDirect
+	}
+
+	for _, env := range dm.Envelopes {
+		if len(env.Ciphertext) > s.Limits.MaxMessageBytes+envelopeOverhead {
+			return ErrMessageTooLong
+		}
+	}
+
+	senderKey := s.devices.get(token)
+	if senderKey == nil {
+		return ErrNoDeviceKey
+	}
+
+	// names are normalized like the clients do when they encrypt the message
+	to := NormalizeName(dm.To)
+
+	own, err := s.Directory.Devices(name)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get devices of the sender")
+	}
+
+	keys, err := s.Directory.Devices(to)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get devices of the recipient")
+	}
+
+	// devices by id, true for devices of the recipient
+	targets := make(map[string]bool)
+	for _, key := range own {
+		targets[e2e.DeviceID(key)] = false
+	}
+	for _, key := range keys {
+		targets[e2e.DeviceID(key)] = true
+	}
+	delete(targets, e2e.DeviceID(senderKey))
+
+	now := time.Now()
+	var delivered bool
+
+	for _, env := range dm.Envelopes {
+		recipient, ok := targets[env.Device]
+		if !ok {
+			continue
+		}
+
+		ok = s.Publish(chat.ResponseStream{
+			Id:        history.NewID(now),
+			Timestamp: ptypes.TimestampNow(),
+			Event: &chat.ResponseStream_DirectMessage{
+				DirectMessage: &chat.ResponseStream_Direct{
+					From:       name,
+					To:         to,
+					SenderKey:  senderKey,
+					Nonce:      env.Nonce,
+					Ciphertext: env.Ciphertext,
+					Device:     env.Device,
+				},
+			},
+		})
+		if !ok {
+			return ErrNotServing
+		}
+
+		delivered = delivered || recipient
+	}
+
+	if !delivered {
+		return ErrRecipientOffline
+	}
+
+	return nil
+}
+
+// relay method sends envelope of direct message delivered by the bus to the session of this instance with the device
+func (s *Server) relay(res chat.ResponseStream) {
+	dm := res.GetDirectMessage()
+
+	for _, session := range s.Clients.Sessions() {
+		if session.Name != dm.To && session.Name != dm.From {
+			continue
+		}
+
+		key := s.devices.get(session.Token)
+		if key != nil && e2e.DeviceID(key) == dm.Device && !bytes.Equal(key, dm.SenderKey) {
+			s.Clients.Send(session.Token, res)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+)
+
+func TestDirect(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	ctx := context.Background()
+
+	login := func(name string) (*e2e.Key, string, chan chat.ResponseStream) {
+		key, _ := e2e.GenerateKey()
+
+		res, err := s.Login(ctx, &chat.LoginRequest{Name: name, PublicKey: key.Public()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return key, res.Token, s.Clients.AddStream(res.Token)
+	}
+
+	alice, aliceToken, _ := login("Alice")
+	aliceLaptop, _, aliceLaptopStream := login("Alice")
+	bobPhone, _, bobPhoneStream := login("Bob")
+	bobLaptop, _, bobLaptopStream := login("Bob")
+
+	if _, err := s.Login(ctx, &chat.LoginRequest{Name: "Eve", PublicKey: []byte("short")}); err == nil {
+		t.Error("Login with invalid public key should fail")
+	}
+
+	res, err := s.Keys(ctx, &chat.KeysRequest{Token: aliceToken, Name: "Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Keys) != 2 {
+		t.Fatalf("Bob should have %d devices but got %d", 2, len(res.Keys))
+	}
+
+	dm := &chat.DirectMessage{To: "Bob"}
+	for _, key := range [][]byte{bobPhone.Public(), bobLaptop.Public(), aliceLaptop.Public()} {
+		nonce, ciphertext, err := alice.Seal(key, "Alice", "Bob", []byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dm.Envelopes = append(dm.Envelopes, &chat.Envelope{Device: e2e.DeviceID(key), Nonce: nonce, Ciphertext: ciphertext})
+	}
+
+	broadcast := len(s.Broadcast)
+
+	if err := s.direct("Alice", aliceToken, dm); err != nil {
+		t.Fatal(err)
+	}
+
+	// envelopes are routed through the bus
+	if n := len(s.Broadcast) - broadcast; n != 3 {
+		t.Errorf("Direct message should be published as %d envelopes but got %d", 3, n)
+	}
+	relay(s, s)
+
+	devices := []struct {
+		name   string
+		key    *e2e.Key
+		stream chan chat.ResponseStream
+	}{
+		{name: "Bob phone", key: bobPhone, stream: bobPhoneStream},
+		{name: "Bob laptop", key: bobLaptop, stream: bobLaptopStream},
+		{name: "Alice laptop", key: aliceLaptop, stream: aliceLaptopStream},
+	}
+
+	for _, d := range devices {
+		if len(d.stream) != 1 {
+			t.Errorf("%s should receive %d event but got %d", d.name, 1, len(d.stream))
+			continue
+		}
+
+		res := <-d.stream
+		evt := res.GetDirectMessage()
+		text, err := d.key.Open(evt.SenderKey, evt.From, evt.To, evt.Nonce, evt.Ciphertext)
+		if err != nil || string(text) != "secret" {
+			t.Errorf("%s should decrypt %q but got %q, %v", d.name, "secret", text, err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		dm       *chat.DirectMessage
+		expected error
+	}{
+		{name: "offline", dm: &chat.DirectMessage{To: "Carol", Envelopes: dm.Envelopes}, expected: ErrRecipientOffline},
+		{name: "no envelopes", dm: &chat.DirectMessage{To: "Bob"}, expected: ErrInvalidDirect},
+		{name: "unknown device", dm: &chat.DirectMessage{To: "Bob", Envelopes: []*chat.Envelope{{Device: "unknown"}}}, expected: ErrRecipientOffline},
+	}
+
+	for _, tc := range cases {
+		if err := s.direct("Alice", aliceToken, tc.dm); err != tc.expected {
+			t.Errorf("Error should be %v but got %v (%s)", tc.expected, err, tc.name)
+		}
+	}
+
+	token, _ := s.Join("Carol")
+	if err := s.direct("Carol", token, dm); err != ErrNoDeviceKey {
+		t.Errorf("Error should be %v but got %v", ErrNoDeviceKey, err)
+	}
+
+	s.Permissions.Set(RolesConfig{Default: RoleUser, Users: map[string]Role{"Alice": RoleGuest}})
+	if err := s.direct("Alice", aliceToken, dm); err != ErrForbidden {
+		t.Errorf("Error should be %v but got %v", ErrForbidden, err)
+	}
+}
+
+// relay function delivers direct messages published by the server to sessions of the instance
+func relay(s, instance *Server) {
+	for len(s.Broadcast) > 0 {
+		if res := <-s.Broadcast; res.GetDirectMessage() != nil {
+			instance.relay(res)
+		}
+	}
+}
+
+func TestDirectInstances(t *testing.T) {
+	ctx := context.Background()
+
+	// instances share the directory of devices
+	s, _ := NewServer("example:8000", false)
+	other, _ := NewServer("example:8001", false)
+	other.Directory = s.Directory
+
+	alice, _ := e2e.GenerateKey()
+	bob, _ := e2e.GenerateKey()
+
+	res, err := s.Login(ctx, &chat.LoginRequest{Name: "Alice", PublicKey: alice.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceToken := res.Token
+
+	// full width letter is normalized by the server and the clients
+	res, err = other.Login(ctx, &chat.LoginRequest{Name: "\uff22ob", PublicKey: bob.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobStream := other.Clients.AddStream(res.Token)
+
+	keys, err := s.Keys(ctx, &chat.KeysRequest{Token: aliceToken, Name: "\uff22ob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys.Keys) != 1 {
+		t.Fatalf("Bob should have %d device on other instance but got %d", 1, len(keys.Keys))
+	}
+
+	nonce, ciphertext, err := alice.Seal(bob.Public(), "Alice", "Bob", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dm := &chat.DirectMessage{To: "\uff22ob", Envelopes: []*chat.Envelope{{Device: e2e.DeviceID(bob.Public()), Nonce: nonce, Ciphertext: ciphertext}}}
+	if err := s.direct("Alice", aliceToken, dm); err != nil {
+		t.Fatal(err)
+	}
+	relay(s, other)
+
+	if len(bobStream) != 1 {
+		t.Fatalf("Bob should receive %d event but got %d", 1, len(bobStream))
+	}
+
+	ev := <-bobStream
+	evt := ev.GetDirectMessage()
+	if evt.To != "Bob" {
+		t.Errorf("Recipient should be %s but got %s", "Bob", evt.To)
+	}
+
+	if text, err := bob.Open(evt.SenderKey, evt.From, evt.To, evt.Nonce, evt.Ciphertext); err != nil || string(text) != "secret" {
+		t.Errorf("Bob should decrypt %q but got %q, %v", "secret", text, err)
+	}
+
+	// device is removed from the directory when the session is closed
+	other.Leave(res.Token)
+	if err := s.direct("Alice", aliceToken, dm); err != ErrRecipientOffline {
+		t.Errorf("Error should be %v but got %v", ErrRecipientOffline, err)
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/chat"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxAttachments limits number of files attached to single message
+const maxAttachments = 10
+
+// ErrUnknownAttachment is returned when message refers to file which is not kept
+var ErrUnknownAttachment = errors.New("unknown attachment")
+
+// Upload method keeps file sent by the client in chunks
+func (s *Server) Upload(srv chat.Chat_UploadServer) error {
+	req, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+
+	name, ok := s.Clients.GetNameByToken(req.Token)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	if s.Files == nil {
+		return status.Error(codes.FailedPrecondition, "Files are not kept")
+	}
+
+	if !s.Permissions.Allowed(name, DefaultRoom, PermPost) {
+		return status.Error(codes.PermissionDenied, ErrForbidden.Error())
+	}
+
+	if s.Restrictions.Muted(name) {
+		return status.Error(codes.PermissionDenied, ErrMuted.Error())
+	}
+
+	r := &uploadReader{srv: srv, chunk: req.Chunk}
+
+	b, err := s.Files.Put(name, req.Name, req.Size, req.Sha256, r)
+	switch errors.Cause(err) {
+	case nil:
+	case blob.ErrQuota:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case blob.ErrInvalidFile, blob.ErrSizeMismatch, blob.ErrHashMismatch:
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+
+		s.Logger.Error("Failed to keep file", "user", name, "err", err)
+		return status.Error(codes.Internal, "Failed to keep file")
+	}
+
+	s.Logger.Debug("Client has uploaded a file", "user", name, "token", req.Token, "id", b.ID, "size", b.Size)
+	s.record(audit.Upload, name, b.ID, b.Name)
+
+	return srv.SendAndClose(&chat.UploadResponse{Attachment: attachment(b)})
+}
+
+// Download method sends kept file to the client in chunks
+func (s *Server) Download(req *chat.DownloadRequest, srv chat.Chat_DownloadServer) error {
+	if _, ok := s.Clients.GetNameByToken(req.Token); !ok {
+		return status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	if s.Files == nil {
+		return status.Error(codes.FailedPrecondition, "Files are not kept")
+	}
+
+	b, r, err := s.Files.Open(req.Id)
+	if err == blob.ErrNotFound {
+		return status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		s.Logger.Error("Failed to open file", "id", req.Id, "err", err)
+		return status.Error(codes.Internal, "Failed to open file")
+	}
+	defer r.Close()
+
+	res := &chat.DownloadResponse{Attachment: attachment(b)}
+	buf := make([]byte, blob.ChunkSize)
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			s.Logger.Error("Failed to read file", "id", req.Id, "err", err)
+			return status.Error(codes.Internal, "Failed to read file")
+		}
+
+		// empty file is sent as single response with attachment
+		if n > 0 || res.Attachment != nil {
+			res.Chunk = buf[:n]
+			if err := srv.Send(res); err != nil {
+				return err
+			}
+			res = new(chat.DownloadResponse)
+		}
+
+		if n < len(buf) {
+			return nil
+		}
+	}
+}
+
+// attachments method returns references of kept files attached to message
+func (s *Server) attachments(ids []string) ([]*chat.Attachment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if s.Files == nil || len(ids) > maxAttachments {
+		return nil, ErrUnknownAttachment
+	}
+
+	res := make([]*chat.Attachment, 0, len(ids))
+	for _, id := range ids {
+		b, ok := s.Files.Get(id)
+		if !ok {
+			return nil, ErrUnknownAttachment
+		}
+
+		res = append(res, attachment(b))
+	}
+
+	return res, nil
+}
+
+// attachment function returns reference of kept file
+func attachment(b blob.Blob) *chat.Attachment {
+	return &chat.Attachment{
+		Id:     b.ID,
+		Name:   b.Name,
+		Size:   b.Size,
+		Sha256: b.SHA256,
+	}
+}
+
+// uploadReader reads chunks of uploaded file from the stream
+type uploadReader struct {
+	srv   chat.Chat_UploadServer
+	chunk []byte
+}
+
+// Read method returns io.EOF when the client finishes sending
+func (u *uploadReader) Read(p []byte) (int, error) {
+	for len(u.chunk) == 0 {
+		req, err := u.srv.Recv()
+		if err != nil {
+			return 0, err
+		}
+
+		u.chunk = req.Chunk
+	}
+
+	n := copy(p, u.chunk)
+	u.chunk = u.chunk[n:]
+
+	return n, nil
+}
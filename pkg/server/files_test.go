@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/chat"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFiles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := NewServer(freeAddr(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Files, err = blob.NewStore(t.TempDir(), blob.Quota{MaxFileBytes: 3 * blob.ChunkSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Run(ctx)
+
+	conn, err := grpc.DialContext(ctx, s.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := chat.NewChatClient(conn)
+
+	res, err := c.Login(ctx, &chat.LoginRequest{Name: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(name, content string, size int64) (*chat.UploadResponse, error) {
+		stream, err := c.Upload(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req := &chat.UploadRequest{Token: res.Token, Name: name, Size: size, Sha256: sha256.NewHash(content)}
+		for data := []byte(content); len(data) > 0 || req.Token != ""; req = new(chat.UploadRequest) {
+			n := len(data)
+			if n > blob.ChunkSize {
+				n = blob.ChunkSize
+			}
+
+			req.Chunk, data = data[:n], data[n:]
+			if err := stream.Send(req); err != nil {
+				break
+			}
+		}
+
+		return stream.CloseAndRecv()
+	}
+
+	content := strings.Repeat("log line\n", blob.ChunkSize/4)
+
+	uploaded, err := upload("server.log", content, int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := uploaded.Attachment; a.Name != "server.log" || a.Size != int64(len(content)) || a.Id == "" {
+		t.Errorf("Attachment should describe uploaded file but got %+v", a)
+	}
+
+	if _, err := upload("big.log", content+content, 2*int64(len(content))); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Upload over quota should fail with %s but got %v", codes.ResourceExhausted, err)
+	}
+
+	if _, err := upload("short.log", content, int64(len(content))+1); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Upload of incomplete file should fail with %s but got %v", codes.InvalidArgument, err)
+	}
+
+	stream, err := c.Download(ctx, &chat.DownloadRequest{Token: res.Token, Id: uploaded.Attachment.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	var chunks int
+	for {
+		part, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if (chunks == 0) != (part.Attachment != nil) {
+			t.Errorf("Attachment should be sent in the first chunk only (chunk %d)", chunks)
+		}
+
+		buf.Write(part.Chunk)
+		chunks++
+	}
+
+	if buf.String() != content || chunks != 3 {
+		t.Errorf("Downloaded file should be %d bytes in %d chunks but got %d bytes in %d", len(content), 3, buf.Len(), chunks)
+	}
+
+	unknown, _ := c.Download(ctx, &chat.DownloadRequest{Token: res.Token, Id: "unknown"})
+	if _, err := unknown.Recv(); status.Code(err) != codes.NotFound {
+		t.Errorf("Download of unknown file should fail with %s but got %v", codes.NotFound, err)
+	}
+
+	if err := s.say("Alice", "", &chat.RequestStream{Message: "see log", Attachments: []string{uploaded.Attachment.Id}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.say("Alice", "", &chat.RequestStream{Message: "see log", Attachments: []string{"unknown"}}, nil); err != ErrUnknownAttachment {
+		t.Errorf("Error should be %v but got %v", ErrUnknownAttachment, err)
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/cluster"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// health service names
+const (
+	chatService  = "chat.Chat"
+	adminService = "chat.Admin"
+)
+
+// Health implements grpc.health.v1 Health service
+// the server as a whole ("") and chat.Chat are serving while the server is ready,
+// chat.Admin is serving until the server starts shutting down
+type Health struct {
+	Server *Server
+}
+
+// Check method returns serving status of the service
+func (h *Health) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	var err error
+
+	switch req.Service {
+	case "", chatService:
+		err = h.Server.Ready()
+	case adminService:
+		if !h.Server.Serving() {
+			err = ErrNotServing
+		}
+	default:
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	if err != nil {
+		h.Server.Logger.Debug("Health check failed", "service", req.Service, "err", err)
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// Serving method returns true if the server is running and not shutting down
+func (s *Server) Serving() bool {
+	return atomic.LoadInt32(&s.serving) == 1
+}
+
+// Ready method returns error if the server can not serve clients
+// the server is ready if it is serving, events are broadcast and storage backends are healthy
+func (s *Server) Ready() error {
+	if !s.Serving() {
+		return ErrNotServing
+	}
+
+	if atomic.LoadInt32(&s.broadcasting) == 0 {
+		return errors.New("Broadcast is not running")
+	}
+
+	// broadcast is stuck if the queue is full
+	if len(s.Broadcast) == cap(s.Broadcast) {
+		return errors.New("Broadcast queue is full")
+	}
+
+	backends := []interface{}{s.Bus, s.Presence}
+	if s.History != nil {
+		backends = append(backends, s.History)
+	}
+	if s.Files != nil {
+		backends = append(backends, s.Files)
+	}
+
+	for _, backend := range backends {
+		if c, ok := backend.(cluster.Checker); ok {
+			if err := c.Check(); err != nil {
+				return errors.WithMessage(err, "Storage is unavailable")
+			}
+		}
+	}
+
+	return nil
+}
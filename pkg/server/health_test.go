@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/blob"
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/cluster"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// unavailableBus is event bus which backend is down
+type unavailableBus struct {
+	cluster.Bus
+}
+
+func (b unavailableBus) Check() error {
+	return errors.New("connection refused")
+}
+
+func TestHealth(t *testing.T) {
+	// stores which directories are removed after they are opened
+	unavailableHistory := func(s *Server) {
+		dir := t.TempDir()
+
+		var err error
+		if s.History, err = history.OpenStore(filepath.Join(dir, "history.jsonl")); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(dir)
+	}
+
+	unavailableFiles := func(s *Server) {
+		dir := filepath.Join(t.TempDir(), "files")
+
+		var err error
+		if s.Files, err = blob.NewStore(dir, blob.Quota{}); err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(dir)
+	}
+
+	cases := []struct {
+		name    string
+		setup   func(s *Server)
+		service string
+		status  healthpb.HealthCheckResponse_ServingStatus
+		code    codes.Code
+	}{
+		{
+			name:    "not started",
+			setup:   func(s *Server) {},
+			service: "",
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:    "ready",
+			setup:   start,
+			service: "",
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:    "chat ready",
+			setup:   start,
+			service: chatService,
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "broadcast stopped",
+			setup: func(s *Server) {
+				start(s)
+				s.broadcasting = 0
+			},
+			service: chatService,
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name: "broadcast queue full",
+			setup: func(s *Server) {
+				start(s)
+				for len(s.Broadcast) < cap(s.Broadcast) {
+					s.Broadcast <- chat.ResponseStream{}
+				}
+			},
+			service: "",
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name: "storage unavailable",
+			setup: func(s *Server) {
+				start(s)
+				s.Bus = unavailableBus{s.Bus}
+			},
+			service: "",
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name: "history unavailable",
+			setup: func(s *Server) {
+				start(s)
+				unavailableHistory(s)
+			},
+			service: chatService,
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name: "files unavailable",
+			setup: func(s *Server) {
+				start(s)
+				unavailableFiles(s)
+			},
+			service: chatService,
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name: "storage available",
+			setup: func(s *Server) {
+				start(s)
+
+				var err error
+				if s.History, err = history.OpenStore(filepath.Join(t.TempDir(), "history.jsonl")); err != nil {
+					t.Fatal(err)
+				}
+				if s.Files, err = blob.NewStore(t.TempDir(), blob.Quota{}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			service: chatService,
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "admin with storage unavailable",
+			setup: func(s *Server) {
+				start(s)
+				s.Bus = unavailableBus{s.Bus}
+			},
+			service: adminService,
+			status:  healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "admin during shutdown",
+			setup: func(s *Server) {
+				start(s)
+				s.serving = 0
+			},
+			service: adminService,
+			status:  healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:    "unknown service",
+			setup:   start,
+			service: "chat.Unknown",
+			code:    codes.NotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		s, _ := NewServer("example:8000", false)
+		tc.setup(s)
+
+		res, err := (&Health{Server: s}).Check(context.Background(), &healthpb.HealthCheckRequest{Service: tc.service})
+
+		if code := status.Code(err); tc.code != code {
+			t.Errorf("Code should be %s but got %s (%s)", tc.code, code, tc.name)
+			continue
+		}
+
+		if err == nil && tc.status != res.Status {
+			t.Errorf("Status should be %s but got %s (%s)", tc.status, res.Status, tc.name)
+		}
+	}
+}
+
+// start function marks the server as running without listening
+func start(s *Server) {
+	s.serving = 1
+	s.broadcasting = 1
+}
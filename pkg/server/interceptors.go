@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sc-chat/test-chat/internal/constants"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/chat"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// adminPrefix is common prefix of Admin service methods
+const adminPrefix = "/chat.Admin/"
+
+// actorKey is context key of the client calling Admin service
+type actorKey struct{}
+
+// actor function returns name of the client calling Admin service
+func actor(ctx context.Context) string {
+	if name, ok := ctx.Value(actorKey{}).(string); ok {
+		return name
+	}
+
+	return "server"
+}
+
+// adminPermissions defines permission required for each Admin service method
+var adminPermissions = map[string]Permission{
+	adminPrefix + "ListSessions": PermModerate,
+	adminPrefix + "Kick":         PermModerate,
+	adminPrefix + "Mute":         PermModerate,
+	adminPrefix + "Announce":     PermModerate,
+	adminPrefix + "Ban":          PermAdmin,
+	adminPrefix + "Stats":        PermAdmin,
+	adminPrefix + "ReloadRoles":  PermAdmin,
+	adminPrefix + "SetLogLevel":  PermAdmin,
+	adminPrefix + "Export":       PermAdmin,
+	adminPrefix + "Import":       PermAdmin,
+}
+
+// actorStream is Admin service stream which context carries name of the client
+type actorStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context method returns context of the stream with the actor
+func (a *actorStream) Context() context.Context {
+	return a.ctx
+}
+
+// unaryInterceptor rejects Login of clients without login permission
+// and Admin service calls without valid admin token or client session with required role
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if login, ok := req.(*chat.LoginRequest); ok && !s.Permissions.Allowed(NormalizeName(login.Name), DefaultRoom, PermLogin) {
+		s.record(audit.AuthFailed, NormalizeName(login.Name), "", "not allowed to log in")
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.PermissionDenied, "name is not allowed to log in")
+	}
+
+	if !strings.HasPrefix(info.FullMethod, adminPrefix) {
+		return handler(ctx, req)
+	}
+
+	name, err := s.authorizeAdmin(ctx, info.FullMethod)
+	if err != nil {
+		s.record(audit.AuthFailed, name, "", info.FullMethod+": "+status.Convert(err).Message())
+		return nil, err
+	}
+
+	return handler(context.WithValue(ctx, actorKey{}, name), req)
+}
+
+// streamInterceptor rejects Stream of clients which have lost login permission
+// and Admin service streams without valid admin token or client session with required role
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, adminPrefix) {
+		name, err := s.authorizeAdmin(ss.Context(), info.FullMethod)
+		if err != nil {
+			s.record(audit.AuthFailed, name, "", info.FullMethod+": "+status.Convert(err).Message())
+			return err
+		}
+
+		return handler(srv, &actorStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), actorKey{}, name)})
+	}
+
+	token, ok := s.getToken(ss.Context())
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	if name, ok := s.Clients.GetNameByToken(token); ok && !s.Permissions.Allowed(name, DefaultRoom, PermLogin) {
+		s.record(audit.AuthFailed, name, "", "not allowed to stream")
+		return status.Error(codes.PermissionDenied, "name is not allowed to log in")
+	}
+
+	return handler(srv, ss)
+}
+
+// authorizeAdmin method checks admin token or role of the client session
+// returns name of the client, "admin" if admin token is used
+func (s *Server) authorizeAdmin(ctx context.Context, method string) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if len(md[constants.AdminTokenHeader]) > 0 {
+		token := md[constants.AdminTokenHeader][0]
+		if s.AdminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) != 1 {
+			return "", status.Error(codes.PermissionDenied, "Invalid admin token")
+		}
+
+		return "admin", nil
+	}
+
+	token, ok := s.getToken(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "Missing admin token header")
+	}
+
+	name, ok := s.Clients.GetNameByToken(token)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	perm, ok := adminPermissions[method]
+	if !ok {
+		perm = PermAdmin
+	}
+
+	if !s.Permissions.Allowed(name, DefaultRoom, perm) {
+		return name, status.Error(codes.PermissionDenied, "Role of the client does not allow this method")
+	}
+
+	// anyone can claim the name of a moderator
+	if !s.verified.has(token) {
+		return name, status.Error(codes.PermissionDenied, "Role of the client requires login with admin token or client certificate")
+	}
+
+	return name, nil
+}
+
+// credential method returns true if the login request proves the client name
+// with admin token or client certificate issued for the name
+func (s *Server) credential(ctx context.Context, name string) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md[constants.AdminTokenHeader]) > 0 {
+		token := md[constants.AdminTokenHeader][0]
+		return s.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 {
+		return false
+	}
+
+	return NormalizeName(info.State.VerifiedChains[0][0].Subject.CommonName) == name
+}
+
+// privileged method returns true if the session is verified and the role of the client has the permission
+func (s *Server) privileged(session Session, perm Permission) bool {
+	return s.verified.has(session.Token) && s.Permissions.Allowed(session.Name, DefaultRoom, perm)
+}
+
+// sessionSet keeps tokens of sessions
+type sessionSet struct {
+	tokens map[string]bool
+	mtx    sync.RWMutex
+}
+
+func (v *sessionSet) add(token string) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	v.tokens[token] = true
+}
+
+func (v *sessionSet) has(token string) bool {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+
+	return v.tokens[token]
+}
+
+func (v *sessionSet) remove(token string) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	delete(v.tokens, token)
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRoom is the room of the chat, all clients are its members
+const DefaultRoom = "chat"
+
+// Role of the client
+type Role string
+
+// client roles
+const (
+	RoleNone      Role = "none"
+	RoleGuest     Role = "guest"
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission is a set of allowed actions
+type Permission int
+
+// client permissions
+const (
+	PermLogin Permission = 1 << iota
+	PermPost
+	PermModerate
+	PermAdmin
+)
+
+// rolePermissions defines actions allowed for each role
+var rolePermissions = map[Role]Permission{
+	RoleNone:      0,
+	RoleGuest:     PermLogin,
+	RoleUser:      PermLogin | PermPost,
+	RoleModerator: PermLogin | PermPost | PermModerate,
+	RoleAdmin:     PermLogin | PermPost | PermModerate | PermAdmin,
+}
+
+// RolesConfig struct describes roles of clients by name
+//
+//	{
+//	  "default": "user",
+//	  "users": {"Alice": "admin", "Bob": "moderator"},
+//	  "rooms": {"chat": {"default": "guest", "users": {"Carol": "user"}}}
+//	}
+//
+// Role in a room overrides global role, explicit role of the client overrides default role
+type RolesConfig struct {
+	Default Role                  `json:"default"`
+	Users   map[string]Role       `json:"users"`
+	Rooms   map[string]RoomConfig `json:"rooms"`
+}
+
+// RoomConfig struct describes roles of clients in a room
+type RoomConfig struct {
+	Default Role            `json:"default"`
+	Users   map[string]Role `json:"users"`
+}
+
+// validate method checks that all roles are known
+func (c RolesConfig) validate() error {
+	if err := validateRoles("", c.Default, c.Users); err != nil {
+		return err
+	}
+
+	for room, rc := range c.Rooms {
+		if err := validateRoles("rooms."+room+".", rc.Default, rc.Users); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateRoles function returns error naming the key of unknown role
+func validateRoles(prefix string, def Role, users map[string]Role) error {
+	if _, ok := rolePermissions[def]; def != "" && !ok {
+		return errors.Errorf("Unknown role %q of %sdefault", def, prefix)
+	}
+
+	for name, role := range users {
+		if _, ok := rolePermissions[role]; !ok {
+			return errors.Errorf("Unknown role %q of %susers.%s", role, prefix, name)
+		}
+	}
+
+	return nil
+}
+
+// Permissions keeps roles of clients, roles can be reloaded from the file at runtime
+type Permissions struct {
+	path string
+	cfg  RolesConfig
+	mtx  sync.RWMutex
+}
+
+// Role method returns role of the client in the room
+func (p *Permissions) Role(name, room string) Role {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	rc := p.cfg.Rooms[room]
+
+	if role, ok := rc.Users[name]; ok {
+		return role
+	}
+
+	if role, ok := p.cfg.Users[name]; ok {
+		return role
+	}
+
+	if rc.Default != "" {
+		return rc.Default
+	}
+
+	if p.cfg.Default != "" {
+		return p.cfg.Default
+	}
+
+	return RoleUser
+}
+
+// Names method returns names of clients with explicit roles
+func (p *Permissions) Names() []string {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	var names []string
+	for name := range p.cfg.Users {
+		names = append(names, name)
+	}
+
+	for _, rc := range p.cfg.Rooms {
+		for name := range rc.Users {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Allowed method returns true if the client has permission in the room
+func (p *Permissions) Allowed(name, room string, perm Permission) bool {
+	return rolePermissions[p.Role(name, room)]&perm == perm
+}
+
+// Reload method reads roles from the file again
+// current roles are kept if the file is invalid
+func (p *Permissions) Reload() error {
+	if p.path == "" {
+		return errors.New("Roles file is not configured")
+	}
+
+	cfg, err := readRoles(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.Set(cfg)
+
+	return nil
+}
+
+// Set method replaces roles configuration
+func (p *Permissions) Set(cfg RolesConfig) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.cfg = cfg
+}
+
+func readRoles(path string) (RolesConfig, error) {
+	var cfg RolesConfig
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, errors.WithMessage(err, "failed to read roles file")
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, errors.WithMessage(err, "failed to parse roles file")
+	}
+
+	return cfg, cfg.validate()
+}
+
+// NewPermissions returns Permissions pointer, all clients are users
+func NewPermissions() *Permissions {
+	return new(Permissions)
+}
+
+// LoadPermissions returns Permissions pointer with roles from the file
+func LoadPermissions(path string) (*Permissions, error) {
+	cfg, err := readRoles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Permissions{path: path, cfg: cfg}, nil
+}
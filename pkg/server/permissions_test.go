@@ -0,0 +1,110 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPermissions(t *testing.T) {
+	p := NewPermissions()
+	p.Set(RolesConfig{
+		Default: RoleUser,
+		Users: map[string]Role{
+			"Alice": RoleAdmin,
+			"Bob":   RoleModerator,
+			"Eve":   RoleNone,
+		},
+		Rooms: map[string]RoomConfig{
+			"news": {
+				Default: RoleGuest,
+				Users:   map[string]Role{"Carol": RoleUser, "Bob": RoleGuest},
+			},
+		},
+	})
+
+	cases := []struct {
+		name string
+		room string
+		role Role
+	}{
+		{name: "Alice", room: DefaultRoom, role: RoleAdmin},
+		{name: "Bob", room: DefaultRoom, role: RoleModerator},
+		{name: "Eve", room: DefaultRoom, role: RoleNone},
+		{name: "Dave", room: DefaultRoom, role: RoleUser},
+		{name: "Alice", room: "news", role: RoleAdmin},
+		{name: "Bob", room: "news", role: RoleGuest},
+		{name: "Carol", room: "news", role: RoleUser},
+		{name: "Dave", room: "news", role: RoleGuest},
+	}
+
+	for _, tc := range cases {
+		if role := p.Role(tc.name, tc.room); tc.role != role {
+			t.Errorf("Role of %s in %s should be %s but got %s", tc.name, tc.room, tc.role, role)
+		}
+	}
+
+	if p.Allowed("Dave", "news", PermPost) {
+		t.Error("Guest should not be allowed to post")
+	}
+
+	if !p.Allowed("Bob", DefaultRoom, PermLogin|PermModerate) || p.Allowed("Bob", DefaultRoom, PermAdmin) {
+		t.Error("Moderator should be allowed to moderate but not to administer")
+	}
+
+	if p.Allowed("Eve", DefaultRoom, PermLogin) {
+		t.Error("Client without role should not be allowed to log in")
+	}
+}
+
+func TestPermissionsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "roles.json")
+	write := func(data string) {
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"users": {"Alice": "admin"}}`)
+
+	p, err := LoadPermissions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if role := p.Role("Alice", DefaultRoom); role != RoleAdmin {
+		t.Errorf("Role should be %s but got %s", RoleAdmin, role)
+	}
+
+	write(`{"users": {"Alice": "moderator"}}`)
+	if err := p.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if role := p.Role("Alice", DefaultRoom); role != RoleModerator {
+		t.Errorf("Role should be %s but got %s", RoleModerator, role)
+	}
+
+	// invalid file keeps current roles
+	write(`{"rooms": {"chat": {"users": {"Alice": "owner"}}}}`)
+	err = p.Reload()
+	if err == nil || !strings.Contains(err.Error(), "rooms.chat.users.Alice") {
+		t.Errorf("Error should name the key but got %v", err)
+	}
+
+	if role := p.Role("Alice", DefaultRoom); role != RoleModerator {
+		t.Errorf("Role should be %s but got %s", RoleModerator, role)
+	}
+
+	if err := NewPermissions().Reload(); err == nil {
+		t.Error("Reload without roles file should fail")
+	}
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// violationWindow is the period in which throttled messages are counted as repeated violations
+const violationWindow = time.Minute
+
+// RateLimitConfig struct describes message rate limits
+// rates are messages per second, zero rate disables the limit
+type RateLimitConfig struct {
+	// TokenRate and TokenBurst limit single client session
+	TokenRate  float64 `yaml:"token_rate"`
+	TokenBurst int     `yaml:"token_burst"`
+
+	// NameRate and NameBurst limit all sessions of the client together
+	NameRate  float64 `yaml:"name_rate"`
+	NameBurst int     `yaml:"name_burst"`
+
+	// Violations is number of throttled messages which mutes the client for MuteFor, zero disables muting
+	Violations int           `yaml:"violations"`
+	MuteFor    time.Duration `yaml:"mute_for"`
+}
+
+// DefaultRateLimits are rate limits of new server
+var DefaultRateLimits = RateLimitConfig{
+	TokenRate:  5,
+	TokenBurst: 10,
+	NameRate:   10,
+	NameBurst:  20,
+	Violations: 20,
+	MuteFor:    time.Minute,
+}
+
+// bucket of tokens refilled at constant rate
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter keeps token buckets by key
+type limiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+}
+
+// refill method returns bucket of the key refilled up to now, nil if the limit is disabled
+func (l *limiter) refill(key string, now time.Time) *bucket {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	return b
+}
+
+// allow method takes token from the bucket of the key
+func (l *limiter) allow(key string, now time.Time) bool {
+	return take(l.refill(key, now))
+}
+
+// take function takes token from all buckets if each of them has one
+func take(buckets ...*bucket) bool {
+	for _, b := range buckets {
+		if b != nil && b.tokens < 1 {
+			return false
+		}
+	}
+
+	for _, b := range buckets {
+		if b != nil {
+			b.tokens--
+		}
+	}
+
+	return true
+}
+
+// cleanup method removes full buckets which do not limit anything
+func (l *limiter) cleanup(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// set method changes rate and burst of the limiter keeping buckets, so that throttled clients stay throttled
+// buckets are refilled at the previous rate up to now and cut to the new burst
+func (l *limiter) set(rate float64, burst int, now time.Time) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	for key := range l.buckets {
+		l.refill(key, now)
+	}
+
+	l.rate = rate
+	l.burst = float64(burst)
+
+	if rate <= 0 {
+		l.buckets = make(map[string]*bucket)
+		return
+	}
+
+	for _, b := range l.buckets {
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+	}
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// violation of the rate limit by the client
+type violation struct {
+	count int
+	first time.Time
+}
+
+// RateLimits limits messages of client sessions and names with token buckets
+type RateLimits struct {
+	cfg        RateLimitConfig
+	tokens     *limiter
+	names      *limiter
+	violations map[string]*violation
+	checks     int
+	mtx        sync.Mutex
+}
+
+// Allow method takes token from buckets of the session and the client
+// returns false if the message is throttled and true as the second value if the client should be muted
+func (r *RateLimits) Allow(name, token string) (bool, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+
+	// buckets of gone clients are not kept forever
+	r.checks++
+	if r.checks%1000 == 0 {
+		r.tokens.cleanup(now)
+		r.names.cleanup(now)
+
+		for name, v := range r.violations {
+			if now.Sub(v.first) > violationWindow {
+				delete(r.violations, name)
+			}
+		}
+	}
+
+	// throttled messages do not spend limits
+	if take(r.tokens.refill(token, now), r.names.refill(name, now)) {
+		return true, false
+	}
+
+	if r.cfg.Violations <= 0 {
+		return false, false
+	}
+
+	v, ok := r.violations[name]
+	if !ok || now.Sub(v.first) > violationWindow {
+		v = &violation{first: now}
+		r.violations[name] = v
+	}
+
+	v.count++
+	if v.count < r.cfg.Violations {
+		return false, false
+	}
+
+	delete(r.violations, name)
+	return false, true
+}
+
+// Config method returns rate limits configuration
+func (r *RateLimits) Config() RateLimitConfig {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.cfg
+}
+
+// Update method replaces rate limits configuration, buckets and violations of clients are kept
+func (r *RateLimits) Update(cfg RateLimitConfig) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+
+	r.cfg = cfg
+	r.tokens.set(cfg.TokenRate, cfg.TokenBurst, now)
+	r.names.set(cfg.NameRate, cfg.NameBurst, now)
+}
+
+// NewRateLimits returns RateLimits pointer
+func NewRateLimits(cfg RateLimitConfig) *RateLimits {
+	return &RateLimits{
+		cfg:        cfg,
+		tokens:     newLimiter(cfg.TokenRate, cfg.TokenBurst),
+		names:      newLimiter(cfg.NameRate, cfg.NameBurst),
+		violations: make(map[string]*violation),
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(10, 3)
+	now := time.Now()
+
+	cases := []struct {
+		after time.Duration
+		allow bool
+	}{
+		{after: 0, allow: true},
+		{after: 0, allow: true},
+		{after: 0, allow: true},
+		{after: 0, allow: false},
+		{after: 50 * time.Millisecond, allow: false},
+		{after: 100 * time.Millisecond, allow: true},
+		{after: 100 * time.Millisecond, allow: false},
+		{after: time.Hour, allow: true},
+		{after: time.Hour, allow: true},
+		{after: time.Hour, allow: true},
+		{after: time.Hour, allow: false},
+	}
+
+	for i, tc := range cases {
+		if allow := l.allow("key", now.Add(tc.after)); tc.allow != allow {
+			t.Errorf("Allow #%d should be %t but got %t", i, tc.allow, allow)
+		}
+	}
+
+	l.cleanup(now.Add(2 * time.Hour))
+	if len(l.buckets) != 0 {
+		t.Errorf("Buckets should be %d but got %d", 0, len(l.buckets))
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	r := NewRateLimits(RateLimitConfig{
+		TokenRate:  0.001,
+		TokenBurst: 2,
+		NameRate:   0.001,
+		NameBurst:  3,
+		Violations: 3,
+		MuteFor:    time.Minute,
+	})
+
+	cases := []struct {
+		token string
+		allow bool
+		mute  bool
+	}{
+		{token: "a", allow: true},
+		{token: "a", allow: true},
+		// session limit
+		{token: "a", allow: false},
+		// name limit is shared by sessions
+		{token: "b", allow: true},
+		{token: "b", allow: false},
+		{token: "c", allow: false, mute: true},
+		// violations are counted again after mute
+		{token: "c", allow: false},
+	}
+
+	for i, tc := range cases {
+		allow, mute := r.Allow("Bob", tc.token)
+		if tc.allow != allow || tc.mute != mute {
+			t.Errorf("Allow #%d should be %t/%t but got %t/%t", i, tc.allow, tc.mute, allow, mute)
+		}
+	}
+
+	if allow, _ := r.Allow("Alice", "d"); !allow {
+		t.Error("Limits of other client should not be affected")
+	}
+}
+
+func TestRateLimitsUpdate(t *testing.T) {
+	cfg := RateLimitConfig{TokenRate: 0.001, TokenBurst: 2, Violations: 3, MuteFor: time.Minute}
+	r := NewRateLimits(cfg)
+
+	for i := 0; i < 2; i++ {
+		r.Allow("Bob", "a")
+	}
+
+	if allow, _ := r.Allow("Bob", "a"); allow {
+		t.Fatal("Session should be throttled")
+	}
+
+	// reload keeps buckets and violations of throttled clients
+	r.Update(cfg)
+
+	if allow, _ := r.Allow("Bob", "a"); allow {
+		t.Error("Session should stay throttled after update")
+	}
+
+	if allow, mute := r.Allow("Bob", "a"); allow || !mute {
+		t.Errorf("Violations should be kept after update but got %t/%t", allow, mute)
+	}
+
+	// new burst is applied
+	r.Update(RateLimitConfig{TokenRate: 0.001, TokenBurst: 1})
+
+	if allow, _ := r.Allow("Alice", "b"); !allow {
+		t.Error("New session should be allowed")
+	}
+
+	if allow, _ := r.Allow("Alice", "b"); allow {
+		t.Error("New burst should be applied")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.RateLimits = NewRateLimits(RateLimitConfig{
+		TokenRate:  0.001,
+		TokenBurst: 1,
+		Violations: 2,
+		MuteFor:    time.Hour,
+	})
+
+	cases := []error{nil, ErrThrottled, ErrMuted}
+
+	for i, expected := range cases {
+		if err := s.Throttle("Eve", "token"); expected != err {
+			t.Errorf("Error #%d should be %v but got %v", i, expected, err)
+		}
+	}
+
+	if !s.Restrictions.Muted("Eve") {
+		t.Error("Flooding client should be muted")
+	}
+
+	s.RateLimits = NewRateLimits(RateLimitConfig{})
+	for i := 0; i < 100; i++ {
+		if err := s.Throttle("Bob", "token"); err != nil {
+			t.Fatalf("Error should be nil but got %v", err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/history"
+	"github.com/sc-chat/test-chat/pkg/moderation"
+)
+
+// Settings are parts of server configuration which can be changed without restart
+type Settings struct {
+	RateLimits RateLimitConfig
+	Level      logger.Level
+
+	// Restrictions are added to current bans and mutes, nothing is added if nil
+	Restrictions *Restrictions
+	// Moderation replaces moderation rules, current rules are kept if nil
+	Moderation *moderation.Config
+	// Certificate replaces TLS certificate for new connections, current certificate is kept if nil
+	Certificate *tls.Certificate
+	// Retention replaces retention rules of history, current rules are kept if nil
+	Retention *history.Retention
+}
+
+// Reload method applies loaded settings without dropping connections
+// current settings are kept if loading or any of the settings fails, the result is logged and sent to admins
+func (s *Server) Reload(load func() (Settings, error)) error {
+	err := s.reload(load)
+	if err != nil {
+		s.Logger.Error("Failed to reload config", "err", err)
+		s.record(audit.Reload, "signal", "config", "failed: "+err.Error())
+		s.notifyAdmins("Config reload failed: " + err.Error())
+
+		return err
+	}
+
+	s.Logger.Info("Config is reloaded", "level", s.Logger.Level())
+	s.record(audit.Reload, "signal", "config", "")
+	s.notifyAdmins("Config is reloaded")
+
+	return nil
+}
+
+func (s *Server) reload(load func() (Settings, error)) error {
+	settings, err := load()
+	if err != nil {
+		return err
+	}
+
+	// everything which can fail is checked before the settings are applied
+	if settings.Moderation != nil {
+		if err := moderation.NewPipeline().Apply(*settings.Moderation); err != nil {
+			return errors.WithMessage(err, "invalid moderation rules")
+		}
+	}
+
+	if settings.Certificate != nil && s.TLS == nil {
+		return errors.New("TLS can not be enabled without restart")
+	}
+
+	var roles *RolesConfig
+	if s.Permissions.path != "" {
+		cfg, err := readRoles(s.Permissions.path)
+		if err != nil {
+			return err
+		}
+		roles = &cfg
+	}
+
+	s.RateLimits.Update(settings.RateLimits)
+	s.Logger.SetLevel(settings.Level)
+
+	if settings.Restrictions != nil {
+		s.Restrictions.Merge(settings.Restrictions)
+	}
+
+	if settings.Moderation != nil {
+		s.Moderation.Apply(*settings.Moderation)
+	}
+
+	if settings.Certificate != nil {
+		s.certificate.Store(settings.Certificate)
+	}
+
+	if roles != nil {
+		s.Permissions.Set(*roles)
+	}
+
+	if settings.Retention != nil && s.History != nil {
+		s.History.SetRetention(*settings.Retention)
+	}
+
+	return nil
+}
+
+// tlsConfig method returns TLS config of the server which certificate can be replaced by reload
+func (s *Server) tlsConfig() *tls.Config {
+	cfg := s.TLS.Clone()
+	if len(cfg.Certificates) == 0 {
+		return cfg
+	}
+
+	s.certificate.Store(&cfg.Certificates[0])
+	cfg.Certificates = nil
+	cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return s.certificate.Load().(*tls.Certificate), nil
+	}
+
+	return cfg
+}
+
+// notifyAdmins method sends server announcement to sessions of admins
+func (s *Server) notifyAdmins(message string) {
+	for _, session := range s.Clients.Sessions() {
+		if s.privileged(session, PermAdmin) {
+			s.notify(session.Token, message)
+		}
+	}
+}
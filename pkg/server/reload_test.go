@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/internal/logger"
+	"github.com/sc-chat/test-chat/pkg/moderation"
+
+	"google.golang.org/grpc"
+)
+
+func TestReload(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Permissions.Set(RolesConfig{Users: map[string]Role{"Root": RoleAdmin, "Mod": RoleModerator}})
+	s.Restrictions.Ban("Eve", time.Hour)
+
+	admin, _ := s.Join("Root")
+	adminStream := s.Clients.AddStream(admin)
+	mod, _ := s.Join("Mod")
+	modStream := s.Clients.AddStream(mod)
+	s.verified.add(admin)
+	s.verified.add(mod)
+
+	banned := NewRestrictions()
+	banned.Ban("Mallory", time.Hour)
+
+	limits := RateLimitConfig{TokenRate: 1, TokenBurst: 2}
+
+	err := s.Reload(func() (Settings, error) {
+		return Settings{
+			RateLimits:   limits,
+			Level:        logger.DebugLevel,
+			Restrictions: banned,
+			Moderation: &moderation.Config{
+				Words: &moderation.WordsConfig{List: []string{"darn"}, Action: moderation.Mask},
+			},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg := s.RateLimits.Config(); cfg != limits {
+		t.Errorf("Rate limits should be %+v but got %+v", limits, cfg)
+	}
+
+	if level := s.Logger.Level(); level != logger.DebugLevel {
+		t.Errorf("Level should be %s but got %s", logger.DebugLevel, level)
+	}
+
+	for _, name := range []string{"Eve", "Mallory"} {
+		if !s.Restrictions.Banned(name) {
+			t.Errorf("%s should be banned", name)
+		}
+	}
+
+	if text := s.Moderation.Check("Bob", "oh darn").Text; text != "oh ****" {
+		t.Errorf("Message should be %q but got %q", "oh ****", text)
+	}
+
+	res := <-adminStream
+	if msg := res.GetServerAnnouncement().Message; msg != "Config is reloaded" {
+		t.Errorf("Admin should be notified about reload but got %q", msg)
+	}
+
+	if len(modStream) != 0 {
+		t.Error("Moderator should not be notified about reload")
+	}
+
+	// invalid rules keep current settings
+	err = s.Reload(func() (Settings, error) {
+		return Settings{
+			Level: logger.ErrorLevel,
+			Moderation: &moderation.Config{
+				Regex: []moderation.RegexConfig{{Name: "bad", Pattern: "(", Action: moderation.Drop}},
+			},
+		}, nil
+	})
+	if err == nil {
+		t.Fatal("Reload with invalid rules should fail")
+	}
+
+	// rule without action would allow everything
+	err = s.Reload(func() (Settings, error) {
+		return Settings{
+			Level:      logger.ErrorLevel,
+			Moderation: &moderation.Config{Words: &moderation.WordsConfig{List: []string{"darn"}}},
+		}, nil
+	})
+	if err == nil {
+		t.Fatal("Reload with rule without action should fail")
+	}
+
+	if level := s.Logger.Level(); level != logger.DebugLevel {
+		t.Errorf("Level should be %s but got %s", logger.DebugLevel, level)
+	}
+
+	if cfg := s.RateLimits.Config(); cfg != limits {
+		t.Errorf("Rate limits should be %+v but got %+v", limits, cfg)
+	}
+
+	res = <-adminStream
+	if msg := res.GetServerAnnouncement().Message; !strings.HasPrefix(msg, "Config reload failed") {
+		t.Errorf("Admin should be notified about failed reload but got %q", msg)
+	}
+}
+
+func TestReloadState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := NewServer(freeAddr(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.DrainTimeout = 0
+	s.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	// restrictions are loaded from the state file of the previous run
+	s.Restrictions.Ban("Eve", time.Hour)
+	s.Restrictions.Mute("Mallory", time.Hour)
+	if err := s.Restrictions.Save(s.StateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	conn, err := grpc.DialContext(ctx, s.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	load := func() (Settings, error) {
+		r, err := LoadRestrictions(s.StateFile)
+		return Settings{RateLimits: s.RateLimits.Config(), Level: s.Logger.Level(), Restrictions: r}, err
+	}
+
+	// lifted restrictions are not brought back by the state file
+	s.Restrictions.Ban("Eve", 0)
+	s.Restrictions.Mute("Mallory", 0)
+
+	if err := s.Reload(load); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Restrictions.Banned("Eve") {
+		t.Error("Lifted ban should not be restored by reload")
+	}
+
+	if s.Restrictions.Muted("Mallory") {
+		t.Error("Lifted mute should not be restored by reload")
+	}
+
+	// restrictions added to the file are applied
+	r := NewRestrictions()
+	r.Ban("Trent", time.Hour)
+	if err := r.Save(s.StateFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Reload(load); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Restrictions.Banned("Trent") {
+		t.Error("Ban added to the state file should be applied by reload")
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Restrictions keeps temporary bans and mutes of clients by name
+type Restrictions struct {
+	// OnChange is called after bans or mutes are changed, expired restrictions do not call it
+	OnChange func()
+
+	bans  map[string]time.Time
+	mutes map[string]time.Time
+	mtx   sync.Mutex
+}
+
+// Ban method forbids client to log in for duration, zero duration lifts the ban
+func (r *Restrictions) Ban(name string, d time.Duration) {
+	r.set(r.bans, name, d)
+}
+
+// Banned method returns true if the client is banned
+func (r *Restrictions) Banned(name string) bool {
+	return r.active(r.bans, name)
+}
+
+// Mute method forbids client to send messages for duration, zero duration lifts the mute
+func (r *Restrictions) Mute(name string, d time.Duration) {
+	r.set(r.mutes, name, d)
+}
+
+// Muted method returns true if the client is muted
+func (r *Restrictions) Muted(name string) bool {
+	return r.active(r.mutes, name)
+}
+
+func (r *Restrictions) set(m map[string]time.Time, name string, d time.Duration) {
+	r.mtx.Lock()
+	if d <= 0 {
+		delete(m, name)
+	} else {
+		m[name] = time.Now().Add(d)
+	}
+	r.mtx.Unlock()
+
+	r.changed()
+}
+
+// changed method calls OnChange hook outside of the lock
+func (r *Restrictions) changed() {
+	if r.OnChange != nil {
+		r.OnChange()
+	}
+}
+
+func (r *Restrictions) active(m map[string]time.Time, name string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	until, ok := m[name]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(until) {
+		delete(m, name)
+		return false
+	}
+
+	return true
+}
+
+// Merge method adds restrictions of other, restriction which expires later wins
+func (r *Restrictions) Merge(other *Restrictions) {
+	other.mtx.Lock()
+	bans, mutes := unexpired(other.bans), unexpired(other.mutes)
+	other.mtx.Unlock()
+
+	r.mtx.Lock()
+	merge(r.bans, bans)
+	merge(r.mutes, mutes)
+	r.mtx.Unlock()
+
+	r.changed()
+}
+
+func merge(dst, src map[string]time.Time) {
+	for name, until := range src {
+		if until.After(dst[name]) {
+			dst[name] = until
+		}
+	}
+}
+
+// restrictionsFile is JSON representation of restrictions with expiration times
+type restrictionsFile struct {
+	Bans  map[string]time.Time `json:"bans"`
+	Mutes map[string]time.Time `json:"mutes"`
+}
+
+// Save method writes active restrictions to JSON file, the file is replaced atomically
+func (r *Restrictions) Save(path string) error {
+	r.mtx.Lock()
+	rf := restrictionsFile{
+		Bans:  unexpired(r.bans),
+		Mutes: unexpired(r.mutes),
+	}
+	r.mtx.Unlock()
+
+	data, err := json.MarshalIndent(rf, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.WithMessage(err, "failed to save restrictions")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.WithMessage(err, "failed to save restrictions")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.WithMessage(err, "failed to save restrictions")
+	}
+
+	return errors.WithMessage(os.Rename(tmp.Name(), path), "failed to save restrictions")
+}
+
+// unexpired function returns copy of restrictions which are not expired
+func unexpired(m map[string]time.Time) map[string]time.Time {
+	now := time.Now()
+
+	res := make(map[string]time.Time, len(m))
+	for name, until := range m {
+		if until.After(now) {
+			res[name] = until
+		}
+	}
+
+	return res
+}
+
+// LoadRestrictions returns Restrictions pointer with restrictions saved to JSON file
+// missing file gives no restrictions
+func LoadRestrictions(path string) (*Restrictions, error) {
+	r := NewRestrictions()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "failed to read restrictions")
+	}
+
+	var rf restrictionsFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse restrictions")
+	}
+
+	for name, until := range unexpired(rf.Bans) {
+		r.bans[name] = until
+	}
+
+	for name, until := range unexpired(rf.Mutes) {
+		r.mutes[name] = until
+	}
+
+	return r, nil
+}
+
+// NewRestrictions returns Restrictions pointer
+func NewRestrictions() *Restrictions {
+	return &Restrictions{
+		bans:  make(map[string]time.Time),
+		mutes: make(map[string]time.Time),
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestrictions(t *testing.T) {
+	r := NewRestrictions()
+
+	r.Ban("Eve", time.Hour)
+	r.Mute("Mallory", time.Hour)
+	r.Mute("Bob", time.Nanosecond)
+	r.Ban("Trent", time.Hour)
+	r.Ban("Trent", 0)
+
+	time.Sleep(time.Millisecond)
+
+	cases := []struct {
+		name   string
+		banned bool
+		muted  bool
+	}{
+		{name: "Eve", banned: true, muted: false},
+		{name: "Mallory", banned: false, muted: true},
+		{name: "Bob", banned: false, muted: false},
+		{name: "Trent", banned: false, muted: false},
+	}
+
+	for _, tc := range cases {
+		if banned := r.Banned(tc.name); tc.banned != banned {
+			t.Errorf("Banned should be %t but got %t (%+v)", tc.banned, banned, tc)
+		}
+
+		if muted := r.Muted(tc.name); tc.muted != muted {
+			t.Errorf("Muted should be %t but got %t (%+v)", tc.muted, muted, tc)
+		}
+	}
+}
+
+func TestRestrictionsSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	r := NewRestrictions()
+	r.Ban("Eve", time.Hour)
+	r.Mute("Mallory", time.Hour)
+	r.Mute("Bob", time.Nanosecond)
+
+	time.Sleep(time.Millisecond)
+
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRestrictions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !loaded.Banned("Eve") || !loaded.Muted("Mallory") || loaded.Muted("Bob") {
+		t.Errorf("Restrictions should be %v but got %v", r, loaded)
+	}
+
+	if _, err := LoadRestrictions(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Missing file should give no restrictions but got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCompactInterval is period of purging history by its retention rules
+const DefaultCompactInterval = time.Hour
+
+// compact method purges history by its retention rules periodically until the context is done
+func (s *Server) compact(ctx context.Context) {
+	if s.CompactInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.CompactInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Compact(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compact method purges history records which exceed retention rules at the time
+// returns number of purged records
+func (s *Server) Compact(now time.Time) int {
+	purged, err := s.History.Compact(now)
+
+	atomic.AddInt64(&s.stats.compactions, 1)
+	atomic.AddInt64(&s.stats.purged, int64(purged))
+
+	if err != nil {
+		s.Logger.Error("Failed to compact history", "purged", purged, "err", err)
+	} else if purged > 0 {
+		s.Logger.Info("History is compacted", "purged", purged, "records", s.History.Len())
+	}
+
+	return purged
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/pkg/history"
+)
+
+func TestCompact(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.History = history.NewStore()
+
+	for _, message := range []string{"first", "second", "third"} {
+		if err := s.Say("Alice", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for len(s.Broadcast) > 0 {
+		s.keep(<-s.Broadcast)
+	}
+
+	if n := s.Compact(time.Now()); n != 0 {
+		t.Errorf("Nothing should be purged without retention rules but got %d", n)
+	}
+
+	err := s.Reload(func() (Settings, error) {
+		return Settings{Retention: &history.Retention{Global: history.Rule{MaxCount: 1}}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := s.Compact(time.Now()); n != 2 {
+		t.Errorf("Purged records should be %d but got %d", 2, n)
+	}
+
+	if n := s.History.Len(); n != 1 {
+		t.Errorf("History should keep %d record but got %d", 1, n)
+	}
+
+	if purged := s.stats.purged; purged != 2 {
+		t.Errorf("Purged metric should be %d but got %d", 2, purged)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxSearchLimit limits number of messages returned by single search
+const maxSearchLimit = 100
+
+// Search method returns messages from history matching the query, newest first
+func (s *Server) Search(ctx context.Context, req *chat.SearchRequest) (*chat.SearchResponse, error) {
+	if _, ok := s.Clients.GetNameByToken(req.Token); !ok {
+		return nil, status.Error(codes.Unauthenticated, "Invalid token")
+	}
+
+	if s.History == nil {
+		return nil, status.Error(codes.FailedPrecondition, "History is not kept")
+	}
+
+	q := history.Query{
+		Terms:  req.Query,
+		Author: req.Author,
+		Room:   req.Room,
+		Limit:  int(req.Limit),
+	}
+
+	var err error
+	if req.Since != nil {
+		if q.Since, err = ptypes.Timestamp(req.Since); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid since")
+		}
+	}
+
+	if req.Until != nil {
+		if q.Until, err = ptypes.Timestamp(req.Until); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid until")
+		}
+	}
+
+	if q.Limit < 0 || q.Limit > maxSearchLimit {
+		q.Limit = maxSearchLimit
+	}
+
+	res := new(chat.SearchResponse)
+	for _, r := range s.History.Search(q) {
+		event := r.Event()
+		res.Messages = append(res.Messages, &event)
+	}
+
+	return res, nil
+}
+
+// keep method adds event delivered by the bus to history
+func (s *Server) keep(res chat.ResponseStream) {
+	if s.History == nil {
+		return
+	}
+
+	r, ok, err := history.NewRecord(res, DefaultRoom)
+	if err != nil {
+		s.Logger.Error("Failed to keep event in history", "id", res.Id, "err", err)
+		return
+	} else if !ok {
+		return
+	}
+
+	if _, err := s.History.Add(r); err != nil {
+		s.Logger.Error("Failed to keep event in history", "id", r.ID, "err", err)
+	}
+}
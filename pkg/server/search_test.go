@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSearch(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+
+	token, _ := s.Join("Alice")
+
+	if _, err := s.Search(context.Background(), &chat.SearchRequest{Token: token, Query: "hello"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Search without history should fail with %s but got %v", codes.FailedPrecondition, err)
+	}
+
+	s.History = history.NewStore()
+
+	for _, message := range []string{"Hello world", "unrelated", "HELLO again"} {
+		if err := s.Say("Alice", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// events are kept when they are delivered by the bus
+	for len(s.Broadcast) > 0 {
+		s.keep(<-s.Broadcast)
+	}
+
+	if n := s.History.Len(); n != 4 {
+		t.Errorf("History should keep %d events but got %d", 4, n)
+	}
+
+	res, err := s.Search(context.Background(), &chat.SearchRequest{Token: token, Query: "hello", Author: "Alice", Room: DefaultRoom})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"HELLO again", "Hello world"}
+	if len(res.Messages) != len(expected) {
+		t.Fatalf("Search should find %d messages but got %d", len(expected), len(res.Messages))
+	}
+
+	for i, msg := range res.Messages {
+		if text := msg.GetClientMessage().GetMessage(); text != expected[i] {
+			t.Errorf("Message should be %q but got %q", expected[i], text)
+		}
+
+		if msg.Id == "" {
+			t.Error("Message should have id")
+		}
+	}
+
+	if _, err := s.Search(context.Background(), &chat.SearchRequest{Token: "unknown", Query: "hello"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Search with invalid token should fail with %s but got %v", codes.Unauthenticated, err)
+	}
+}
@@ -2,29 +2,68 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
-	"log"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
 
 	"github.com/sc-chat/test-chat/internal/constants"
-	"github.com/sc-chat/test-chat/internal/debug"
+	"github.com/sc-chat/test-chat/internal/logger"
 	"github.com/sc-chat/test-chat/internal/randint"
 	"github.com/sc-chat/test-chat/internal/sha256"
+	"github.com/sc-chat/test-chat/pkg/audit"
+	"github.com/sc-chat/test-chat/pkg/blob"
 	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/cluster"
+	"github.com/sc-chat/test-chat/pkg/e2e"
+	"github.com/sc-chat/test-chat/pkg/history"
+	"github.com/sc-chat/test-chat/pkg/metrics"
+	"github.com/sc-chat/test-chat/pkg/moderation"
+	"github.com/sc-chat/test-chat/pkg/sign"
+	"github.com/sc-chat/test-chat/pkg/trace"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 const tokenHeader = "x-token"
 
+// DefaultBroadcastBuffer is number of events waiting for broadcast
+const DefaultBroadcastBuffer = 1000
+
+var (
+	// ErrBanned is returned when banned client tries to log in
+	ErrBanned = errors.New("Client is banned")
+
+	// ErrMuted is returned when muted client tries to send message
+	ErrMuted = errors.New("Client is muted")
+
+	// ErrThrottled is returned when client sends messages faster than rate limits allow
+	ErrThrottled = errors.New("Client is throttled")
+
+	// ErrDropped is returned when message is dropped by moderation
+	ErrDropped = errors.New("Message is dropped by moderation")
+
+	// ErrNotServing is returned when the server is not started or is shutting down
+	ErrNotServing = errors.New("Server is not serving")
+
+	// ErrForbidden is returned when role of the client does not allow the action
+	ErrForbidden = errors.New("Client is not allowed to do this")
+)
+
 // NewServer returns Server pointer
 func NewServer(addr string, allowDebug bool) (*Server, error) {
 	// basic server address validation
@@ -32,20 +71,186 @@ func NewServer(addr string, allowDebug bool) (*Server, error) {
 		return nil, errors.New("Invalid address")
 	}
 
-	return &Server{
+	s := &Server{
 		Addr:      addr,
 		Clients:   NewClientState(),
-		Logger:    debug.NewLogger(allowDebug),
-		Broadcast: make(chan chat.ResponseStream, 1000),
-	}, nil
+		Logger:    logger.NewLogger(allowDebug),
+		Broadcast: make(chan chat.ResponseStream, DefaultBroadcastBuffer),
+		Bus:       cluster.NewLocalBus(),
+		Presence:  cluster.NewLocalPresence(),
+		Directory: cluster.NewLocalDirectory(),
+
+		Restrictions: NewRestrictions(),
+		Permissions:  NewPermissions(),
+		RateLimits:   NewRateLimits(DefaultRateLimits),
+		Limits:       DefaultLimits,
+		Moderation:   moderation.NewPipeline(),
+
+		Metrics:         metrics.NewRegistry(),
+		DrainTimeout:    DefaultDrainTimeout,
+		CompactInterval: DefaultCompactInterval,
+
+		devices: newSessionKeys(),
+		signers: newSessionKeys(),
+		verified: &sessionSet{
+			tokens: make(map[string]bool),
+		},
+		stats:   &counters{messageRate: metrics.NewRate(10 * time.Second)},
+		stopped: make(chan struct{}),
+	}
+
+	s.registerMetrics()
+
+	return s, nil
 }
 
 // Server struct
 type Server struct {
 	Addr      string
 	Clients   ClientProcessor
-	Logger    debug.Logger
+	Logger    *logger.Logger
 	Broadcast chan chat.ResponseStream
+
+	// Bus, Presence and Directory are shared by all server instances
+	Bus       cluster.Bus
+	Presence  cluster.Presence
+	Directory cluster.Directory
+
+	// Rosters provide online clients which are not connected to the server directly
+	Rosters []Roster
+
+	Restrictions *Restrictions
+	Permissions  *Permissions
+	RateLimits   *RateLimits
+	Limits       Limits
+	Moderation   *moderation.Pipeline
+
+	// Audit keeps security relevant events, events are not kept if nil
+	Audit *audit.Log
+
+	// History keeps chat events delivered by the bus and indexes messages for search, history is not kept if nil
+	History *history.Store
+
+	// CompactInterval is period of purging history by its retention rules
+	CompactInterval time.Duration
+
+	// Files keeps files uploaded by clients, files are not accepted if nil
+	Files *blob.Store
+
+	// Tracer records spans of client requests and event delivery, spans are not recorded if nil
+	Tracer *trace.Tracer
+
+	// Metrics are exposed in Prometheus text format
+	Metrics *metrics.Registry
+
+	// DrainTimeout is time given to connected clients between shutdown notice and closing of their streams
+	DrainTimeout time.Duration
+
+	// StateFile keeps bans and mutes between restarts, state is not saved if empty
+	StateFile string
+
+	// TLS enables TLS of client connections, connections are not encrypted if nil
+	TLS *tls.Config
+
+	// AdminToken grants access to Admin service, clients must provide it in admin token header
+	// moderators and admins can use their session token instead
+	AdminToken string
+
+	// devices and signers are public keys of sessions for direct messages and message signatures
+	devices *sessionKeys
+	signers *sessionKeys
+
+	// verified are sessions which proved the client name with a credential, only they can use privileged roles
+	verified *sessionSet
+	stats    *counters
+	rpc      *metrics.RPCHandler
+	started  time.Time
+
+	// serving and broadcasting are 1 while the server accepts clients and broadcast goroutine runs
+	serving      int32
+	broadcasting int32
+	state        int32
+
+	// broadcastMtx guards sending to Broadcast against closing it
+	broadcastMtx    sync.RWMutex
+	broadcastClosed bool
+	stopped         chan struct{}
+
+	// certificate is current TLS certificate of the server
+	certificate atomic.Value
+}
+
+// counters keeps server statistics
+type counters struct {
+	logins        int64
+	loginFailures int64
+	messages      int64
+	streams       int64
+	messageRate   *metrics.Rate
+	purged        int64
+	compactions   int64
+}
+
+// registerMetrics method registers server metrics
+func (s *Server) registerMetrics() {
+	m := s.Metrics
+
+	m.NewGaugeFunc("chat_online_users", "Number of online clients.", func() float64 {
+		return float64(len(s.Online()))
+	})
+	m.NewGaugeFunc("chat_active_streams", "Number of open client streams.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.streams))
+	})
+	m.NewCounterFunc("chat_logins_total", "Number of client logins.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.logins))
+	})
+	m.NewCounterFunc("chat_login_failures_total", "Number of rejected client logins.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.loginFailures))
+	})
+	m.NewCounterFunc("chat_messages_total", "Number of client messages sent to the chat.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.messages))
+	})
+	m.NewGaugeFunc("chat_messages_per_second", "Client messages per second over the last 10 seconds.", s.stats.messageRate.PerSecond)
+	m.NewCounterFunc("chat_dropped_events_total", "Number of events not delivered to full client streams.", func() float64 {
+		return float64(s.Clients.Dropped())
+	})
+	m.NewGaugeFunc("chat_broadcast_queue", "Number of events waiting in the broadcast channel.", func() float64 {
+		return float64(len(s.Broadcast))
+	})
+
+	m.NewGaugeFunc("chat_history_records", "Number of records kept in history.", func() float64 {
+		if s.History == nil {
+			return 0
+		}
+		return float64(s.History.Len())
+	})
+	m.NewGaugeFunc("chat_files", "Number of kept files.", func() float64 {
+		if s.Files == nil {
+			return 0
+		}
+		n, _ := s.Files.Usage()
+		return float64(n)
+	})
+	m.NewGaugeFunc("chat_files_bytes", "Size of kept files in bytes.", func() float64 {
+		if s.Files == nil {
+			return 0
+		}
+		_, size := s.Files.Usage()
+		return float64(size)
+	})
+	m.NewCounterFunc("chat_history_purged_total", "Number of history records purged by retention rules.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.purged))
+	})
+	m.NewCounterFunc("chat_history_compactions_total", "Number of history compaction runs.", func() float64 {
+		return float64(atomic.LoadInt64(&s.stats.compactions))
+	})
+
+	s.rpc = metrics.NewRPCHandler(m, "chat_rpc_duration_seconds")
+}
+
+// Roster provides names of online clients
+type Roster interface {
+	Online() []string
 }
 
 // Run method
@@ -53,134 +258,519 @@ func (s *Server) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	srv := grpc.NewServer()
+	// state file is rewritten on every change so that reload of the file does not bring back lifted restrictions
+	if s.StateFile != "" {
+		s.Restrictions.OnChange = s.persist
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+		grpc.StatsHandler(s.rpc),
+	}
+
+	if s.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig())))
+	}
+
+	srv := grpc.NewServer(opts...)
 	chat.RegisterChatServer(srv, s)
+	chat.RegisterAdminServer(srv, &Admin{Server: s})
+	healthpb.RegisterHealthServer(srv, &Health{Server: s})
 
 	l, err := net.Listen("tcp", s.Addr)
 	if err != nil {
 		return errors.WithMessage(err, "Failed to start on provided address")
 	}
 
-	s.Logger.Debug("Server listening on %s", s.Addr)
+	// events are delivered to clients until the server is drained
+	busCtx, busCancel := context.WithCancel(context.Background())
+	defer busCancel()
+
+	events, err := s.Bus.Subscribe(busCtx)
+	if err != nil {
+		l.Close()
+		return errors.WithMessage(err, "Failed to subscribe to event bus")
+	}
+
+	s.Logger.Info("Server is listening", "addr", s.Addr)
+	s.started = time.Now()
+
+	atomic.StoreInt32(&s.broadcasting, 1)
+	go s.broadcast()
+	go s.deliver(events)
 
-	go s.broadcast(ctx)
+	if s.History != nil {
+		go s.compact(ctx)
+	}
+
+	atomic.StoreInt32(&s.serving, 1)
 
 	go func() {
 		sErr := srv.Serve(l)
 		if sErr != nil {
-			log.Println("Serve error", sErr)
+			s.Logger.Error("Serve error", "err", sErr)
 		}
 		cancel()
 	}()
 
 	<-ctx.Done()
 
-	s.Broadcast <- chat.ResponseStream{
-		Timestamp: ptypes.TimestampNow(),
-		Event: &chat.ResponseStream_ServerShutdown{
-			ServerShutdown: &chat.ResponseStream_Shutdown{},
-		},
+	s.drain(srv)
+	return nil
+}
+
+// Login method
+func (s *Server) Login(ctx context.Context, req *chat.LoginRequest) (res *chat.LoginResponse, err error) {
+	_, span := s.Tracer.Start(ctx, "chat.Chat/Login", "user", req.Name)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if s.Draining() {
+		return nil, status.Error(codes.Unavailable, "Server is shutting down")
 	}
 
-	s.Logger.Debug("Shutting down")
+	name, err := s.ValidateName(req.Name)
+	if err != nil {
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
-	srv.GracefulStop()
-	close(s.Broadcast)
-	return nil
+	if len(req.PublicKey) > 0 && !e2e.ValidKey(req.PublicKey) {
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.InvalidArgument, "invalid public key")
+	}
+
+	if len(req.SigningKey) > 0 && !sign.ValidKey(req.SigningKey) {
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.InvalidArgument, "invalid signing key")
+	}
+
+	token, err := s.Join(name)
+	if err == ErrBanned {
+		s.record(audit.AuthFailed, name, "", "banned")
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.PermissionDenied, "name is banned")
+	} else if err != nil {
+		atomic.AddInt64(&s.stats.loginFailures, 1)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if len(req.PublicKey) > 0 {
+		s.addDevice(name, token, req.PublicKey)
+	}
+
+	if len(req.SigningKey) > 0 {
+		s.signers.set(token, req.SigningKey)
+	}
+
+	if s.credential(ctx, name) {
+		s.verified.add(token)
+	}
+
+	return &chat.LoginResponse{Token: token, Name: name}, nil
 }
 
-// Login method
-func (s *Server) Login(ctx context.Context, req *chat.LoginRequest) (*chat.LoginResponse, error) {
-	if req.Name == "" {
-		return nil, status.Error(codes.InvalidArgument, "name is required")
+// Logout method
+func (s *Server) Logout(ctx context.Context, req *chat.LogoutRequest) (*chat.LogoutResponse, error) {
+	if _, ok := s.Leave(req.Token); !ok {
+		return nil, status.Error(codes.NotFound, "Token not found")
+	}
+
+	return new(chat.LogoutResponse), nil
+}
+
+// Join method opens new client session and returns its token
+// chat members are notified when the first session of the client is opened
+func (s *Server) Join(name string) (string, error) {
+	if s.Draining() {
+		return "", ErrNotServing
+	}
+
+	if s.Restrictions.Banned(name) {
+		return "", ErrBanned
 	}
 
 	// generate unique token
-	token := sha256.NewHash(req.Name + "_" + strconv.FormatInt(time.Now().Unix(), 10) + "_" + randint.NewRandomIntString(8))
+	token := sha256.NewHash(name + "_" + strconv.FormatInt(time.Now().Unix(), 10) + "_" + randint.NewRandomIntString(8))
 
 	// add client
-	ok := s.Clients.Add(req.Name, token)
+	ok := s.Clients.Add(name, token)
+
+	// client can have sessions on other server instances
+	if first, err := s.Presence.Join(name); err == nil {
+		ok = first
+	} else {
+		s.Logger.Warn("Failed to update presence", "user", name, "err", err)
+	}
 
-	s.Logger.Debug("%s (%s) has logged in", req.Name, token)
+	s.Logger.Debug("Client has logged in", "user", name, "token", token)
+	s.record(audit.Login, name, "", "session "+logger.TokenPrefix(token))
+	atomic.AddInt64(&s.stats.logins, 1)
 
 	if ok {
-		s.Broadcast <- chat.ResponseStream{
+		s.Publish(chat.ResponseStream{
 			Timestamp: ptypes.TimestampNow(),
 			Event: &chat.ResponseStream_ClientLogin{
 				ClientLogin: &chat.ResponseStream_Login{
-					Name: req.Name,
+					Name: name,
 				},
 			},
-		}
+		})
 	}
 
-	return &chat.LoginResponse{Token: token}, nil
+	return token, nil
 }
 
-// Logout method
-func (s *Server) Logout(ctx context.Context, req *chat.LogoutRequest) (*chat.LogoutResponse, error) {
-	name, ok := s.Clients.Remove(req.Token)
+// Leave method closes client session by token
+// returns client name and false if the session is not found
+// chat members are notified when the last session of the client is closed
+func (s *Server) Leave(token string) (string, bool) {
+	name, ok := s.Clients.Remove(token)
 	if !ok && name == "" {
-		return nil, status.Error(codes.NotFound, "Token not found")
+		return "", false
 	}
 
-	s.Logger.Debug("%s (%s) has logged out", name, req.Token)
+	s.removeDevice(name, token)
+	s.signers.remove(token)
+	s.verified.remove(token)
 
-	if ok {
-		s.Broadcast <- chat.ResponseStream{
+	s.Logger.Debug("Client has logged out", "user", name, "token", token)
+	s.record(audit.Logout, name, "", "session "+logger.TokenPrefix(token))
+
+	s.leavePresence(name, ok)
+
+	return name, true
+}
+
+// leavePresence method notifies chat members if the client has no sessions left on any server instance
+func (s *Server) leavePresence(name string, last bool) {
+	if l, err := s.Presence.Leave(name); err == nil {
+		last = l
+	} else {
+		s.Logger.Warn("Failed to update presence", "user", name, "err", err)
+	}
+
+	if last {
+		s.Publish(chat.ResponseStream{
 			Timestamp: ptypes.TimestampNow(),
 			Event: &chat.ResponseStream_ClientLogout{
 				ClientLogout: &chat.ResponseStream_Logout{
 					Name: name,
 				},
 			},
+		})
+	}
+}
+
+// releaseSessions method removes sessions and devices of this instance from shared presence
+// clients of other instances are notified about clients going offline
+func (s *Server) releaseSessions() {
+	for _, session := range s.Clients.Sessions() {
+		s.removeDevice(session.Name, session.Token)
+		s.leavePresence(session.Name, false)
+	}
+}
+
+// Online method returns sorted names of clients online on any server instance including clients from rosters
+func (s *Server) Online() []string {
+	online, err := s.Presence.Online()
+	if err != nil {
+		s.Logger.Warn("Failed to get presence", "err", err)
+		online = s.Clients.Online()
+	}
+
+	for _, r := range s.Rosters {
+		online = append(online, r.Online()...)
+	}
+
+	sort.Strings(online)
+
+	return online
+}
+
+// Kick method closes client session and its stream
+// returns client name and false if the session is not found
+func (s *Server) Kick(token string) (string, bool) {
+	name, ok := s.Clients.Remove(token)
+	if !ok && name == "" {
+		return "", false
+	}
+
+	s.removeDevice(name, token)
+	s.signers.remove(token)
+	s.verified.remove(token)
+
+	s.Logger.Info("Client has been kicked", "user", name, "token", token)
+	s.record(audit.Logout, name, "", "session "+logger.TokenPrefix(token)+" kicked")
+
+	s.notify(token, "You have been kicked")
+	s.Clients.CloseStream(token)
+	s.leavePresence(name, ok)
+
+	return name, true
+}
+
+// KickName method closes all sessions of the client
+// returns number of closed sessions
+func (s *Server) KickName(name string) int {
+	var n int
+
+	for _, session := range s.Clients.Sessions() {
+		if session.Name != name {
+			continue
+		}
+
+		if _, ok := s.Kick(session.Token); ok {
+			n++
 		}
 	}
 
-	return new(chat.LogoutResponse), nil
+	return n
+}
+
+// Announce method sends server announcement to all chat members
+func (s *Server) Announce(message string) {
+	s.Publish(chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ServerAnnouncement{
+			ServerAnnouncement: &chat.ResponseStream_Announcement{
+				Message: message,
+			},
+		},
+	})
+}
+
+// Say method sends client message to all chat members
+func (s *Server) Say(name, message string) error {
+	return s.say(name, "", &chat.RequestStream{Message: message}, nil)
+}
+
+// say method sends client message with trace context of the span which received it
+// signature of the message is verified by the signing key of the session and attached to the message
+func (s *Server) say(name, traceparent string, req *chat.RequestStream, signingKey []byte) error {
+	if s.stopping() {
+		return ErrNotServing
+	}
+
+	if !s.Permissions.Allowed(name, DefaultRoom, PermPost) {
+		return ErrForbidden
+	}
+
+	if s.Restrictions.Muted(name) {
+		return ErrMuted
+	}
+
+	message, err := s.ValidateMessage(req.Message)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := s.attachments(req.Attachments)
+	if err != nil {
+		return err
+	}
+
+	signingKey, err = signature(name, req, signingKey)
+	if err != nil {
+		return err
+	}
+
+	res := s.Moderation.Check(name, message)
+	switch res.Action {
+	case moderation.Drop:
+		s.Logger.Info("Message is dropped by moderation", "user", name)
+		return ErrDropped
+	case moderation.Mute:
+		s.Restrictions.Mute(name, res.MuteFor)
+		s.Logger.Info("Client is muted by moderation", "user", name, "for", res.MuteFor)
+		s.record(audit.Mute, "moderation", name, "for "+res.MuteFor.String())
+		return ErrMuted
+	case moderation.Flag:
+		s.notifyModerators(fmt.Sprintf("Message of %s is flagged by %s: %s", name, flaggedBy(res), res.Text))
+	}
+	// signature of the author is not valid for masked text, so the message is sent unsigned
+	sig := req.Signature
+	if res.Text != message {
+		sig, signingKey = nil, nil
+	}
+	message = res.Text
+
+	atomic.AddInt64(&s.stats.messages, 1)
+	s.stats.messageRate.Add(1)
+
+	s.Publish(chat.ResponseStream{
+		Timestamp:   ptypes.TimestampNow(),
+		Traceparent: traceparent,
+		Event: &chat.ResponseStream_ClientMessage{
+			ClientMessage: &chat.ResponseStream_Message{
+				Name:        name,
+				Message:     message,
+				Attachments: attachments,
+				Signature:   sig,
+				SigningKey:  signingKey,
+			},
+		},
+	})
+
+	return nil
+}
+
+// Throttle method applies rate limits to the message of the client session
+// returns ErrThrottled if the message should be dropped and ErrMuted if the client is muted for repeated violations
+func (s *Server) Throttle(name, token string) error {
+	ok, mute := s.RateLimits.Allow(name, token)
+	if ok {
+		return nil
+	}
+
+	if !mute {
+		return ErrThrottled
+	}
+
+	d := s.RateLimits.Config().MuteFor
+	s.Restrictions.Mute(name, d)
+	s.Logger.Info("Client is muted for flooding", "user", name, "for", d)
+	s.record(audit.Mute, "flood", name, "for "+d.String())
+
+	return ErrMuted
 }
 
 // Stream method
 func (s *Server) Stream(srv chat.Chat_StreamServer) error {
 	token, ok := s.getToken(srv.Context())
 	if !ok {
+		s.record(audit.AuthFailed, "", "", "stream without token")
 		return status.Error(codes.Unauthenticated, "Missing token header")
 	}
 
 	name, ok := s.Clients.GetNameByToken(token)
 	if !ok {
+		s.record(audit.AuthFailed, "", "", "stream with invalid token "+logger.TokenPrefix(token))
 		return status.Error(codes.Unauthenticated, "Invalid token")
 	}
 
-	go s.sendEventsToClient(srv, token)
+	if s.Draining() {
+		return status.Error(codes.Unavailable, "Server is shutting down")
+	}
+
+	stream := s.Clients.AddStream(token)
+
+	atomic.AddInt64(&s.stats.streams, 1)
+	defer atomic.AddInt64(&s.stats.streams, -1)
 
+	done := make(chan struct{})
+	go func() {
+		s.sendEventsToClient(srv, token, stream)
+		close(done)
+	}()
+
+	received := make(chan error, 1)
+	go func() {
+		received <- s.receiveMessages(srv, name, token)
+	}()
+
+	select {
+	case <-done:
+		// stream is closed by the server (nil error) or the client is gone
+		return srv.Context().Err()
+	case err := <-received:
+		if err != nil {
+			return err
+		}
+	}
+
+	// client has finished sending, events are delivered until the stream is closed
+	<-done
+	return srv.Context().Err()
+}
+
+// receiveMessages method broadcasts client messages until the client finishes sending
+func (s *Server) receiveMessages(srv chat.Chat_StreamServer, name, token string) error {
 	for {
 		req, err := srv.Recv()
 		if err == io.EOF {
-			break
+			return nil
 		} else if err != nil {
 			return err
 		}
 
-		s.Logger.Debug("%s (%s) has sent a message: %s", name, token, req.Message)
+		s.Logger.Debug("Client has sent a message", "user", name, "token", token, "message", req.Message)
 
-		s.Broadcast <- chat.ResponseStream{
-			Timestamp: ptypes.TimestampNow(),
-			Event: &chat.ResponseStream_ClientMessage{
-				ClientMessage: &chat.ResponseStream_Message{
-					Name:    name,
-					Message: req.Message,
-				},
-			},
+		_, span := s.Tracer.Start(srv.Context(), "chat.Chat/Stream receive", "user", name)
+
+		err = s.Throttle(name, token)
+		if err == nil && req.Direct != nil {
+			err = s.direct(name, token, req.Direct)
+		} else if err == nil {
+			err = s.say(name, span.Traceparent(), req, s.signers.get(token))
+		}
+
+		span.SetError(err)
+		span.End()
+
+		switch err {
+		case ErrThrottled:
+			s.Logger.Debug("Client is throttled", "user", name, "token", token)
+			s.notify(token, "You are sending messages too fast")
+		case ErrForbidden:
+			s.Logger.Debug("Client is not allowed to post", "user", name, "token", token)
+			s.notify(token, "You are not allowed to post")
+		case ErrMuted:
+			s.Logger.Debug("Client is muted", "user", name, "token", token)
+			s.notify(token, "You are muted")
+		case ErrDropped:
+			s.notify(token, "Message is rejected by moderation")
+		case ErrNotServing:
+			s.notify(token, "Message is rejected: server is shutting down")
+		case ErrMessageTooLong, ErrInvalidMessage, ErrUnknownAttachment, ErrNoDeviceKey, ErrRecipientOffline, ErrInvalidDirect, ErrInvalidSignature:
+			s.Logger.Debug("Client has sent invalid message", "user", name, "token", token, "err", err)
+			s.notify(token, "Message is rejected: "+err.Error())
+		default:
+			if err != nil {
+				s.Logger.Error("Failed to handle message", "user", name, "token", token, "err", err)
+				s.notify(token, "Message is rejected: internal error")
+			}
 		}
 	}
+}
 
-	<-srv.Context().Done()
-	return srv.Context().Err()
+// notifyModerators method sends server announcement to sessions of moderators
+func (s *Server) notifyModerators(message string) {
+	for _, session := range s.Clients.Sessions() {
+		if s.privileged(session, PermModerate) {
+			s.notify(session.Token, message)
+		}
+	}
 }
 
-func (s *Server) sendEventsToClient(srv chat.Chat_StreamServer, token string) {
-	stream := s.Clients.AddStream(token)
+// flaggedBy function returns rules which flagged the message
+func flaggedBy(res moderation.Result) string {
+	var rules []string
+	for _, v := range res.Verdicts {
+		if v.Action == moderation.Flag {
+			rules = append(rules, v.Rule)
+		}
+	}
+
+	return strings.Join(rules, ", ")
+}
+
+// notify method sends server announcement to single client session
+func (s *Server) notify(token, message string) {
+	s.Clients.Send(token, chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ServerAnnouncement{
+			ServerAnnouncement: &chat.ResponseStream_Announcement{
+				Message: message,
+			},
+		},
+	})
+}
+
+func (s *Server) sendEventsToClient(srv chat.Chat_StreamServer, token string, stream chan chat.ResponseStream) {
 	defer s.Clients.CloseStream(token)
 
 	for {
@@ -190,17 +780,27 @@ func (s *Server) sendEventsToClient(srv chat.Chat_StreamServer, token string) {
 			return
 
 		// read new event
-		case res := <-stream:
-			if r, ok := status.FromError(srv.Send(&res)); ok {
+		case res, ok := <-stream:
+			if !ok {
+				s.Logger.Debug("Stream of client is closed", "token", token)
+				return
+			}
+
+			span := s.Tracer.StartRemote(res.Traceparent, "chat.Chat/Stream send", "token_prefix", logger.TokenPrefix(token))
+			err := srv.Send(&res)
+			span.SetError(err)
+			span.End()
+
+			if r, ok := status.FromError(err); ok {
 				switch r.Code() {
 				case codes.OK:
 					// nothing to do
 				case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
-					s.Logger.Debug("Client (%s) terminated connection", token)
+					s.Logger.Debug("Client terminated connection", "token", token)
 					return
 
 				default:
-					s.Logger.Debug("Failed to send to client (%s): %v", token, r.Err())
+					s.Logger.Warn("Failed to send to client", "token", token, "err", r.Err())
 					return
 				}
 			}
@@ -208,10 +808,56 @@ func (s *Server) sendEventsToClient(srv chat.Chat_StreamServer, token string) {
 	}
 }
 
-// brodcast method spreads event to all connected clients
-func (s *Server) broadcast(ctx context.Context) {
+// brodcast method spreads event to all server instances
+func (s *Server) broadcast() {
+	defer atomic.StoreInt32(&s.broadcasting, 0)
+
 	for res := range s.Broadcast {
+		// shutdown concerns only clients of this instance
+		if _, ok := res.Event.(*chat.ResponseStream_ServerShutdown); ok {
+			s.Clients.Broadcast(res)
+			continue
+		}
+
+		if err := s.Bus.Publish(res); err != nil {
+			s.Logger.Error("Failed to publish event", "err", err)
+		}
+	}
+}
+
+// deliver method spreads events received from the bus to all connected clients
+func (s *Server) deliver(events <-chan chat.ResponseStream) {
+	for res := range events {
+		// direct messages are sent only to devices they are encrypted for
+		if res.GetDirectMessage() != nil {
+			s.relay(res)
+			continue
+		}
+
+		span := s.Tracer.StartRemote(res.Traceparent, "broadcast fan-out")
+		dropped := s.Clients.Dropped()
+
+		// sending to clients is traced as child of the fan-out
+		if span != nil {
+			res.Traceparent = span.Traceparent()
+		}
+
+		s.keep(res)
 		s.Clients.Broadcast(res)
+
+		span.SetAttributes("event", fmt.Sprintf("%T", res.Event), "dropped", strconv.FormatInt(s.Clients.Dropped()-dropped, 10))
+		span.End()
+	}
+}
+
+// record method writes event to the audit log if it is set
+func (s *Server) record(typ, actor, target, detail string) {
+	if s.Audit == nil {
+		return
+	}
+
+	if err := s.Audit.Record(typ, actor, target, detail); err != nil {
+		s.Logger.Error("Failed to record audit event", "type", typ, "err", err)
 	}
 }
 
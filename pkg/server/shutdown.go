@@ -0,0 +1,204 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/history"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultDrainTimeout is time given to connected clients before the server stops
+const DefaultDrainTimeout = 10 * time.Second
+
+const (
+	// flushTimeout limits waiting for pending events and stopping of gRPC server
+	flushTimeout = 5 * time.Second
+
+	// drainPoll is interval of checking drain progress
+	drainPoll = 50 * time.Millisecond
+)
+
+// server states
+const (
+	stateRunning int32 = iota
+	// new sessions are rejected, connected clients can chat until the deadline
+	stateDraining
+	// messages are rejected, pending events are flushed and streams are closed
+	stateStopping
+)
+
+// countdownMarks are remaining times announced to clients during drain
+var countdownMarks = []time.Duration{5 * time.Minute, time.Minute, 30 * time.Second, 10 * time.Second, 5 * time.Second}
+
+// Publish method sends event to all chat members, returns false if the server is stopped
+// event gets new id unless it is forwarded with one
+func (s *Server) Publish(res chat.ResponseStream) bool {
+	s.broadcastMtx.RLock()
+	defer s.broadcastMtx.RUnlock()
+
+	if s.broadcastClosed {
+		return false
+	}
+
+	if res.Id == "" {
+		res.Id = history.NewID(time.Now())
+	}
+
+	s.Broadcast <- res
+
+	return true
+}
+
+// Draining method returns true if the server is shutting down and rejects new sessions
+func (s *Server) Draining() bool {
+	return atomic.LoadInt32(&s.state) != stateRunning
+}
+
+// stopping method returns true if the server rejects messages of connected clients
+func (s *Server) stopping() bool {
+	return atomic.LoadInt32(&s.state) == stateStopping
+}
+
+// drain method stops the server so that connected clients get notice with deadline and all pending events
+func (s *Server) drain(srv *grpc.Server) {
+	// health checks report NOT_SERVING while clients are drained
+	atomic.StoreInt32(&s.serving, 0)
+	atomic.StoreInt32(&s.state, stateDraining)
+
+	deadline := time.Now().Add(s.DrainTimeout)
+	s.Logger.Info("Server is shutting down", "deadline", deadline.Format(time.RFC3339), "sessions", len(s.Clients.Sessions()))
+
+	ts, _ := ptypes.TimestampProto(deadline)
+	s.Publish(chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ServerShutdown{
+			ServerShutdown: &chat.ResponseStream_Shutdown{Deadline: ts},
+		},
+	})
+
+	s.countdown(deadline)
+
+	atomic.StoreInt32(&s.state, stateStopping)
+
+	if !s.flush(flushTimeout) {
+		s.Logger.Warn("Pending events are not delivered", "broadcast_queue", len(s.Broadcast), "pending", s.Clients.Pending())
+	}
+
+	s.persist()
+
+	// clients get end of stream after all pending events
+	for _, session := range s.Clients.Sessions() {
+		s.Clients.CloseStream(session.Token)
+	}
+
+	s.releaseSessions()
+	s.stop(srv, flushTimeout)
+
+	// logouts of released sessions are published to other instances before the broadcast is closed
+	if !s.flush(flushTimeout) {
+		s.Logger.Warn("Pending events are not published", "broadcast_queue", len(s.Broadcast))
+	}
+
+	s.broadcastMtx.Lock()
+	s.broadcastClosed = true
+	close(s.Broadcast)
+	s.broadcastMtx.Unlock()
+
+	close(s.stopped)
+	s.Logger.Info("Server is stopped")
+}
+
+// Stopped method returns channel which is closed when the server is stopped
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stopped
+}
+
+// countdown method announces remaining time to clients until the deadline or until all clients leave
+func (s *Server) countdown(deadline time.Time) {
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+
+	// the shutdown event carries the deadline, only marks below the drain timeout are announced
+	marks := countdownMarks
+	for len(marks) > 0 && marks[0] >= time.Until(deadline) {
+		marks = marks[1:]
+	}
+
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 || len(s.Clients.Sessions()) == 0 {
+			return
+		}
+
+		if len(marks) > 0 && remaining <= marks[0] {
+			s.announceLocal(fmt.Sprintf("Server is shutting down in %s", marks[0]))
+			marks = marks[1:]
+		}
+
+		<-ticker.C
+	}
+}
+
+// flush method waits until broadcast queue and client streams are empty, returns false on timeout
+func (s *Server) flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for len(s.Broadcast) > 0 || s.Clients.Pending() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(drainPoll)
+	}
+
+	return true
+}
+
+// persist method saves state which should survive restart
+func (s *Server) persist() {
+	if s.StateFile == "" {
+		return
+	}
+
+	if err := s.Restrictions.Save(s.StateFile); err != nil {
+		s.Logger.Error("Failed to save state", "file", s.StateFile, "err", err)
+		return
+	}
+
+	s.Logger.Info("State is saved", "file", s.StateFile)
+}
+
+// stop method stops gRPC server gracefully, connections are closed forcibly on timeout
+func (s *Server) stop(srv *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		s.Logger.Warn("Server is stopped forcibly")
+		srv.Stop()
+		<-done
+	}
+}
+
+// announceLocal method sends announcement to clients of this instance only
+func (s *Server) announceLocal(message string) {
+	s.Clients.Broadcast(chat.ResponseStream{
+		Timestamp: ptypes.TimestampNow(),
+		Event: &chat.ResponseStream_ServerAnnouncement{
+			ServerAnnouncement: &chat.ResponseStream_Announcement{
+				Message: message,
+			},
+		},
+	})
+}
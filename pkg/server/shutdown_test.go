@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sc-chat/test-chat/internal/constants"
+	"github.com/sc-chat/test-chat/pkg/chat"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := NewServer(freeAddr(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.DrainTimeout = 200 * time.Millisecond
+	s.StateFile = filepath.Join(t.TempDir(), "state.json")
+	s.Restrictions.Ban("Eve", time.Hour)
+
+	srvCtx, srvCancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		s.Run(srvCtx)
+		close(done)
+	}()
+
+	conn, err := grpc.DialContext(ctx, s.Addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := chat.NewChatClient(conn)
+
+	res, err := c.Login(ctx, &chat.LoginRequest{Name: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streamCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs(constants.TokenHeader, res.Token))
+	stream, err := c.Stream(streamCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait until the stream is open
+	for atomic.LoadInt64(&s.stats.streams) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	srvCancel()
+
+	evt, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown, ok := evt.Event.(*chat.ResponseStream_ServerShutdown)
+	if !ok || shutdown.ServerShutdown.Deadline == nil {
+		t.Fatalf("Event should be shutdown with deadline but got %v", evt)
+	}
+
+	// new sessions are rejected while connected clients are drained
+	if _, err := c.Login(ctx, &chat.LoginRequest{Name: "Bob"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Code should be %s but got %s", codes.Unavailable, status.Code(err))
+	}
+
+	// connected clients can chat until the deadline
+	if err := stream.Send(&chat.RequestStream{Message: "bye"}); err != nil {
+		t.Fatal(err)
+	}
+
+	evt, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := evt.GetClientMessage(); msg == nil || msg.Message != "bye" {
+		t.Errorf("Event should be message %q but got %v", "bye", evt)
+	}
+
+	// the stream is closed by the server after the deadline
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Stream should be closed by the server but got %v", err)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server should be stopped")
+	}
+
+	if s.Publish(chat.ResponseStream{}) {
+		t.Error("Publish should fail when the server is stopped")
+	}
+
+	r, err := LoadRestrictions(s.StateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Banned("Eve") {
+		t.Error("Ban should be saved")
+	}
+}
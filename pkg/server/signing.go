@@ -0,0 +1,25 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/sign"
+)
+
+// ErrInvalidSignature is returned when signature of the message is not made by signing key of the session
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// signature function verifies signature of the message by signing key of the session
+// returns signing key attached to the message, nil if the message is not signed
+func signature(name string, req *chat.RequestStream, key []byte) ([]byte, error) {
+	if len(req.Signature) == 0 {
+		return nil, nil
+	}
+
+	if !sign.Verify(key, name, req.Message, req.Attachments, req.Signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	return key, nil
+}
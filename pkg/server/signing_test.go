@@ -0,0 +1,70 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+	"github.com/sc-chat/test-chat/pkg/moderation"
+	"github.com/sc-chat/test-chat/pkg/sign"
+)
+
+func TestSignedMessage(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Moderation = moderation.NewPipeline(moderation.NewWordList([]string{"darn"}, moderation.Mask))
+
+	alice, _ := sign.GenerateKey()
+	eve, _ := sign.GenerateKey()
+
+	res, err := s.Login(context.Background(), &chat.LoginRequest{Name: "Alice", SigningKey: alice.Public()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := s.signers.get(res.Token)
+
+	if _, err := s.Login(context.Background(), &chat.LoginRequest{Name: "Eve", SigningKey: []byte("short")}); err == nil {
+		t.Error("Login with invalid signing key should fail")
+	}
+
+	cases := []struct {
+		name      string
+		req       *chat.RequestStream
+		key       []byte
+		expected  error
+		signature bool
+	}{
+		{name: "signed", req: &chat.RequestStream{Message: "hello", Signature: alice.Sign("Alice", "hello", nil)}, key: key, signature: true},
+		{name: "not signed", req: &chat.RequestStream{Message: "hello"}, key: key},
+		{name: "other key", req: &chat.RequestStream{Message: "hello", Signature: eve.Sign("Alice", "hello", nil)}, key: key, expected: ErrInvalidSignature},
+		{name: "other message", req: &chat.RequestStream{Message: "bye", Signature: alice.Sign("Alice", "hello", nil)}, key: key, expected: ErrInvalidSignature},
+		{name: "masked", req: &chat.RequestStream{Message: "oh darn", Signature: alice.Sign("Alice", "oh darn", nil)}, key: key},
+		{name: "no key", req: &chat.RequestStream{Message: "hello", Signature: alice.Sign("Alice", "hello", nil)}, expected: ErrInvalidSignature},
+	}
+
+	for _, tc := range cases {
+		for len(s.Broadcast) > 0 {
+			<-s.Broadcast
+		}
+
+		if err := s.say("Alice", "", tc.req, tc.key); err != tc.expected {
+			t.Errorf("Error should be %v but got %v (%s)", tc.expected, err, tc.name)
+			continue
+		}
+
+		if tc.expected != nil {
+			continue
+		}
+
+		evt := <-s.Broadcast
+		msg := evt.GetClientMessage()
+
+		if signed := len(msg.Signature) > 0 && bytes.Equal(msg.SigningKey, alice.Public()); signed != tc.signature {
+			t.Errorf("Message should be signed %t but got %t (%s)", tc.signature, signed, tc.name)
+		}
+
+		if !tc.signature && msg.SigningKey != nil {
+			t.Errorf("Signing key should not be attached to unsigned message (%s)", tc.name)
+		}
+	}
+}
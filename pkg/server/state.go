@@ -1,7 +1,9 @@
 package server
 
 import (
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sc-chat/test-chat/pkg/chat"
 )
@@ -11,17 +13,37 @@ type ClientProcessor interface {
 	Add(name, token string) bool
 	Remove(token string) (string, bool)
 	GetNameByToken(token string) (string, bool)
+	Online() []string
+	Sessions() []Session
 	AddStream(token string) chan chat.ResponseStream
 	CloseStream(token string)
+	Send(token string, s chat.ResponseStream) bool
 	Broadcast(s chat.ResponseStream)
+	Dropped() int64
+	Pending() int
 }
 
+// Session describes single client session
+type Session struct {
+	Name  string
+	Token string
+}
+
+// DefaultStreamBuffer is number of events buffered for each client stream
+const DefaultStreamBuffer = 100
+
 // ClientsState implements ClientProcessor interface
 type ClientsState struct {
 	Tokens  map[string]string
 	Names   map[string]map[string]bool
 	Streams map[string]chan chat.ResponseStream
 
+	// StreamBuffer is number of events buffered for each client stream, events to full streams are dropped
+	StreamBuffer int
+
+	// dropped is number of events not delivered to full client streams
+	dropped int64
+
 	tokenMtx  sync.RWMutex
 	nameMtx   sync.RWMutex
 	streamMtx sync.RWMutex
@@ -43,6 +65,39 @@ func (c *ClientsState) GetNameByToken(token string) (string, bool) {
 	return name, ok
 }
 
+// Online method returns sorted names of clients with at least one session
+func (c *ClientsState) Online() []string {
+	c.nameMtx.RLock()
+	names := make([]string, 0, len(c.Names))
+	for name := range c.Names {
+		names = append(names, name)
+	}
+	c.nameMtx.RUnlock()
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Sessions method returns all client sessions sorted by name
+func (c *ClientsState) Sessions() []Session {
+	c.tokenMtx.RLock()
+	sessions := make([]Session, 0, len(c.Tokens))
+	for token, name := range c.Tokens {
+		sessions = append(sessions, Session{Name: name, Token: token})
+	}
+	c.tokenMtx.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Name != sessions[j].Name {
+			return sessions[i].Name < sessions[j].Name
+		}
+		return sessions[i].Token < sessions[j].Token
+	})
+
+	return sessions
+}
+
 // Remove method removes client by token
 // returns client name and bool flag which is true if last client token was deleted
 func (c *ClientsState) Remove(token string) (string, bool) {
@@ -66,15 +121,53 @@ func (c *ClientsState) Broadcast(s chat.ResponseStream) {
 			// nothing to do
 		default:
 			// client stream is full, dropping message
+			atomic.AddInt64(&c.dropped, 1)
 		}
 	}
 
 	c.streamMtx.RUnlock()
 }
 
+// Dropped method returns number of events dropped by Broadcast because client streams were full
+func (c *ClientsState) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// Send method sends event to the client stream
+// returns false if the client has no stream or it is full
+func (c *ClientsState) Send(token string, s chat.ResponseStream) bool {
+	c.streamMtx.RLock()
+	defer c.streamMtx.RUnlock()
+
+	stream, ok := c.Streams[token]
+	if !ok {
+		return false
+	}
+
+	select {
+	case stream <- s:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pending method returns number of events waiting in client streams
+func (c *ClientsState) Pending() int {
+	c.streamMtx.RLock()
+	defer c.streamMtx.RUnlock()
+
+	var n int
+	for _, stream := range c.Streams {
+		n += len(stream)
+	}
+
+	return n
+}
+
 // AddStream method adds new stream to stream map
 func (c *ClientsState) AddStream(token string) chan chat.ResponseStream {
-	stream := make(chan chat.ResponseStream, 100)
+	stream := make(chan chat.ResponseStream, c.StreamBuffer)
 	c.streamMtx.Lock()
 	c.Streams[token] = stream
 	c.streamMtx.Unlock()
@@ -151,5 +244,7 @@ func NewClientState() *ClientsState {
 		Tokens:  make(map[string]string),
 		Names:   make(map[string]map[string]bool),
 		Streams: make(map[string]chan chat.ResponseStream),
+
+		StreamBuffer: DefaultStreamBuffer,
 	}
 }
@@ -1,6 +1,10 @@
 package server
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/sc-chat/test-chat/pkg/chat"
+)
 
 func TestClientStateAdd(t *testing.T) {
 	state := NewClientState()
@@ -211,3 +215,111 @@ func TestClientStateCloseStream(t *testing.T) {
 		}
 	}
 }
+
+func TestClientStateOnline(t *testing.T) {
+	state := NewClientState()
+
+	fixtures := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "Bob",
+			token: "example",
+		},
+		{
+			name:  "Alice",
+			token: "example2",
+		},
+		{
+			name:  "Bob",
+			token: "example3",
+		},
+	}
+
+	for _, item := range fixtures {
+		state.Add(item.name, item.token)
+	}
+
+	online := state.Online()
+	expected := []string{"Alice", "Bob"}
+
+	if len(expected) != len(online) {
+		t.Fatalf("Online should be %v but got %v", expected, online)
+	}
+
+	for i := range expected {
+		if expected[i] != online[i] {
+			t.Errorf("Online should be %v but got %v", expected, online)
+		}
+	}
+}
+
+func TestClientStateSessions(t *testing.T) {
+	state := NewClientState()
+
+	state.Add("Bob", "example2")
+	state.Add("Alice", "example3")
+	state.Add("Bob", "example")
+
+	expected := []Session{
+		{Name: "Alice", Token: "example3"},
+		{Name: "Bob", Token: "example"},
+		{Name: "Bob", Token: "example2"},
+	}
+
+	sessions := state.Sessions()
+	if len(expected) != len(sessions) {
+		t.Fatalf("Sessions should be %v but got %v", expected, sessions)
+	}
+
+	for i := range expected {
+		if expected[i] != sessions[i] {
+			t.Errorf("Session should be %v but got %v", expected[i], sessions[i])
+		}
+	}
+}
+
+func TestClientStateSend(t *testing.T) {
+	state := NewClientState()
+	stream := state.AddStream("example")
+
+	cases := []struct {
+		token string
+		ok    bool
+	}{
+		{
+			token: "example",
+			ok:    true,
+		},
+		{
+			token: "unknown",
+			ok:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		ok := state.Send(tc.token, chat.ResponseStream{})
+
+		if tc.ok != ok {
+			t.Errorf("Ok should be %t but got %t (%+v)", tc.ok, ok, tc)
+		}
+	}
+
+	if len(stream) != 1 {
+		t.Errorf("Len should be %d but got %d", 1, len(stream))
+	}
+}
+
+func TestClientStateBroadcastDropped(t *testing.T) {
+	state := NewClientState()
+	stream := state.AddStream("example")
+
+	for i := 0; i < cap(stream)+5; i++ {
+		state.Broadcast(chat.ResponseStream{})
+	}
+
+	if dropped := state.Dropped(); dropped != 5 {
+		t.Errorf("Dropped should be %d but got %d", 5, dropped)
+	}
+}
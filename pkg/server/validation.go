@@ -0,0 +1,150 @@
+package server
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrMessageTooLong is returned when message exceeds the limit
+	ErrMessageTooLong = errors.New("Message is too long")
+
+	// ErrInvalidMessage is returned when message is not valid UTF-8 or contains control characters
+	ErrInvalidMessage = errors.New("Message contains invalid characters")
+)
+
+// Limits struct describes sizes of client input
+type Limits struct {
+	// MaxMessageBytes is maximum size of message in bytes
+	MaxMessageBytes int `yaml:"max_message_bytes"`
+
+	// MaxNameLength is maximum length of name in characters
+	MaxNameLength int `yaml:"max_name_length"`
+}
+
+// DefaultLimits are limits of new server
+var DefaultLimits = Limits{
+	MaxMessageBytes: 4096,
+	MaxNameLength:   32,
+}
+
+// nameSymbols are allowed in names besides letters and digits
+const nameSymbols = "_-."
+
+// confusables maps characters to latin characters they look like
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'l', 'ї': 'l',
+	'ј': 'j', 'ԁ': 'd', 'һ': 'h', 'ԛ': 'q', 'ԝ': 'w', 'ɡ': 'g',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'l', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// digits and symbols
+	'0': 'o', '1': 'l', '3': 'e', '5': 's', '|': 'l',
+	// latin letters which look alike
+	'i': 'l', 'ı': 'l',
+}
+
+// NormalizeName function returns name in Unicode normalization form KC
+// so that visually equal names are the same name
+func NormalizeName(name string) string {
+	return norm.NFKC.String(strings.TrimSpace(name))
+}
+
+// ValidateName method checks name against the name policy and returns normalized name
+func (s *Server) ValidateName(name string) (string, error) {
+	name = NormalizeName(name)
+
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	if n := utf8.RuneCountInString(name); n > s.Limits.MaxNameLength {
+		return "", errors.Errorf("name must not be longer than %d characters", s.Limits.MaxNameLength)
+	}
+
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || strings.ContainsRune(nameSymbols, r) {
+			continue
+		}
+
+		// remote clients are addressed as name@server
+		if r == '@' {
+			return "", errors.New("name must not contain @")
+		}
+
+		return "", errors.Errorf("name must contain only letters, digits and %q", nameSymbols)
+	}
+
+	if other, ok := s.confusable(name); ok {
+		return "", errors.Errorf("name is confusable with %s", other)
+	}
+
+	return name, nil
+}
+
+// confusable method returns name of online or privileged client which looks like the name but differs from it
+func (s *Server) confusable(name string) (string, bool) {
+	sk := skeleton(name)
+
+	names := append(s.Online(), s.Permissions.Names()...)
+	for _, other := range names {
+		if other != name && skeleton(other) == sk {
+			return other, true
+		}
+	}
+
+	return "", false
+}
+
+// skeleton function returns form of the name used to compare names which look alike
+func skeleton(name string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		// accents are dropped
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+
+		// separators are easy to overlook
+		if strings.ContainsRune(nameSymbols, r) {
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	// rn looks like m
+	return strings.Replace(b.String(), "rn", "m", -1)
+}
+
+// ValidateMessage method checks message against limits and returns it in Unicode normalization form C
+// control characters and escape sequences are rejected so that messages can't corrupt terminals
+func (s *Server) ValidateMessage(message string) (string, error) {
+	if len(message) > s.Limits.MaxMessageBytes {
+		return "", ErrMessageTooLong
+	}
+
+	if !utf8.ValidString(message) {
+		return "", ErrInvalidMessage
+	}
+
+	for _, r := range message {
+		// bidi overrides display text in different order than it is written
+		if unicode.IsControl(r) || (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069') {
+			return "", ErrInvalidMessage
+		}
+	}
+
+	return norm.NFC.String(message), nil
+}
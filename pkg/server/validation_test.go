@@ -0,0 +1,82 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Permissions.Set(RolesConfig{Users: map[string]Role{"Admin": RoleAdmin}})
+	s.Join("Alice")
+
+	cases := []struct {
+		name       string
+		normalized string
+		valid      bool
+	}{
+		{name: "Bob", normalized: "Bob", valid: true},
+		{name: " Bob_2.0 ", normalized: "Bob_2.0", valid: true},
+		{name: "José", normalized: "José", valid: true},
+		{name: "Ｂｏｂ", normalized: "Bob", valid: true},
+		{name: "Алёна", normalized: "Алёна", valid: true},
+		{name: "Alice", normalized: "Alice", valid: true},
+		{name: "", valid: false},
+		{name: strings.Repeat("a", 33), valid: false},
+		{name: "Bob@example.com", valid: false},
+		{name: "Bob Smith", valid: false},
+		{name: "Bob\x1b[2J", valid: false},
+		// homoglyphs of online and privileged clients
+		{name: "alice", valid: false},
+		{name: "Аlice", valid: false},
+		{name: "A1ice", valid: false},
+		{name: "Adrnin", valid: false},
+		{name: "Ädmin", valid: false},
+	}
+
+	for _, tc := range cases {
+		name, err := s.ValidateName(tc.name)
+		if tc.valid != (err == nil) {
+			t.Errorf("Name %q should be valid %t but got %v", tc.name, tc.valid, err)
+			continue
+		}
+
+		if tc.valid && tc.normalized != name {
+			t.Errorf("Name should be %q but got %q", tc.normalized, name)
+		}
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	s, _ := NewServer("example:8000", false)
+	s.Limits.MaxMessageBytes = 16
+
+	cases := []struct {
+		message    string
+		normalized string
+		err        error
+	}{
+		{message: "hello", normalized: "hello"},
+		{message: "cafe\u0301", normalized: "caf\u00e9"},
+		{message: "привет", normalized: "привет"},
+		{message: strings.Repeat("a", 17), err: ErrMessageTooLong},
+		{message: "\x1b[2J", err: ErrInvalidMessage},
+		{message: "bell\a", err: ErrInvalidMessage},
+		{message: "line\r", err: ErrInvalidMessage},
+		{message: "\u009b31m", err: ErrInvalidMessage},
+		{message: "abc‮gpj.exe", err: ErrInvalidMessage},
+		{message: "\xff", err: ErrInvalidMessage},
+	}
+
+	for _, tc := range cases {
+		message, err := s.ValidateMessage(tc.message)
+		if tc.err != err {
+			t.Errorf("Error of %q should be %v but got %v", tc.message, tc.err, err)
+			continue
+		}
+
+		if err == nil && tc.normalized != message {
+			t.Errorf("Message should be %q but got %q", tc.normalized, message)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sc-chat/test-chat/internal/sha256"
+)
+
+// context separates signatures of chat messages from signatures of anything else
+const context = "chat message v1"
+
+// Key is Ed25519 key signing messages of a client
+type Key struct {
+	private ed25519.PrivateKey
+}
+
+// Public method returns public key registered at login
+func (k *Key) Public() []byte {
+	return []byte(k.private.Public().(ed25519.PublicKey))
+}
+
+// Sign method returns signature of the message sent by the client with attached files
+func (k *Key) Sign(name, message string, attachments []string) []byte {
+	return ed25519.Sign(k.private, payload(name, message, attachments))
+}
+
+// Verify function returns true if the signature of the message is made by the key
+func Verify(pub []byte, name, message string, attachments []string, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(ed25519.PublicKey(pub), payload(name, message, attachments), sig)
+}
+
+// ValidKey function returns true if provided bytes are Ed25519 public key
+func ValidKey(pub []byte) bool {
+	return len(pub) == ed25519.PublicKeySize
+}
+
+// Fingerprint function returns fingerprint of the public key compared by people to verify the client
+func Fingerprint(pub []byte) string {
+	return sha256.Fingerprint(pub)
+}
+
+// payload function returns signed bytes of the message
+// each field is prefixed with its length so that content can't be moved between fields
+func payload(name, message string, attachments []string) []byte {
+	var buf []byte
+	for _, field := range append([]string{context, name, message}, attachments...) {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+
+	return buf
+}
+
+// GenerateKey returns Key pointer with new random key
+func GenerateKey() (*Key, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{private: private}, nil
+}
+
+// LoadKey returns Key pointer with key read from the file
+// new key is generated and written to the file if it does not exist
+func LoadKey(path string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		k, err := GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return k, ioutil.WriteFile(path, []byte(hex.EncodeToString(k.private.Seed())+"\n"), 0600)
+	} else if err != nil {
+		return nil, err
+	}
+
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("invalid signing key file")
+	}
+
+	return &Key{private: ed25519.NewKeyFromSeed(seed)}, nil
+}
@@ -0,0 +1,61 @@
+package sign
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	alice, _ := GenerateKey()
+	eve, _ := GenerateKey()
+
+	sig := alice.Sign("Alice", "hello", []string{"f00d"})
+
+	cases := []struct {
+		name        string
+		key         []byte
+		author      string
+		message     string
+		attachments []string
+		sig         []byte
+		valid       bool
+	}{
+		{name: "valid", key: alice.Public(), author: "Alice", message: "hello", attachments: []string{"f00d"}, sig: sig, valid: true},
+		{name: "other key", key: eve.Public(), author: "Alice", message: "hello", attachments: []string{"f00d"}, sig: sig},
+		{name: "other author", key: alice.Public(), author: "Eve", message: "hello", attachments: []string{"f00d"}, sig: sig},
+		{name: "changed message", key: alice.Public(), author: "Alice", message: "hello!", attachments: []string{"f00d"}, sig: sig},
+		{name: "removed attachment", key: alice.Public(), author: "Alice", message: "hello", sig: sig},
+		{name: "moved separator", key: alice.Public(), author: "Alice", message: "hello\x00f00d", sig: sig},
+		{name: "no signature", key: alice.Public(), author: "Alice", message: "hello", attachments: []string{"f00d"}},
+		{name: "invalid key", key: []byte("short"), author: "Alice", message: "hello", attachments: []string{"f00d"}, sig: sig},
+	}
+
+	for _, tc := range cases {
+		if valid := Verify(tc.key, tc.author, tc.message, tc.attachments, tc.sig); tc.valid != valid {
+			t.Errorf("Signature should be valid %t but got %t (%s)", tc.valid, valid, tc.name)
+		}
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing.key")
+
+	k, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(k.Public(), loaded.Public()) {
+		t.Errorf("Key should be %x but got %x", k.Public(), loaded.Public())
+	}
+
+	if !ValidKey(k.Public()) || ValidKey([]byte("short")) {
+		t.Error("Only Ed25519 public keys should be valid")
+	}
+}
@@ -0,0 +1,250 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Exporter receives finished spans
+type Exporter interface {
+	Export(s SpanData)
+}
+
+// FileExporter writes spans as JSON lines
+type FileExporter struct {
+	w   io.Writer
+	mtx sync.Mutex
+}
+
+// Export method writes span as single JSON line
+func (f *FileExporter) Export(s SpanData) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	json.NewEncoder(f.w).Encode(s)
+}
+
+// NewFileExporter returns FileExporter pointer
+func NewFileExporter(w io.Writer) *FileExporter {
+	return &FileExporter{w: w}
+}
+
+// Exporters sends spans to every exporter of the list
+type Exporters []Exporter
+
+// Export method passes span to all exporters
+func (e Exporters) Export(s SpanData) {
+	for _, exporter := range e {
+		exporter.Export(s)
+	}
+}
+
+// OTLPExporter sends batches of spans to OTLP/HTTP collector in JSON encoding
+type OTLPExporter struct {
+	URL    string
+	Client *http.Client
+
+	// queue passes exported spans to Run, spans are dropped when it is full
+	queue chan SpanData
+	// dropped is number of spans not exported because the queue was full
+	dropped int64
+
+	spans []SpanData
+	mtx   sync.Mutex
+}
+
+const (
+	// otlpBatchSize is number of spans which triggers sending
+	otlpBatchSize = 100
+	// otlpQueueSize is number of spans waiting for Run
+	otlpQueueSize = 10 * otlpBatchSize
+)
+
+// Export method queues span for Run, the span is dropped if the queue is full
+func (o *OTLPExporter) Export(s SpanData) {
+	select {
+	case o.queue <- s:
+	default:
+		atomic.AddInt64(&o.dropped, 1)
+	}
+}
+
+// Dropped method returns number of spans dropped by Export because the queue was full
+func (o *OTLPExporter) Dropped() int64 {
+	return atomic.LoadInt64(&o.dropped)
+}
+
+// add method adds span to the batch and returns true if the batch is full
+func (o *OTLPExporter) add(s SpanData) bool {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	o.spans = append(o.spans, s)
+	return len(o.spans) >= otlpBatchSize
+}
+
+// Flush method sends queued and collected spans to the collector
+func (o *OTLPExporter) Flush() error {
+	for done := false; !done; {
+		select {
+		case s := <-o.queue:
+			o.add(s)
+		default:
+			done = true
+		}
+	}
+
+	o.mtx.Lock()
+	spans := o.spans
+	o.spans = nil
+	o.mtx.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	res, err := o.Client.Post(o.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "failed to export spans")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return errors.Errorf("failed to export spans: collector returned %s", res.Status)
+	}
+
+	return nil
+}
+
+// Run method collects queued spans and flushes them when the batch is full or with interval until done is closed,
+// remaining spans are flushed before return, failed flushes are passed to report and do not stop the exporter
+func (o *OTLPExporter) Run(done <-chan struct{}, interval time.Duration, report func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return o.Flush()
+		case s := <-o.queue:
+			if !o.add(s) {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		if err := o.Flush(); err != nil && report != nil {
+			report(err)
+		}
+	}
+}
+
+// NewOTLPExporter returns OTLPExporter pointer sending spans to the collector url, e.g. http://localhost:4318/v1/traces
+func NewOTLPExporter(url string) (*OTLPExporter, error) {
+	if url == "" {
+		return nil, errors.New("Invalid collector url")
+	}
+
+	return &OTLPExporter{
+		URL:    url,
+		Client: &http.Client{Timeout: 5 * time.Second},
+		queue:  make(chan SpanData, otlpQueueSize),
+	}, nil
+}
+
+// OTLP JSON encoding of ExportTraceServiceRequest
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	// 2 is STATUS_CODE_ERROR
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// otlpRequest function groups spans by service
+func otlpRequest(spans []SpanData) otlpTraces {
+	var req otlpTraces
+	index := make(map[string]int)
+
+	for _, s := range spans {
+		i, ok := index[s.Service]
+		if !ok {
+			var rs otlpResourceSpans
+			rs.Resource.Attributes = []otlpAttribute{{Key: "service.name", Value: otlpValue{s.Service}}}
+			rs.ScopeSpans = []otlpScopeSpans{{}}
+
+			i = len(req.ResourceSpans)
+			index[s.Service] = i
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+
+		span := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: a.Key, Value: otlpValue{a.Value}})
+		}
+
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.Error}
+		}
+
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, span)
+	}
+
+	return req
+}
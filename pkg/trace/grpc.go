@@ -0,0 +1,35 @@
+package trace
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Header is gRPC metadata key carrying W3C traceparent
+const Header = "traceparent"
+
+// Inject function returns context with traceparent of the span in the context added to outgoing gRPC metadata
+func Inject(ctx context.Context) context.Context {
+	traceparent := SpanFromContext(ctx).Traceparent()
+	if traceparent == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, Header, traceparent)
+}
+
+// Extract function returns remote span context from incoming gRPC metadata
+func Extract(ctx context.Context) (SpanContext, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[Header]) == 0 {
+		return SpanContext{}, false
+	}
+
+	sc, err := ParseTraceparent(md[Header][0])
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	return sc, true
+}
@@ -0,0 +1,251 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TraceID identifies trace across all services
+type TraceID [16]byte
+
+// String method returns hex representation of the id
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies span within the trace
+type SpanID [8]byte
+
+// String method returns hex representation of the id
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is propagated between services in W3C traceparent format
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// Valid method returns true if both ids are set
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent method returns W3C traceparent of the span context, empty string if it is not valid
+func (sc SpanContext) Traceparent() string {
+	if !sc.Valid() {
+		return ""
+	}
+
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"
+}
+
+// ParseTraceparent function returns span context of W3C traceparent
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[3]) != 2 || parts[0] == "ff" {
+		return sc, errors.Errorf("Invalid traceparent %q", s)
+	}
+
+	if n, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || n != len(sc.TraceID) || len(parts[1]) != 2*n {
+		return sc, errors.Errorf("Invalid trace id %q", parts[1])
+	}
+
+	if n, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || n != len(sc.SpanID) || len(parts[2]) != 2*n {
+		return sc, errors.Errorf("Invalid span id %q", parts[2])
+	}
+
+	if !sc.Valid() {
+		return sc, errors.Errorf("Invalid traceparent %q", s)
+	}
+
+	return sc, nil
+}
+
+// Attribute is key-value pair describing the span
+type Attribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SpanData is finished span passed to the exporter
+type SpanData struct {
+	Service    string      `json:"service"`
+	Name       string      `json:"name"`
+	TraceID    string      `json:"trace_id"`
+	SpanID     string      `json:"span_id"`
+	ParentID   string      `json:"parent_id,omitempty"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Span measures single operation, nil span does nothing so that tracing can be disabled
+type Span struct {
+	tracer *Tracer
+	ctx    SpanContext
+	data   SpanData
+	ended  bool
+	mtx    sync.Mutex
+}
+
+// Context method returns span context which is propagated to children
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.ctx
+}
+
+// Traceparent method returns W3C traceparent of the span, empty string for nil span
+func (s *Span) Traceparent() string {
+	return s.Context().Traceparent()
+}
+
+// SetAttributes method adds attributes given as alternating keys and values
+func (s *Span) SetAttributes(keyvals ...string) {
+	if s == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		s.data.Attributes = append(s.data.Attributes, Attribute{Key: keyvals[i], Value: keyvals[i+1]})
+	}
+}
+
+// SetError method marks the span failed, nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.data.Error = err.Error()
+}
+
+// End method finishes the span and passes it to the exporter, only the first call has effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	if s.ended {
+		s.mtx.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mtx.Unlock()
+
+	s.tracer.Exporter.Export(data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan function returns context carrying the span
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext function returns span of the context, nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Tracer starts spans of the service, nil tracer starts nil spans
+type Tracer struct {
+	Service  string
+	Exporter Exporter
+}
+
+// Start method starts span which is child of the span in the context or of the remote span from incoming gRPC metadata
+// returns context carrying the new span
+func (t *Tracer) Start(ctx context.Context, name string, keyvals ...string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanFromContext(ctx).Context()
+	if !parent.Valid() {
+		parent, _ = Extract(ctx)
+	}
+
+	s := t.start(parent, name)
+	s.SetAttributes(keyvals...)
+
+	return ContextWithSpan(ctx, s), s
+}
+
+// StartRemote method starts span which is child of the remote span given as traceparent
+// new trace is started if the traceparent is empty or invalid
+func (t *Tracer) StartRemote(traceparent, name string, keyvals ...string) *Span {
+	if t == nil {
+		return nil
+	}
+
+	parent, _ := ParseTraceparent(traceparent)
+
+	s := t.start(parent, name)
+	s.SetAttributes(keyvals...)
+
+	return s
+}
+
+func (t *Tracer) start(parent SpanContext, name string) *Span {
+	s := &Span{tracer: t}
+
+	s.ctx.TraceID = parent.TraceID
+	if !parent.Valid() {
+		rand.Read(s.ctx.TraceID[:])
+	}
+	rand.Read(s.ctx.SpanID[:])
+
+	s.data = SpanData{
+		Service: t.Service,
+		Name:    name,
+		TraceID: s.ctx.TraceID.String(),
+		SpanID:  s.ctx.SpanID.String(),
+		Start:   time.Now(),
+	}
+
+	if parent.Valid() {
+		s.data.ParentID = parent.SpanID.String()
+	}
+
+	return s
+}
+
+// NewTracer returns Tracer pointer
+func NewTracer(service string, exporter Exporter) (*Tracer, error) {
+	if exporter == nil {
+		return nil, errors.New("Invalid exporter")
+	}
+
+	return &Tracer{
+		Service:  service,
+		Exporter: exporter,
+	}, nil
+}
@@ -0,0 +1,265 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// memoryExporter keeps spans in memory
+type memoryExporter struct {
+	spans []SpanData
+}
+
+func (m *memoryExporter) Export(s SpanData) {
+	m.spans = append(m.spans, s)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		traceparent string
+		err         bool
+	}{
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", err: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", err: true},
+		{traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", err: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", err: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736zz-00f067aa0ba902b7-01", err: true},
+		{traceparent: "", err: true},
+	}
+
+	for _, tc := range cases {
+		sc, err := ParseTraceparent(tc.traceparent)
+		if tc.err != (err != nil) {
+			t.Errorf("Error should be %t but got %v (%s)", tc.err, err, tc.traceparent)
+			continue
+		}
+
+		if err == nil && sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Trace id should be %s but got %s", "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID)
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	e := new(memoryExporter)
+	tracer, err := NewTracer("chat-server", e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// remote parent from incoming metadata
+	remote := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, remote))
+
+	ctx, parent := tracer.Start(ctx, "chat.Chat/Login", "user", "Alice")
+	_, child := tracer.Start(ctx, "child")
+	child.SetError(errors.New("oops"))
+	child.End()
+	child.End()
+	parent.End()
+
+	sibling := tracer.StartRemote(parent.Traceparent(), "chat.Chat/Stream send")
+	sibling.End()
+
+	root := tracer.StartRemote("", "broadcast fan-out")
+	root.End()
+
+	if len(e.spans) != 4 {
+		t.Fatalf("Spans should be %d but got %d", 4, len(e.spans))
+	}
+
+	cases := []struct {
+		span     SpanData
+		name     string
+		traceID  string
+		parentID string
+	}{
+		{span: e.spans[0], name: "child", traceID: "4bf92f3577b34da6a3ce929d0e0e4736", parentID: e.spans[1].SpanID},
+		{span: e.spans[1], name: "chat.Chat/Login", traceID: "4bf92f3577b34da6a3ce929d0e0e4736", parentID: "00f067aa0ba902b7"},
+		{span: e.spans[2], name: "chat.Chat/Stream send", traceID: "4bf92f3577b34da6a3ce929d0e0e4736", parentID: e.spans[1].SpanID},
+		{span: e.spans[3], name: "broadcast fan-out", traceID: e.spans[3].TraceID, parentID: ""},
+	}
+
+	for _, tc := range cases {
+		if tc.name != tc.span.Name || tc.traceID != tc.span.TraceID || tc.parentID != tc.span.ParentID {
+			t.Errorf("Span should be %s %s/%s but got %+v", tc.name, tc.traceID, tc.parentID, tc.span)
+		}
+	}
+
+	if e.spans[3].TraceID == e.spans[1].TraceID {
+		t.Error("Span without parent should start new trace")
+	}
+
+	if e.spans[0].Error != "oops" {
+		t.Errorf("Error should be %q but got %q", "oops", e.spans[0].Error)
+	}
+
+	if attrs := e.spans[1].Attributes; len(attrs) != 1 || attrs[0] != (Attribute{Key: "user", Value: "Alice"}) {
+		t.Errorf("Attributes should be user=Alice but got %v", attrs)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+
+	ctx, span := tracer.Start(context.Background(), "noop")
+	span.SetAttributes("user", "Alice")
+	span.SetError(errors.New("oops"))
+	span.End()
+
+	if span != nil || SpanFromContext(ctx) != nil || span.Traceparent() != "" {
+		t.Error("Nil tracer should start nil spans")
+	}
+
+	if _, ok := metadata.FromOutgoingContext(Inject(ctx)); ok {
+		t.Error("Nothing should be injected without span")
+	}
+}
+
+func TestInject(t *testing.T) {
+	tracer, _ := NewTracer("chat-client", new(memoryExporter))
+
+	ctx, span := tracer.Start(context.Background(), "client.Login")
+	md, _ := metadata.FromOutgoingContext(Inject(ctx))
+
+	sc, ok := Extract(metadata.NewIncomingContext(context.Background(), md))
+	if !ok || sc != span.Context() {
+		t.Errorf("Span context should be %s but got %s", span.Traceparent(), sc.Traceparent())
+	}
+}
+
+func TestExporters(t *testing.T) {
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer collector.Close()
+
+	out := new(bytes.Buffer)
+	otlp, _ := NewOTLPExporter(collector.URL)
+
+	tracer, _ := NewTracer("chat-server", Exporters{NewFileExporter(out), otlp})
+
+	span := tracer.StartRemote("", "chat.Chat/Login", "user", "Alice")
+	span.SetError(errors.New("oops"))
+	span.End()
+
+	var data SpanData
+	if err := json.Unmarshal(out.Bytes(), &data); err != nil || data.Name != "chat.Chat/Login" {
+		t.Errorf("File should contain span but got %q (%v)", out.String(), err)
+	}
+
+	if err := otlp.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var req otlpTraces
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("Request should contain single span but got %s", body)
+	}
+
+	sent := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if sent.Name != "chat.Chat/Login" || sent.Status.Code != 2 || sent.Attributes[0].Value.StringValue != "Alice" {
+		t.Errorf("Span should be failed login of Alice but got %+v", sent)
+	}
+
+	if req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "chat-server" {
+		t.Errorf("Service should be %s but got %s", "chat-server", body)
+	}
+}
+
+func TestOTLPRun(t *testing.T) {
+	var requests int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// collector is down for the first request
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer collector.Close()
+
+	otlp, _ := NewOTLPExporter(collector.URL)
+	tracer, _ := NewTracer("chat-server", otlp)
+
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	stopped := make(chan error)
+	go func() {
+		stopped <- otlp.Run(done, 10*time.Millisecond, func(err error) { errs <- err })
+	}()
+
+	tracer.StartRemote("", "chat.Chat/Login").End()
+
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Failed flush should be reported")
+	}
+
+	// exporter keeps running after the failure
+	tracer.StartRemote("", "chat.Chat/Login").End()
+
+	timeout := time.After(2 * time.Second)
+	for atomic.LoadInt32(&requests) < 2 {
+		select {
+		case err := <-stopped:
+			t.Fatalf("Run should not stop but got %v", err)
+		case <-timeout:
+			t.Fatal("Spans should be sent after failed flush")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	close(done)
+	if err := <-stopped; err != nil {
+		t.Errorf("Error should be nil but got %v", err)
+	}
+}
+
+func TestOTLPDropped(t *testing.T) {
+	var sent int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpTraces
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, rs := range req.ResourceSpans {
+			sent += len(rs.ScopeSpans[0].Spans)
+		}
+	}))
+	defer collector.Close()
+
+	otlp, _ := NewOTLPExporter(collector.URL)
+	tracer, _ := NewTracer("chat-server", otlp)
+
+	// nothing reads the queue until Flush
+	for i := 0; i < otlpQueueSize+5; i++ {
+		tracer.StartRemote("", "chat.Chat/Login").End()
+	}
+
+	if otlp.Dropped() != 5 {
+		t.Errorf("Dropped spans should be %d but got %d", 5, otlp.Dropped())
+	}
+
+	if err := otlp.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != otlpQueueSize {
+		t.Errorf("Sent spans should be %d but got %d", otlpQueueSize, sent)
+	}
+}